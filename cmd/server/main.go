@@ -3,10 +3,12 @@ package main
 import (
 	"errors"
 	"github.com/Piszmog/make-a-decision/internal/db"
+	"github.com/Piszmog/make-a-decision/internal/images"
 	"github.com/Piszmog/make-a-decision/internal/log"
 	"github.com/Piszmog/make-a-decision/internal/server"
 	"github.com/Piszmog/make-a-decision/internal/server/router"
 	"os"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 )
@@ -43,10 +45,28 @@ func main() {
 		port = "8080"
 	}
 
+	var routerOpts []router.Option
+	if value := os.Getenv("SPIN_DELAY"); value != "" {
+		spinDelay, err := time.ParseDuration(value)
+		if err != nil {
+			logger.Error("invalid spin delay", "spin_delay", value, "error", err)
+			return
+		}
+		routerOpts = append(routerOpts, router.WithSpinDelay(spinDelay))
+	}
+	if dir := os.Getenv("IMAGE_DIR"); dir != "" {
+		store, err := images.NewDirStore(dir)
+		if err != nil {
+			logger.Error("invalid image directory", "image_dir", dir, "error", err)
+			return
+		}
+		routerOpts = append(routerOpts, router.WithImageStore(store))
+	}
+
 	svr := server.New(
 		logger,
 		":"+port,
-		server.WithRouter(router.New(logger, database)),
+		server.WithRouter(router.New(logger, database, routerOpts...)),
 	)
 
 	svr.StartAndWait()
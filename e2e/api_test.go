@@ -0,0 +1,157 @@
+//go:build e2e
+
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/require"
+)
+
+type apiOption struct {
+	ID      int64    `json:"id"`
+	WheelID int64    `json:"wheel_id"`
+	Name    string   `json:"name"`
+	Weight  int64    `json:"weight"`
+	Tags    []string `json:"tags"`
+}
+
+type apiError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Test: API Lists Options On The Active Wheel
+func TestAPIListOptions(t *testing.T) {
+	beforeEach(t)
+
+	resp, err := page.Request().Get(getFullPath("/api/v1/options"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	var options []apiOption
+	require.NoError(t, resp.JSON(&options))
+
+	names := make([]string, len(options))
+	for i, opt := range options {
+		names[i] = opt.Name
+		require.Equal(t, int64(1), opt.WheelID)
+	}
+	require.Contains(t, names, "Video Games")
+	require.NotContains(t, names, "Tacos")
+}
+
+// Test: API Creates, Updates And Deletes An Option
+func TestAPIOptionLifecycle(t *testing.T) {
+	beforeEach(t)
+
+	resp, err := page.Request().Post(getFullPath("/api/v1/options"), playwright.APIRequestContextPostOptions{
+		Data: map[string]any{"name": "Kayaking", "weight": 4, "tags": []string{"Outdoor", "active"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.Status())
+
+	var created apiOption
+	require.NoError(t, resp.JSON(&created))
+	require.Equal(t, "Kayaking", created.Name)
+	require.Equal(t, int64(4), created.Weight)
+	require.Equal(t, []string{"outdoor", "active"}, created.Tags)
+
+	path := getFullPath(fmt.Sprintf("/api/v1/options/%d", created.ID))
+	resp, err = page.Request().Put(path, playwright.APIRequestContextPutOptions{
+		Data: map[string]any{"name": "Kayaking Trip", "weight": 2},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	var updated apiOption
+	require.NoError(t, resp.JSON(&updated))
+	require.Equal(t, "Kayaking Trip", updated.Name)
+	require.Empty(t, updated.Tags)
+
+	resp, err = page.Request().Delete(path)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.Status())
+
+	resp, err = page.Request().Get(path)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.Status())
+
+	var apiErr apiError
+	require.NoError(t, resp.JSON(&apiErr))
+	require.Equal(t, "not_found", apiErr.Error.Code)
+}
+
+// Test: API Rejects Invalid Options With A JSON Error
+func TestAPIValidationError(t *testing.T) {
+	beforeEach(t)
+
+	resp, err := page.Request().Post(getFullPath("/api/v1/options"), playwright.APIRequestContextPostOptions{
+		Data: map[string]any{"name": "Too Heavy", "weight": 101},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Status())
+
+	var apiErr apiError
+	require.NoError(t, resp.JSON(&apiErr))
+	require.Equal(t, "validation_failed", apiErr.Error.Code)
+	require.NotEmpty(t, apiErr.Error.Message)
+}
+
+// Test: API Requires Authentication
+func TestAPIRequiresAuth(t *testing.T) {
+	request, err := pw.Request.NewContext()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = request.Dispose() })
+
+	resp, err := request.Get(getFullPath("/api/v1/options"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.Status())
+
+	var apiErr apiError
+	require.NoError(t, resp.JSON(&apiErr))
+	require.Equal(t, "unauthorized", apiErr.Error.Code)
+}
+
+// Test: API Spin Returns The Selected Option And Its Odds
+func TestAPISpin(t *testing.T) {
+	beforeEach(t)
+
+	resp, err := page.Request().Post(getFullPath("/api/v1/spins"), playwright.APIRequestContextPostOptions{
+		Data: map[string]any{"strategy": "uniform", "time_constraint_minutes": 20},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	var result struct {
+		SpinID      int64     `json:"spin_id"`
+		Option      apiOption `json:"option"`
+		Probability float64   `json:"probability"`
+		Eligible    []struct {
+			Name string `json:"name"`
+		} `json:"eligible"`
+	}
+	require.NoError(t, resp.JSON(&result))
+	require.NotZero(t, result.SpinID)
+	require.Equal(t, "Meditation", result.Option.Name)
+	require.InDelta(t, 1.0, result.Probability, 0.0001)
+	require.Len(t, result.Eligible, 1)
+}
+
+// Test: OpenAPI Document Is Served
+func TestAPIOpenAPIDocument(t *testing.T) {
+	beforeEach(t)
+
+	resp, err := page.Request().Get(getFullPath("/api/v1/openapi.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	body, err := resp.Text()
+	require.NoError(t, err)
+	require.Contains(t, body, "openapi: 3.0.3")
+}
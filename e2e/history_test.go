@@ -0,0 +1,56 @@
+//go:build e2e
+
+package e2e_test
+
+import (
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/require"
+)
+
+// Test: Spins Are Recorded In History
+func TestDecisionHistory(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	// Make a decision
+	submitBtn := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"})
+	require.NoError(t, submitBtn.Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-card")).ToBeVisible())
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Skip", Exact: playwright.Bool(true)}).Click())
+
+	// Open the history modal
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "History"}).Click())
+	require.NoError(t, expect.Locator(page.GetByText("Decision History")).ToBeVisible())
+
+	// The spin should be listed
+	require.NoError(t, expect.Locator(page.Locator("#history-list [id^='spin-']").First()).ToBeVisible())
+}
+
+// Test: An Accepted Decision Can Be Marked Done And Rated From History
+func TestRateDecision(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"}).Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-card")).ToBeVisible())
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Let's do it"}).Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-accepted")).ToBeVisible())
+	require.NoError(t, page.GetByText("Got it!").Click())
+
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "History"}).Click())
+	row := page.Locator("#history-list [id^='spin-']").First()
+	require.NoError(t, expect.Locator(row).ToContainText("Accepted"))
+
+	require.NoError(t, row.GetByText("Mark done").Click())
+	require.NoError(t, row.Locator("input[name='rating'][value='4']").Check())
+	require.NoError(t, row.Locator("input[name='note']").Fill("Worth it"))
+	require.NoError(t, row.GetByRole("button", playwright.LocatorGetByRoleOptions{Name: "Save"}).Click())
+
+	require.NoError(t, expect.Locator(row).ToContainText("★★★★☆"))
+	require.NoError(t, expect.Locator(row).ToContainText("Worth it"))
+	require.NoError(t, expect.Locator(row.GetByText("Mark done")).ToHaveCount(0))
+}
@@ -3,6 +3,12 @@
 package e2e_test
 
 import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -104,7 +110,7 @@ func TestSingleTagFiltering(t *testing.T) {
 		require.True(t, hasValidResult, "Result should match gaming tag or have no tags, got: %s", resultText)
 
 		// Dismiss result
-		require.NoError(t, page.GetByText("Got it!").Click())
+		require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Skip", Exact: playwright.Bool(true)}).Click())
 	}
 }
 
@@ -144,7 +150,7 @@ func TestMultipleTagFilteringORLogic(t *testing.T) {
 		require.True(t, hasValidResult, "Result should match gaming OR outdoor tags OR have no tags, got: %s", resultText)
 
 		// Dismiss result
-		require.NoError(t, page.GetByText("Got it!").Click())
+		require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Skip", Exact: playwright.Bool(true)}).Click())
 	}
 }
 
@@ -182,7 +188,7 @@ func TestOptionsWithoutTagsAlwaysIncluded(t *testing.T) {
 		}
 
 		// Dismiss result
-		require.NoError(t, page.GetByText("Got it!").Click())
+		require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Skip", Exact: playwright.Bool(true)}).Click())
 	}
 
 	// Both should appear (Meditation has no tags, Running has "active" tag)
@@ -290,3 +296,353 @@ func TestTagFilterPersistence(t *testing.T) {
 	require.NoError(t, err)
 	require.Contains(t, indoorClass, "bg-purple-500", "Selected tag should persist after collapse/expand")
 }
+
+// Test: Result Distribution Respects Filters
+func TestResultDistributionRespectsFilters(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	// Limit to 1 hour (Video Games, Reading a Book, Going for a Run, Meditation)
+	require.NoError(t, page.GetByText("Add time constraint").Click())
+	require.NoError(t, page.Locator("#constraint-hours").Fill("1"))
+	require.NoError(t, page.Locator("#constraint-minutes").Fill("0"))
+
+	submitBtn := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"})
+	require.NoError(t, submitBtn.Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-card")).ToBeVisible())
+
+	// Only the options that fit the constraint are part of the distribution
+	require.NoError(t, expect.Locator(page.GetByText("Chosen from 4 options:")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.Locator("#distribution").GetByText("Watch Movie Marathon")).ToHaveCount(0))
+}
+
+// Test: All Tag Mode Requires Every Selected Tag
+func TestAllTagMode(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	require.NoError(t, page.GetByText("Filter by tags").Click())
+	require.NoError(t, page.Locator("button.tag-pill[data-tag-name='indoor']").Click())
+	require.NoError(t, page.Locator("button.tag-pill[data-tag-name='relaxing']").Click())
+	_, err = page.Locator("#tag-mode").SelectOption(playwright.SelectOptionValues{
+		Values: playwright.StringSlice("all"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, page.GetByLabel("Include untagged options").Uncheck())
+
+	submitBtn := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"})
+	require.NoError(t, submitBtn.Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-card")).ToBeVisible())
+
+	// Only Reading a Book and Watch Movie Marathon are both indoor and relaxing
+	require.NoError(t, expect.Locator(page.GetByText("Chosen from 2 options:")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.Locator("#distribution").GetByText("Meditation")).ToHaveCount(0))
+}
+
+// Test: Excluded Tags Are Never Picked
+func TestExcludedTags(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	require.NoError(t, page.GetByText("Filter by tags").Click())
+	excludeIndoor := page.Locator("button.tag-exclude[data-tag-name='indoor']")
+	require.NoError(t, excludeIndoor.Click())
+	require.NoError(t, expect.Locator(excludeIndoor).ToHaveAttribute("aria-pressed", "true"))
+
+	submitBtn := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"})
+	require.NoError(t, submitBtn.Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-card")).ToBeVisible())
+
+	// Going for a Run and the untagged Meditation are the only options without the indoor tag
+	require.NoError(t, expect.Locator(page.GetByText("Chosen from 2 options:")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.Locator("#distribution").GetByText("Video Games")).ToHaveCount(0))
+
+	// Including a tag clears its exclusion
+	require.NoError(t, page.Locator("button.tag-pill[data-tag-name='indoor']").Click())
+	require.NoError(t, expect.Locator(excludeIndoor).ToHaveAttribute("aria-pressed", "false"))
+}
+
+// Test: Tag Expression Filtering
+func TestTagExpression(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	require.NoError(t, page.GetByText("Filter by tags").Click())
+	require.NoError(t, page.Locator("#tag-expression").Fill("(gaming or outdoor) and not active"))
+
+	submitBtn := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"})
+	require.NoError(t, submitBtn.Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-card")).ToBeVisible())
+
+	// Video Games and Board Games are the only gaming or outdoor options that are not active
+	require.NoError(t, expect.Locator(page.GetByText("Chosen from 2 options:")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.Locator("#distribution").GetByText("Going for a Run")).ToHaveCount(0))
+}
+
+// Test: Invalid Tag Expression Shows An Error
+func TestInvalidTagExpression(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	require.NoError(t, page.GetByText("Filter by tags").Click())
+	require.NoError(t, page.Locator("#tag-expression").Fill("gaming and"))
+
+	submitBtn := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"})
+	require.NoError(t, submitBtn.Click())
+	require.NoError(t, expect.Locator(page.Locator("#tag-expression-error")).ToHaveText("unexpected end of expression at position 11"))
+}
+
+// Test: One Spin Can Pick Several Distinct Options
+func TestPickSeveralOptions(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	require.NoError(t, page.Locator("#pick-count").Fill("3"))
+
+	submitBtn := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"})
+	require.NoError(t, submitBtn.Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-card")).ToBeVisible())
+
+	require.NoError(t, expect.Locator(page.GetByText("Your Picks:")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.Locator("#result-picks li")).ToHaveCount(3))
+	require.NoError(t, expect.Locator(page.Locator("#distribution .font-medium")).ToHaveCount(3))
+}
+
+func TestEliminationRound(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	_, err = page.Locator("#strategy").SelectOption(playwright.SelectOptionValues{
+		Values: playwright.StringSlice("elimination"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, expect.Locator(page.Locator("#elimination-section")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.Locator("#pick-count")).ToBeDisabled())
+
+	submitBtn := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"})
+	require.NoError(t, submitBtn.Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-card")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.GetByText("Knocked Out:")).ToBeVisible())
+
+	// The round panel refreshes to list the option that was knocked out
+	require.NoError(t, expect.Locator(page.Locator("#elimination-drawn li")).ToHaveCount(1))
+
+	page.OnDialog(func(dialog playwright.Dialog) {
+		_ = dialog.Accept()
+	})
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "New round"}).Click())
+	require.NoError(t, expect.Locator(page.GetByText("No round in progress")).ToBeVisible())
+}
+
+func TestProvablyFairSpin(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	// The server seed is made once the section is shown, and its hash is there before the spin
+	require.NoError(t, page.Locator("#fair").Check())
+	require.NoError(t, expect.Locator(page.Locator("#fair-section")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.Locator("#server-seed-hash")).ToBeVisible())
+	commitment, err := page.Locator("#server-seed-hash").TextContent()
+	require.NoError(t, err)
+	require.NoError(t, page.Locator("#client-seed").Fill("e2e"))
+
+	submitBtn := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"})
+	require.NoError(t, submitBtn.Click())
+	require.NoError(t, expect.Locator(page.Locator("#result-proof")).ToBeAttached())
+	require.NoError(t, expect.Locator(page.Locator("#result-proof")).ToContainText(commitment))
+
+	// A new seed is committed to for the next spin
+	require.NoError(t, expect.Locator(page.Locator("#server-seed-hash")).Not().ToHaveText(commitment))
+}
+
+func TestCheckTheOdds(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	_, err = page.Locator("#strategy").SelectOption(playwright.SelectOptionValues{
+		Values: playwright.StringSlice("uniform"),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, page.GetByRole("link", playwright.PageGetByRoleOptions{Name: "Check the odds"}).Click())
+	require.NoError(t, expect.Page(page).ToHaveURL(regexp.MustCompile(`/odds\?.*strategy=uniform`)))
+	require.NoError(t, expect.Locator(page.Locator("#odds-simulation #odds-verdict")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.Locator("#odds-history")).ToBeVisible())
+}
+
+func TestWeightNever(t *testing.T) {
+	beforeEach(t)
+
+	resp, err := page.Request().Post(getFullPath("/api/v1/options"), playwright.APIRequestContextPostOptions{
+		Data: map[string]any{"name": "Skydiving", "weight": 40},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.Status())
+	var created apiOption
+	require.NoError(t, resp.JSON(&created))
+	t.Cleanup(func() {
+		_, _ = page.Request().Delete(getFullPath(fmt.Sprintf("/api/v1/options/%d", created.ID)))
+	})
+
+	_, err = page.Goto(getFullPath(""))
+	require.NoError(t, err)
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Manage options"}).Click())
+
+	row := page.Locator(fmt.Sprintf("#option-%d", created.ID))
+	require.NoError(t, row.Locator(fmt.Sprintf("button[hx-get='/expand-option/%d']", created.ID)).Click())
+	weight := row.Locator("input[name='weight']")
+	require.NoError(t, expect.Locator(weight).ToHaveValue("40"))
+
+	// The buttons step the weight down by one
+	require.NoError(t, row.GetByRole("button", playwright.LocatorGetByRoleOptions{Name: "−"}).Click())
+	require.NoError(t, expect.Locator(weight).ToHaveValue("39"))
+
+	require.NoError(t, weight.Fill("0"))
+	require.NoError(t, row.GetByRole("button", playwright.LocatorGetByRoleOptions{Name: "Save Changes"}).Click())
+	require.NoError(t, expect.Locator(page.Locator(fmt.Sprintf("#option-%d", created.ID))).ToContainText("Never"))
+}
+
+func TestAdaptiveWeights(t *testing.T) {
+	beforeEach(t)
+	t.Cleanup(func() {
+		_, _ = page.Request().Post(getFullPath("/api/wheels/decay"), playwright.APIRequestContextPostOptions{
+			Form: map[string]any{"half_life": ""},
+		})
+	})
+
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Manage options"}).Click())
+	require.NoError(t, expect.Locator(page.Locator("#options-list .option-effective-chance")).ToHaveCount(0))
+
+	require.NoError(t, page.GetByText("Adaptive weights").Click())
+	require.NoError(t, page.Locator("#decay-half-life").Fill("2"))
+	_, err = page.Locator("select[name='half_life_unit']").SelectOption(playwright.SelectOptionValues{
+		Values: playwright.StringSlice("hours"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, page.Locator(".adaptive-weights").GetByRole("button", playwright.LocatorGetByRoleOptions{Name: "Save"}).Click())
+
+	// Every option shows its chance after recent picks next to the chance its weight gives it
+	require.NoError(t, expect.Locator(page.Locator("#options-list .option-effective-chance").First()).ToBeVisible())
+}
+
+func TestRerollWithVetoLimit(t *testing.T) {
+	beforeEach(t)
+	t.Cleanup(func() {
+		_, _ = page.Request().Post(getFullPath("/api/wheels/veto-limit"), playwright.APIRequestContextPostOptions{
+			Form: map[string]any{"veto_limit": ""},
+		})
+	})
+
+	// Settle the last decision so the first spin below starts a new round instead of rerolling it
+	resp, err := page.Request().Post(getFullPath("/api/v1/spins"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+	var spun struct {
+		SpinID int64 `json:"spin_id"`
+	}
+	require.NoError(t, resp.JSON(&spun))
+	resp, err = page.Request().Post(getFullPath(fmt.Sprintf("/api/v1/spins/%d/outcome", spun.SpinID)), playwright.APIRequestContextPostOptions{
+		Data: map[string]any{"outcome": "skipped"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	_, err = page.Goto(getFullPath(""))
+	require.NoError(t, err)
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Manage options"}).Click())
+	require.NoError(t, page.GetByText("Vetoes", playwright.PageGetByTextOptions{Exact: playwright.Bool(true)}).Click())
+	require.NoError(t, page.Locator("#veto-limit").Fill("1"))
+	require.NoError(t, page.Locator("#veto-settings").GetByRole("button", playwright.LocatorGetByRoleOptions{Name: "Save"}).Click())
+	require.NoError(t, expect.Locator(page.Locator("#veto-limit-saved")).ToBeVisible())
+
+	_, err = page.Goto(getFullPath(""))
+	require.NoError(t, err)
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Make a decision"}).Click())
+	require.NoError(t, expect.Locator(page.Locator("#vetoes-left")).ToHaveText("1 veto left this round"))
+
+	// The reroll uses up the round's only veto, so the new decision sticks
+	reroll := page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Reroll"})
+	require.NoError(t, reroll.Click())
+	require.NoError(t, expect.Locator(page.Locator("#vetoes-left")).ToHaveText("No vetoes left this round"))
+	require.NoError(t, expect.Locator(reroll).ToBeDisabled())
+}
+
+func TestOptionDescription(t *testing.T) {
+	beforeEach(t)
+
+	resp, err := page.Request().Post(getFullPath("/api/v1/options"), playwright.APIRequestContextPostOptions{
+		Data: map[string]any{"name": "Try a new recipe"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.Status())
+	var created apiOption
+	require.NoError(t, resp.JSON(&created))
+	t.Cleanup(func() {
+		_, _ = page.Request().Delete(getFullPath(fmt.Sprintf("/api/v1/options/%d", created.ID)))
+	})
+
+	_, err = page.Goto(getFullPath(""))
+	require.NoError(t, err)
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Manage options"}).Click())
+
+	row := page.Locator(fmt.Sprintf("#option-%d", created.ID))
+	require.NoError(t, row.Locator(fmt.Sprintf("button[hx-get='/expand-option/%d']", created.ID)).Click())
+	require.NoError(t, row.Locator("textarea[name='description']").Fill("[Pasta](https://example.com/pasta) <script>alert(1)</script>"))
+
+	// The description is previewed as it is typed, without the script
+	preview := row.Locator(fmt.Sprintf("#description-preview-%d", created.ID))
+	link := preview.GetByRole("link", playwright.LocatorGetByRoleOptions{Name: "Pasta"})
+	require.NoError(t, expect.Locator(link).ToHaveAttribute("href", "https://example.com/pasta"))
+	require.NoError(t, expect.Locator(preview.Locator("script")).ToHaveCount(0))
+
+	require.NoError(t, row.GetByRole("button", playwright.LocatorGetByRoleOptions{Name: "Save Changes"}).Click())
+	require.NoError(t, expect.Locator(page.Locator(fmt.Sprintf("#option-%d", created.ID))).ToContainText("📝"))
+}
+
+func TestOptionAttachments(t *testing.T) {
+	beforeEach(t)
+
+	resp, err := page.Request().Post(getFullPath("/api/v1/options"), playwright.APIRequestContextPostOptions{
+		Data: map[string]any{"name": "Order pizza", "links": []string{"https://www.example.com/menu"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.Status())
+	var created apiOption
+	require.NoError(t, resp.JSON(&created))
+	t.Cleanup(func() {
+		_, _ = page.Request().Delete(getFullPath(fmt.Sprintf("/api/v1/options/%d", created.ID)))
+	})
+
+	_, err = page.Goto(getFullPath(""))
+	require.NoError(t, err)
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Manage options"}).Click())
+
+	row := page.Locator(fmt.Sprintf("#option-%d", created.ID))
+	link := row.GetByRole("link", playwright.LocatorGetByRoleOptions{Name: "example.com"})
+	require.NoError(t, expect.Locator(link).ToHaveAttribute("href", "https://www.example.com/menu"))
+	require.NoError(t, expect.Locator(link).ToHaveAttribute("target", "_blank"))
+
+	var file bytes.Buffer
+	require.NoError(t, png.Encode(&file, image.NewRGBA(image.Rect(0, 0, 640, 480))))
+	require.NoError(t, row.Locator(fmt.Sprintf("button[hx-get='/expand-option/%d']", created.ID)).Click())
+	require.NoError(t, row.Locator("input[name='image']").SetInputFiles(playwright.InputFile{Name: "pizza.png", MimeType: "image/png", Buffer: file.Bytes()}))
+
+	// The image is saved as soon as it is chosen and its thumbnail is served to the signed in user
+	thumbnail := page.Locator(fmt.Sprintf("#image-input-%d img", created.ID))
+	require.NoError(t, expect.Locator(thumbnail).ToBeVisible())
+	require.NoError(t, expect.Locator(thumbnail).ToHaveJSProperty("naturalWidth", 320))
+
+	require.NoError(t, row.GetByRole("button", playwright.LocatorGetByRoleOptions{Name: "Save Changes"}).Click())
+	require.NoError(t, expect.Locator(page.Locator(fmt.Sprintf("#option-%d .option-thumbnail", created.ID))).ToBeVisible())
+}
@@ -0,0 +1,56 @@
+//go:build e2e
+
+package e2e_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/require"
+)
+
+// Test: A Read Only API Token Can Read But Not Write
+func TestAPITokenScopes(t *testing.T) {
+	beforeEach(t)
+	_, err := page.Goto(getFullPath("/settings"))
+	require.NoError(t, err)
+
+	require.NoError(t, page.Locator("input[name='name']").Fill("Reader"))
+	_, err = page.Locator("select[name='scope']").SelectOption(playwright.SelectOptionValues{
+		Values: playwright.StringSlice("read"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Create"}).Click())
+	require.NoError(t, expect.Locator(page.Locator("#new-token code")).ToContainText("mad_"))
+
+	token, err := page.Locator("#new-token code").TextContent()
+	require.NoError(t, err)
+
+	request, err := pw.Request.NewContext(playwright.APIRequestNewContextOptions{
+		ExtraHttpHeaders: map[string]string{"Authorization": "Bearer " + token},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = request.Dispose() })
+
+	resp, err := request.Get(getFullPath("/api/v1/options"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	resp, err = request.Post(getFullPath("/api/v1/options"), playwright.APIRequestContextPostOptions{
+		Data: map[string]any{"name": "Sneaky"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.Status())
+
+	// Revoked tokens stop working
+	page.OnDialog(func(dialog playwright.Dialog) {
+		_ = dialog.Accept()
+	})
+	require.NoError(t, page.Locator("#tokens").GetByRole("button", playwright.LocatorGetByRoleOptions{Name: "Revoke"}).First().Click())
+	require.NoError(t, expect.Locator(page.Locator("#tokens").GetByText("Reader")).ToHaveCount(0))
+
+	resp, err = request.Get(getFullPath("/api/v1/options"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.Status())
+}
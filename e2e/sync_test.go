@@ -0,0 +1,61 @@
+//go:build e2e
+
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/require"
+)
+
+type syncResponse struct {
+	Created int `json:"created"`
+	Merged  int `json:"merged"`
+	Skipped int `json:"skipped"`
+}
+
+// Test: Syncing The Same Local Options Twice Does Not Duplicate Them
+func TestSyncLocalOptionsIsIdempotent(t *testing.T) {
+	beforeEach(t)
+
+	body := map[string]any{"options": []map[string]any{
+		{"id": "local-1-1", "text": "Stargazing", "weight": 2, "duration": 600, "tags": []string{"night"}},
+		{"id": "local-1-2", "text": "stargazing", "weight": 1, "duration": 600, "tags": []string{"outdoor"}},
+	}}
+
+	sync := func() syncResponse {
+		resp, err := page.Request().Post(getFullPath("/api/sync-local-options"), playwright.APIRequestContextPostOptions{
+			Data: body,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.Status())
+
+		var result syncResponse
+		require.NoError(t, resp.JSON(&result))
+		return result
+	}
+
+	require.Equal(t, syncResponse{Created: 1, Merged: 1}, sync())
+	require.Equal(t, syncResponse{Skipped: 2}, sync())
+
+	resp, err := page.Request().Get(getFullPath("/api/v1/options"))
+	require.NoError(t, err)
+	var options []apiOption
+	require.NoError(t, resp.JSON(&options))
+
+	var matches []apiOption
+	for _, opt := range options {
+		if opt.Name == "Stargazing" {
+			matches = append(matches, opt)
+		}
+	}
+	require.Len(t, matches, 1)
+	require.Equal(t, []string{"night", "outdoor"}, matches[0].Tags)
+
+	resp, err = page.Request().Delete(getFullPath(fmt.Sprintf("/api/v1/options/%d", matches[0].ID)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.Status())
+}
@@ -1,9 +1,13 @@
 -- Seed data for E2E testing
 
 -- Clear existing data
+DELETE FROM spins;
 DELETE FROM option_tags;
 DELETE FROM tags;
 DELETE FROM options;
+DELETE FROM wheels;
+DELETE FROM synced_local_options;
+DELETE FROM api_tokens;
 DELETE FROM sessions;
 DELETE FROM users;
 
@@ -12,22 +16,34 @@ DELETE FROM users;
 INSERT INTO users (id, email, password_hash, created_at) VALUES 
 (1, 'test@example.com', '$2a$10$08Tf43MlgLm0FkwgpH3I.uo8wp92YOfhnNhZq2oaRVmrHT2T96alG', datetime('now'));
 
+-- Create the test user's wheels
+INSERT INTO wheels (id, user_id, name, created_at) VALUES
+(1, 1, 'My Wheel', datetime('now')),
+(2, 1, 'Lunch', datetime('now'));
+
+UPDATE users SET active_wheel_id = 1 WHERE id = 1;
+
 -- Create options with various tag combinations for testing
-INSERT INTO options (name, bio, duration_minutes, weight, user_id, created_at) VALUES 
-('Video Games', NULL, 60, 5, 1, datetime('now')),
-('Reading a Book', NULL, 30, 3, 1, datetime('now')),
-('Going for a Run', NULL, 45, 2, 1, datetime('now')),
-('Meditation', NULL, 15, 1, 1, datetime('now')),
-('Watch Movie Marathon', NULL, 180, 1, 1, datetime('now')),
-('Board Games', NULL, 90, 4, 1, datetime('now'));
+INSERT INTO options (name, bio, duration_minutes, weight, user_id, wheel_id, created_at) VALUES 
+('Video Games', NULL, 60, 5, 1, 1, datetime('now')),
+('Reading a Book', NULL, 30, 3, 1, 1, datetime('now')),
+('Going for a Run', NULL, 45, 2, 1, 1, datetime('now')),
+('Meditation', NULL, 15, 1, 1, 1, datetime('now')),
+('Watch Movie Marathon', NULL, 180, 1, 1, 1, datetime('now')),
+('Board Games', NULL, 90, 4, 1, 1, datetime('now'));
+
+-- Options on the second wheel
+INSERT INTO options (name, bio, duration_minutes, weight, user_id, wheel_id, created_at) VALUES
+('Tacos', NULL, 30, 1, 1, 2, datetime('now')),
+('Ramen', NULL, 45, 1, 1, 2, datetime('now'));
 
 -- Create tags
-INSERT INTO tags (name, user_id, created_at) VALUES
-('indoor', 1, datetime('now')),
-('outdoor', 1, datetime('now')),
-('gaming', 1, datetime('now')),
-('relaxing', 1, datetime('now')),
-('active', 1, datetime('now'));
+INSERT INTO tags (name, user_id, wheel_id, created_at) VALUES
+('indoor', 1, 1, datetime('now')),
+('outdoor', 1, 1, datetime('now')),
+('gaming', 1, 1, datetime('now')),
+('relaxing', 1, 1, datetime('now')),
+('active', 1, 1, datetime('now'));
 
 -- Associate options with tags
 -- Option 1: Video Games - gaming, indoor
@@ -0,0 +1,166 @@
+//go:build e2e
+
+package e2e_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/require"
+)
+
+type importReport struct {
+	Total    int `json:"total"`
+	Imported int `json:"imported"`
+	Valid    []struct {
+		Name string `json:"name"`
+	} `json:"valid"`
+	Rejected []struct {
+		Row   int    `json:"row"`
+		Error string `json:"error"`
+	} `json:"rejected"`
+}
+
+// Test: Exporting As CSV Includes Weights, Durations, Tags And Descriptions
+func TestExportCSV(t *testing.T) {
+	beforeEach(t)
+
+	resp, err := page.Request().Get(getFullPath("/api/v1/export"), playwright.APIRequestContextGetOptions{
+		Params: map[string]any{"format": "csv"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+	require.Contains(t, resp.Headers()["content-disposition"], "my-wheel-options.csv")
+
+	body, err := resp.Text()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(body, "name,duration_minutes,weight,tags,description,links,cooldown_minutes,availability\n"))
+	require.Contains(t, body, "Video Games")
+	require.NotContains(t, body, "Tacos")
+}
+
+// Test: Import Preview Reports Rejected Rows Without Saving
+func TestImportPreview(t *testing.T) {
+	beforeEach(t)
+
+	csv := "name,duration_minutes,weight,tags\nPicnic,90,2,outdoor\nMarathon,2000,1,\nHeavy,10,101,\n"
+	resp, err := page.Request().Post(getFullPath("/api/v1/import/preview"), playwright.APIRequestContextPostOptions{
+		Params:  map[string]any{"format": "csv"},
+		Headers: map[string]string{"Content-Type": "text/csv"},
+		Data:    csv,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	var report importReport
+	require.NoError(t, resp.JSON(&report))
+	require.Equal(t, 3, report.Total)
+	require.Zero(t, report.Imported)
+	require.Len(t, report.Valid, 1)
+	require.Equal(t, "Picnic", report.Valid[0].Name)
+	require.Len(t, report.Rejected, 2)
+	require.Equal(t, 2, report.Rejected[0].Row)
+	require.Equal(t, 3, report.Rejected[1].Row)
+
+	resp, err = page.Request().Get(getFullPath("/api/v1/options"))
+	require.NoError(t, err)
+	var options []apiOption
+	require.NoError(t, resp.JSON(&options))
+	for _, opt := range options {
+		require.NotEqual(t, "Picnic", opt.Name)
+	}
+}
+
+// Test: Importing YAML Creates The Valid Options
+func TestImportYAML(t *testing.T) {
+	beforeEach(t)
+
+	yaml := "options:\n  - name: Brunch\n    duration_minutes: 60\n    weight: 3\n    tags: [weekend]\n  - name: \"\"\n"
+	resp, err := page.Request().Post(getFullPath("/api/v1/import"), playwright.APIRequestContextPostOptions{
+		Params:  map[string]any{"wheel_id": 2},
+		Headers: map[string]string{"Content-Type": "application/yaml"},
+		Data:    yaml,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	var report importReport
+	require.NoError(t, resp.JSON(&report))
+	require.Equal(t, 1, report.Imported)
+	require.Len(t, report.Rejected, 1)
+
+	resp, err = page.Request().Get(getFullPath("/api/v1/options"), playwright.APIRequestContextGetOptions{
+		Params: map[string]any{"wheel_id": 2},
+	})
+	require.NoError(t, err)
+	var options []apiOption
+	require.NoError(t, resp.JSON(&options))
+
+	var brunch *apiOption
+	for i := range options {
+		if options[i].Name == "Brunch" {
+			brunch = &options[i]
+		}
+	}
+	require.NotNil(t, brunch)
+	require.Equal(t, int64(3), brunch.Weight)
+	require.Equal(t, []string{"weekend"}, brunch.Tags)
+
+	resp, err = page.Request().Delete(getFullPath(fmt.Sprintf("/api/v1/options/%d", brunch.ID)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.Status())
+}
+
+// Test: Cooldowns And Availability Survive An Import And Export
+func TestTransferCooldownAndAvailability(t *testing.T) {
+	beforeEach(t)
+
+	csv := "name,cooldown_minutes,availability\nLunch Out,1440,\"mon,fri 11:00-14:00; sat,sun\"\nBad Hours,,mon 11:00\n"
+	resp, err := page.Request().Post(getFullPath("/api/v1/import"), playwright.APIRequestContextPostOptions{
+		Params:  map[string]any{"wheel_id": 2},
+		Headers: map[string]string{"Content-Type": "text/csv"},
+		Data:    csv,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	var report importReport
+	require.NoError(t, resp.JSON(&report))
+	require.Equal(t, 1, report.Imported)
+	require.Len(t, report.Rejected, 1)
+	require.Equal(t, 2, report.Rejected[0].Row)
+
+	export := func(format string) string {
+		resp, err := page.Request().Get(getFullPath("/api/v1/export"), playwright.APIRequestContextGetOptions{
+			Params: map[string]any{"wheel_id": 2, "format": format},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.Status())
+		body, err := resp.Text()
+		require.NoError(t, err)
+		return body
+	}
+
+	require.Contains(t, export("csv"), "Lunch Out,,1,,,,1440,\"mon,fri 11:00-14:00; sun,sat\"\n")
+	require.Contains(t, export("json"), `"cooldown_minutes": 1440`)
+	yaml := export("yaml")
+	require.Contains(t, yaml, "cooldown_minutes: 1440")
+	require.Contains(t, yaml, "- days: [mon, fri]\n        start: \"11:00\"\n        end: \"14:00\"\n      - days: [sun, sat]\n")
+
+	resp, err = page.Request().Get(getFullPath("/api/v1/options"), playwright.APIRequestContextGetOptions{
+		Params: map[string]any{"wheel_id": 2},
+	})
+	require.NoError(t, err)
+	var options []apiOption
+	require.NoError(t, resp.JSON(&options))
+	for _, opt := range options {
+		if opt.Name == "Lunch Out" {
+			resp, err = page.Request().Delete(getFullPath(fmt.Sprintf("/api/v1/options/%d", opt.ID)))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusNoContent, resp.Status())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+//go:build e2e
+
+package e2e_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreActiveWheel switches back to the seeded wheel once the test is done
+func restoreActiveWheel(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		resp, err := page.Request().Post(getFullPath("/api/wheels/switch"), playwright.APIRequestContextPostOptions{
+			Form: map[string]any{"wheel_id": "1"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.Status())
+	})
+}
+
+// Test: Switching Wheels Shows That Wheel's Options
+func TestSwitchWheel(t *testing.T) {
+	beforeEach(t)
+	restoreActiveWheel(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	// Switch to the lunch wheel
+	_, err = page.Locator(".wheel-switcher select[name='wheel_id']").SelectOption(playwright.SelectOptionValues{
+		Labels: playwright.StringSlice("Lunch"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, expect.Locator(page.Locator(".wheel-switcher select[name='wheel_id'] option[selected]")).ToHaveText("Lunch"))
+
+	// Only the lunch options should be listed
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Manage options"}).Click())
+	require.NoError(t, expect.Locator(page.Locator("#options-list").GetByText("Tacos")).ToBeVisible())
+	require.NoError(t, expect.Locator(page.Locator("#options-list").GetByText("Video Games")).ToHaveCount(0))
+}
+
+// Test: Creating A Wheel Starts It Empty
+func TestCreateWheel(t *testing.T) {
+	beforeEach(t)
+	restoreActiveWheel(t)
+	_, err := page.Goto(getFullPath(""))
+	require.NoError(t, err)
+
+	page.OnDialog(func(dialog playwright.Dialog) {
+		_ = dialog.Accept("Weekend")
+	})
+	require.NoError(t, page.Locator(".wheel-switcher").GetByRole("button", playwright.LocatorGetByRoleOptions{Name: "New"}).Click())
+	require.NoError(t, expect.Locator(page.Locator(".wheel-switcher select[name='wheel_id'] option[selected]")).ToHaveText("Weekend"))
+
+	require.NoError(t, page.GetByRole("button", playwright.PageGetByRoleOptions{Name: "Manage options"}).Click())
+	require.NoError(t, expect.Locator(page.Locator("#options-list [id^='option-']")).ToHaveCount(0))
+}
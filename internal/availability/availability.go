@@ -0,0 +1,293 @@
+// Package availability describes when an option can come up on a spin, such as weekdays from 11:00 to 14:00 or weekends.
+//
+// Rules are a list of windows. An option with no windows is always available, otherwise it is available when any
+// of its windows covers the time. Windows are evaluated in the wall clock time of the user's time zone.
+package availability
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxWindows is the most windows an option can have
+const MaxWindows = 7
+
+var (
+	// ErrTooManyWindows is returned when rules have more than MaxWindows windows
+	ErrTooManyWindows = fmt.Errorf("an option can have at most %d availability windows", MaxWindows)
+	// ErrNoDays is returned when a window is not on any day of the week
+	ErrNoDays = errors.New("availability window must include at least one day")
+	// ErrPartialHours is returned when a window has a start time without an end time, or the other way around
+	ErrPartialHours = errors.New("availability window needs both a start and an end time")
+	// ErrEmptyHours is returned when a window starts and ends at the same time
+	ErrEmptyHours = errors.New("availability window must end at a different time than it starts")
+)
+
+// Days is a set of days of the week
+type Days uint8
+
+const (
+	// Weekdays is Monday to Friday
+	Weekdays Days = 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday
+	// Weekends is Saturday and Sunday
+	Weekends Days = 1<<time.Saturday | 1<<time.Sunday
+	// EveryDay is every day of the week
+	EveryDay = Weekdays | Weekends
+)
+
+// dayNames are the names days are written as in JSON, indexed by time.Weekday
+var dayNames = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// NewDays returns the set of the days
+func NewDays(days ...time.Weekday) Days {
+	var d Days
+	for _, day := range days {
+		d |= 1 << day
+	}
+	return d
+}
+
+// Has reports whether the day is in the set
+func (d Days) Has(day time.Weekday) bool {
+	return d&(1<<day) != 0
+}
+
+// String describes the days, such as Weekdays or Mon, Wed, Fri
+func (d Days) String() string {
+	switch d {
+	case EveryDay:
+		return "Every day"
+	case Weekdays:
+		return "Weekdays"
+	case Weekends:
+		return "Weekends"
+	}
+
+	// List the days starting from Monday
+	var names []string
+	for i := range 7 {
+		day := time.Weekday((i + 1) % 7)
+		if d.Has(day) {
+			names = append(names, day.String()[:3])
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Names returns the short names of the days, such as mon, starting from Sunday
+func (d Days) Names() []string {
+	names := []string{}
+	for day, name := range dayNames {
+		if d.Has(time.Weekday(day)) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (d Days) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Names())
+}
+
+func (d *Days) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return errors.New("days must be a list of day names")
+	}
+
+	*d = 0
+	for _, name := range names {
+		day, ok := parseDay(name)
+		if !ok {
+			return fmt.Errorf("unknown day %q, expected one of %s", name, strings.Join(dayNames[:], ", "))
+		}
+		*d |= 1 << day
+	}
+	return nil
+}
+
+// parseDay parses a day name such as mon or Monday
+func parseDay(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day, short := range dayNames {
+		if name == short || name == strings.ToLower(time.Weekday(day).String()) {
+			return time.Weekday(day), true
+		}
+	}
+	return 0, false
+}
+
+// Clock is a time of day in minutes after midnight. 24:00 is allowed as the end of a window.
+type Clock int
+
+// ParseClock parses a time of day written as HH:MM
+func ParseClock(s string) (Clock, error) {
+	if len(s) != 5 || s[2] != ':' || strings.Trim(s[:2]+s[3:], "0123456789") != "" {
+		return 0, fmt.Errorf("time %q must be written as HH:MM", s)
+	}
+	hours := int(s[0]-'0')*10 + int(s[1]-'0')
+	minutes := int(s[3]-'0')*10 + int(s[4]-'0')
+	if hours > 24 || minutes > 59 || (hours == 24 && minutes != 0) {
+		return 0, fmt.Errorf("time %q is not a time of day", s)
+	}
+	return Clock(hours*60 + minutes), nil
+}
+
+// String returns the time as HH:MM
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c/60, c%60)
+}
+
+// Window is when an option is available. A window without hours lasts all day.
+// A window that ends before it starts runs overnight into the next day.
+type Window struct {
+	Days  Days
+	Start Clock
+	End   Clock
+	// AllDay is set when the window has no hours
+	AllDay bool
+}
+
+// windowJSON is how a window is written in JSON. Start and end are left out for all day windows.
+type windowJSON struct {
+	Days  Days   `json:"days"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+func (w Window) MarshalJSON() ([]byte, error) {
+	out := windowJSON{Days: w.Days}
+	if !w.AllDay {
+		out.Start = w.Start.String()
+		out.End = w.End.String()
+	}
+	return json.Marshal(out)
+}
+
+func (w *Window) UnmarshalJSON(data []byte) error {
+	var in windowJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	window, err := NewWindow(in.Days, in.Start, in.End)
+	if err != nil {
+		return err
+	}
+	*w = window
+	return nil
+}
+
+// NewWindow returns a window on the days between the start and end times written as HH:MM.
+// Leaving both times blank makes the window last all day.
+func NewWindow(days Days, start, end string) (Window, error) {
+	if days == 0 {
+		return Window{}, ErrNoDays
+	}
+
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	if start == "" && end == "" {
+		return Window{Days: days, AllDay: true}, nil
+	}
+	if start == "" || end == "" {
+		return Window{}, ErrPartialHours
+	}
+
+	startClock, err := ParseClock(start)
+	if err != nil {
+		return Window{}, err
+	}
+	if startClock == 24*60 {
+		return Window{}, fmt.Errorf("time %q cannot start a window", start)
+	}
+	endClock, err := ParseClock(end)
+	if err != nil {
+		return Window{}, err
+	}
+	if startClock == endClock {
+		return Window{}, ErrEmptyHours
+	}
+	if startClock == 0 && endClock == 24*60 {
+		return Window{Days: days, AllDay: true}, nil
+	}
+	return Window{Days: days, Start: startClock, End: endClock}, nil
+}
+
+// Allows reports whether the window covers the wall clock time of t
+func (w Window) Allows(t time.Time) bool {
+	day := t.Weekday()
+	if w.AllDay {
+		return w.Days.Has(day)
+	}
+
+	now := Clock(t.Hour()*60 + t.Minute())
+	if w.Start < w.End {
+		return w.Days.Has(day) && now >= w.Start && now < w.End
+	}
+
+	// Overnight windows belong to the day they start on
+	if now >= w.Start {
+		return w.Days.Has(day)
+	}
+	if now < w.End {
+		return w.Days.Has((day + 6) % 7)
+	}
+	return false
+}
+
+// String describes the window, such as Weekdays 11:00–14:00
+func (w Window) String() string {
+	if w.AllDay {
+		return w.Days.String()
+	}
+	return fmt.Sprintf("%s %s–%s", w.Days, w.Start, w.End)
+}
+
+// Rules are the windows an option is available in
+type Rules []Window
+
+// Parse parses rules written as a JSON list of windows. Blank input has no windows.
+func Parse(data string) (Rules, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+
+	var rules Rules
+	if err := json.Unmarshal([]byte(data), &rules); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+			return nil, errors.New("availability must be a list of windows")
+		}
+		return nil, err
+	}
+	if len(rules) > MaxWindows {
+		return nil, ErrTooManyWindows
+	}
+	return rules, nil
+}
+
+// Allows reports whether an option with the rules can come up at t. Rules without windows allow any time.
+func (r Rules) Allows(t time.Time) bool {
+	if len(r) == 0 {
+		return true
+	}
+	for _, w := range r {
+		if w.Allows(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the windows, such as Weekdays 11:00–14:00, Weekends
+func (r Rules) String() string {
+	parts := make([]string, len(r))
+	for i, w := range r {
+		parts[i] = w.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,120 @@
+package availability_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/availability"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "blank", input: " ", expected: ""},
+		{name: "weekdays lunch", input: `[{"days": ["mon", "tue", "wed", "thu", "fri"], "start": "11:00", "end": "14:00"}]`, expected: "Weekdays 11:00–14:00"},
+		{name: "weekends all day", input: `[{"days": ["Saturday", "sun"]}]`, expected: "Weekends"},
+		{name: "whole day hours", input: `[{"days": ["mon"], "start": "00:00", "end": "24:00"}]`, expected: "Mon"},
+		{name: "overnight", input: `[{"days": ["fri", "sat"], "start": "22:00", "end": "02:00"}]`, expected: "Fri, Sat 22:00–02:00"},
+		{name: "several windows", input: `[{"days": ["wed"], "start": "18:00", "end": "21:00"}, {"days": ["sun", "mon", "tue", "wed", "thu", "fri", "sat"]}]`, expected: "Wed 18:00–21:00, Every day"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := availability.Parse(test.input)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, rules.String())
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "not a list", input: `{"days": ["mon"]}`, expected: "availability must be a list of windows"},
+		{name: "no days", input: `[{"days": []}]`, expected: availability.ErrNoDays.Error()},
+		{name: "unknown day", input: `[{"days": ["funday"]}]`, expected: `unknown day "funday", expected one of sun, mon, tue, wed, thu, fri, sat`},
+		{name: "start only", input: `[{"days": ["mon"], "start": "11:00"}]`, expected: availability.ErrPartialHours.Error()},
+		{name: "same start and end", input: `[{"days": ["mon"], "start": "11:00", "end": "11:00"}]`, expected: availability.ErrEmptyHours.Error()},
+		{name: "bad format", input: `[{"days": ["mon"], "start": "9:00", "end": "11:00"}]`, expected: `time "9:00" must be written as HH:MM`},
+		{name: "out of range", input: `[{"days": ["mon"], "start": "09:00", "end": "24:30"}]`, expected: `time "24:30" is not a time of day`},
+		{name: "start at midnight end", input: `[{"days": ["mon"], "start": "24:00", "end": "02:00"}]`, expected: `time "24:00" cannot start a window`},
+		{name: "too many windows", input: `[{"days": ["mon"]}, {"days": ["mon"]}, {"days": ["mon"]}, {"days": ["mon"]}, {"days": ["mon"]}, {"days": ["mon"]}, {"days": ["mon"]}, {"days": ["mon"]}]`, expected: availability.ErrTooManyWindows.Error()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := availability.Parse(test.input)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestRulesAllows(t *testing.T) {
+	weekdayLunch, err := availability.NewWindow(availability.Weekdays, "11:00", "14:00")
+	require.NoError(t, err)
+	weekends, err := availability.NewWindow(availability.Weekends, "", "")
+	require.NoError(t, err)
+	fridayNight, err := availability.NewWindow(availability.NewDays(time.Friday), "22:00", "02:00")
+	require.NoError(t, err)
+
+	// October 16 2026 is a Friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		rules    availability.Rules
+		time     time.Time
+		expected bool
+	}{
+		{name: "no rules", rules: nil, time: at(16, 3, 0), expected: true},
+		{name: "lunch on a weekday", rules: availability.Rules{weekdayLunch}, time: at(16, 12, 30), expected: true},
+		{name: "start is inclusive", rules: availability.Rules{weekdayLunch}, time: at(16, 11, 0), expected: true},
+		{name: "end is exclusive", rules: availability.Rules{weekdayLunch}, time: at(16, 14, 0), expected: false},
+		{name: "lunch on a weekend", rules: availability.Rules{weekdayLunch}, time: at(17, 12, 30), expected: false},
+		{name: "any window", rules: availability.Rules{weekdayLunch, weekends}, time: at(17, 20, 0), expected: true},
+		{name: "overnight before midnight", rules: availability.Rules{fridayNight}, time: at(16, 23, 0), expected: true},
+		{name: "overnight after midnight", rules: availability.Rules{fridayNight}, time: at(17, 1, 59), expected: true},
+		{name: "overnight over", rules: availability.Rules{fridayNight}, time: at(17, 2, 0), expected: false},
+		{name: "overnight from another day", rules: availability.Rules{fridayNight}, time: at(16, 1, 0), expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, test.rules.Allows(test.time))
+		})
+	}
+}
+
+func TestRulesAllowsInTimeZone(t *testing.T) {
+	weekdayLunch, err := availability.NewWindow(availability.Weekdays, "11:00", "14:00")
+	require.NoError(t, err)
+	rules := availability.Rules{weekdayLunch}
+
+	// 17:00 UTC is lunch time in Chicago but not in UTC
+	instant := time.Date(2026, time.October, 16, 17, 0, 0, 0, time.UTC)
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+
+	require.False(t, rules.Allows(instant))
+	require.True(t, rules.Allows(instant.In(chicago)))
+}
+
+func TestRulesJSONRoundTrip(t *testing.T) {
+	input := `[{"days":["mon","tue","wed","thu","fri"],"start":"11:00","end":"14:00"},{"days":["sun","sat"]}]`
+	rules, err := availability.Parse(input)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(rules)
+	require.NoError(t, err)
+	require.JSONEq(t, input, string(data))
+}
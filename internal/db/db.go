@@ -22,6 +22,9 @@ type Database interface {
 	Queries() *queries.Queries
 	Logger() *slog.Logger
 	Close() error
+	// WithTx runs fn with queries bound to a transaction. The transaction is committed if fn
+	// returns nil and rolled back if it returns an error or panics.
+	WithTx(ctx context.Context, fn func(q *queries.Queries) error) error
 }
 
 func New(logger *slog.Logger, url string) (Database, error) {
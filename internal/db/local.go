@@ -1,7 +1,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/Piszmog/make-a-decision/internal/db/queries"
 	"log/slog"
 
@@ -33,6 +36,34 @@ func (d *LocalDB) Close() error {
 	return d.db.Close()
 }
 
+func (d *LocalDB) WithTx(ctx context.Context, fn func(q *queries.Queries) error) (err error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+				err = errors.Join(err, fmt.Errorf("failed to rollback transaction: %w", rerr))
+			}
+		}
+	}()
+
+	if err = fn(d.queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 func newLocalDB(logger *slog.Logger, path string) (*LocalDB, error) {
 	db, err := sql.Open("libsql", "file:"+path)
 	if err != nil {
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/stretchr/testify/require"
+)
+
+var errInjected = errors.New("injected failure")
+
+func newTestDB(t *testing.T) *LocalDB {
+	t.Helper()
+	database, err := newLocalDB(slog.New(slog.NewTextHandler(io.Discard, nil)), filepath.Join(t.TempDir(), "test.sqlite3"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close() })
+	require.NoError(t, Migrate(database))
+	return database
+}
+
+func userExists(t *testing.T, database *LocalDB, email string) bool {
+	t.Helper()
+	exists, err := database.Queries().UserExists(context.Background(), email)
+	require.NoError(t, err)
+	return exists
+}
+
+func TestWithTxCommits(t *testing.T) {
+	database := newTestDB(t)
+	ctx := context.Background()
+
+	err := database.WithTx(ctx, func(q *queries.Queries) error {
+		_, err := q.CreateUser(ctx, queries.CreateUserParams{Email: "a@example.com", PasswordHash: "x"})
+		return err
+	})
+	require.NoError(t, err)
+	require.True(t, userExists(t, database, "a@example.com"))
+}
+
+func TestWithTxRollsBack(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(q *queries.Queries) error
+	}{
+		{
+			name: "error after a write",
+			fn: func(q *queries.Queries) error {
+				if _, err := q.CreateUser(context.Background(), queries.CreateUserParams{Email: "a@example.com", PasswordHash: "x"}); err != nil {
+					return err
+				}
+				return errInjected
+			},
+		},
+		{
+			name: "failing statement after a write",
+			fn: func(q *queries.Queries) error {
+				if _, err := q.CreateUser(context.Background(), queries.CreateUserParams{Email: "a@example.com", PasswordHash: "x"}); err != nil {
+					return err
+				}
+				// The email is unique so the second insert fails
+				_, err := q.CreateUser(context.Background(), queries.CreateUserParams{Email: "a@example.com", PasswordHash: "x"})
+				return err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			database := newTestDB(t)
+
+			err := database.WithTx(context.Background(), test.fn)
+			require.Error(t, err)
+			require.False(t, userExists(t, database, "a@example.com"))
+		})
+	}
+}
+
+func TestWithTxReturnsError(t *testing.T) {
+	database := newTestDB(t)
+
+	err := database.WithTx(context.Background(), func(_ *queries.Queries) error {
+		return errInjected
+	})
+	require.ErrorIs(t, err, errInjected)
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	database := newTestDB(t)
+	ctx := context.Background()
+
+	require.Panics(t, func() {
+		_ = database.WithTx(ctx, func(q *queries.Queries) error {
+			if _, err := q.CreateUser(ctx, queries.CreateUserParams{Email: "a@example.com", PasswordHash: "x"}); err != nil {
+				return err
+			}
+			panic("injected panic")
+		})
+	})
+	require.False(t, userExists(t, database, "a@example.com"))
+
+	// The connection is usable after the rollback
+	require.NoError(t, database.WithTx(ctx, func(q *queries.Queries) error {
+		_, err := q.CreateUser(ctx, queries.CreateUserParams{Email: "b@example.com", PasswordHash: "x"})
+		return err
+	}))
+	require.True(t, userExists(t, database, "b@example.com"))
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_spins_created_at;
+DROP INDEX IF EXISTS idx_spins_user_id;
+DROP TABLE IF EXISTS spins;
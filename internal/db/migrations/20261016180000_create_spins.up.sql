@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS spins (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  option_id INTEGER REFERENCES options(id) ON DELETE SET NULL,
+  option_name TEXT NOT NULL,
+  time_constraint_minutes INTEGER,
+  tags TEXT NOT NULL DEFAULT '[]',
+  strategy TEXT NOT NULL DEFAULT 'weighted',
+  probability REAL NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_spins_user_id ON spins(user_id);
+CREATE INDEX idx_spins_created_at ON spins(created_at);
@@ -0,0 +1,106 @@
+-- ============================================================
+-- Revert wheels
+-- ============================================================
+
+PRAGMA foreign_keys=OFF;
+
+-- ============================================================
+-- Recreate spins table without wheel_id
+-- ============================================================
+
+CREATE TABLE spins_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  option_id INTEGER REFERENCES options(id) ON DELETE SET NULL,
+  option_name TEXT NOT NULL,
+  time_constraint_minutes INTEGER,
+  tags TEXT NOT NULL DEFAULT '[]',
+  strategy TEXT NOT NULL DEFAULT 'weighted',
+  probability REAL NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+INSERT INTO spins_old (id, user_id, option_id, option_name, time_constraint_minutes, tags, strategy, probability, created_at)
+SELECT id, user_id, option_id, option_name, time_constraint_minutes, tags, strategy, probability, created_at FROM spins;
+
+DROP TABLE spins;
+ALTER TABLE spins_old RENAME TO spins;
+
+CREATE INDEX idx_spins_user_id ON spins(user_id);
+CREATE INDEX idx_spins_created_at ON spins(created_at);
+
+-- ============================================================
+-- Recreate tags table without wheel_id
+-- ============================================================
+
+CREATE TABLE tags_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE(name, user_id)
+);
+
+INSERT OR IGNORE INTO tags_old (id, name, user_id, created_at)
+SELECT id, name, user_id, created_at FROM tags;
+
+DROP TABLE tags;
+ALTER TABLE tags_old RENAME TO tags;
+
+CREATE INDEX idx_tags_user_id ON tags(user_id);
+CREATE INDEX idx_tags_name ON tags(name);
+
+-- ============================================================
+-- Recreate options table without wheel_id
+-- ============================================================
+
+CREATE TABLE options_old (
+  id INTEGER PRIMARY KEY,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  name TEXT NOT NULL,
+  bio TEXT,
+  duration_minutes INTEGER NULL,
+  weight INTEGER DEFAULT 1 CHECK (weight >= 1 AND weight <= 10),
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  CHECK (
+    duration_minutes IS NULL
+    OR (
+      duration_minutes >= 0
+      AND duration_minutes <= 1440
+    )
+  )
+);
+
+INSERT INTO options_old (id, created_at, name, bio, duration_minutes, weight, user_id)
+SELECT id, created_at, name, bio, duration_minutes, weight, user_id FROM options;
+
+DROP TABLE options;
+ALTER TABLE options_old RENAME TO options;
+
+CREATE INDEX idx_options_user_id ON options(user_id);
+CREATE INDEX idx_options_created_at ON options(created_at);
+
+-- ============================================================
+-- Recreate users table without active_wheel_id
+-- ============================================================
+
+CREATE TABLE users_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT NOT NULL UNIQUE,
+  password_hash TEXT NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  CHECK (length(email) > 0 AND email LIKE '%_@__%.__%')
+);
+
+INSERT INTO users_old (id, email, password_hash, created_at)
+SELECT id, email, password_hash, created_at FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+
+CREATE INDEX idx_users_email ON users(email);
+
+DROP INDEX IF EXISTS idx_wheels_user_id;
+DROP TABLE IF EXISTS wheels;
+
+PRAGMA foreign_keys=ON;
@@ -0,0 +1,97 @@
+-- ============================================================
+-- Add wheels so a user can keep several option lists
+-- ============================================================
+
+PRAGMA foreign_keys=OFF;
+
+CREATE TABLE IF NOT EXISTS wheels (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  CHECK (length(name) > 0)
+);
+
+CREATE INDEX idx_wheels_user_id ON wheels(user_id);
+
+-- Every existing user starts with a single wheel holding their current options
+INSERT INTO wheels (user_id, name)
+SELECT id, 'My Wheel' FROM users;
+
+-- Remember which wheel the user is working with
+ALTER TABLE users ADD COLUMN active_wheel_id INTEGER REFERENCES wheels(id) ON DELETE SET NULL;
+
+UPDATE users
+SET active_wheel_id = (SELECT id FROM wheels WHERE wheels.user_id = users.id);
+
+-- ============================================================
+-- Recreate options table with wheel_id
+-- ============================================================
+
+CREATE TABLE options_new (
+  id INTEGER PRIMARY KEY,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  name TEXT NOT NULL,
+  bio TEXT,
+  duration_minutes INTEGER NULL,
+  weight INTEGER DEFAULT 1 CHECK (weight >= 1 AND weight <= 10),
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  wheel_id INTEGER NOT NULL REFERENCES wheels(id) ON DELETE CASCADE,
+  CHECK (
+    duration_minutes IS NULL
+    OR (
+      duration_minutes >= 0
+      AND duration_minutes <= 1440
+    )
+  )
+);
+
+INSERT INTO options_new (id, created_at, name, bio, duration_minutes, weight, user_id, wheel_id)
+SELECT o.id, o.created_at, o.name, o.bio, o.duration_minutes, o.weight, o.user_id, w.id
+FROM options o
+INNER JOIN wheels w ON w.user_id = o.user_id;
+
+DROP TABLE options;
+ALTER TABLE options_new RENAME TO options;
+
+CREATE INDEX idx_options_user_id ON options(user_id);
+CREATE INDEX idx_options_wheel_id ON options(wheel_id);
+CREATE INDEX idx_options_created_at ON options(created_at);
+
+-- ============================================================
+-- Recreate tags table with wheel_id (tag names are unique per wheel)
+-- ============================================================
+
+CREATE TABLE tags_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  wheel_id INTEGER NOT NULL REFERENCES wheels(id) ON DELETE CASCADE,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE(name, wheel_id)
+);
+
+INSERT INTO tags_new (id, name, user_id, wheel_id, created_at)
+SELECT t.id, t.name, t.user_id, w.id, t.created_at
+FROM tags t
+INNER JOIN wheels w ON w.user_id = t.user_id;
+
+DROP TABLE tags;
+ALTER TABLE tags_new RENAME TO tags;
+
+CREATE INDEX idx_tags_user_id ON tags(user_id);
+CREATE INDEX idx_tags_wheel_id ON tags(wheel_id);
+CREATE INDEX idx_tags_name ON tags(name);
+
+-- ============================================================
+-- Scope spins to the wheel they were made on
+-- ============================================================
+
+ALTER TABLE spins ADD COLUMN wheel_id INTEGER REFERENCES wheels(id) ON DELETE CASCADE;
+
+UPDATE spins
+SET wheel_id = (SELECT id FROM wheels WHERE wheels.user_id = spins.user_id);
+
+CREATE INDEX idx_spins_wheel_id ON spins(wheel_id);
+
+PRAGMA foreign_keys=ON;
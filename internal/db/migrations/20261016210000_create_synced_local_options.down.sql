@@ -0,0 +1 @@
+DROP TABLE IF EXISTS synced_local_options;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS synced_local_options (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  local_id TEXT NOT NULL,
+  option_id INTEGER,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (option_id) REFERENCES options(id) ON DELETE SET NULL,
+  UNIQUE(user_id, local_id)
+);
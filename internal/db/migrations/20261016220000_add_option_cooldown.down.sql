@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_spins_option_id;
+
+ALTER TABLE options DROP COLUMN cooldown_minutes;
@@ -0,0 +1,3 @@
+ALTER TABLE options ADD COLUMN cooldown_minutes INTEGER;
+
+CREATE INDEX idx_spins_option_id ON spins(option_id, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE spins DROP COLUMN target_at;
+
+ALTER TABLE users DROP COLUMN time_zone;
+
+ALTER TABLE options DROP COLUMN availability;
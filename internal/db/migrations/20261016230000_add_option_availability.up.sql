@@ -0,0 +1,5 @@
+ALTER TABLE options ADD COLUMN availability TEXT;
+
+ALTER TABLE users ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE spins ADD COLUMN target_at DATETIME;
@@ -0,0 +1,2 @@
+ALTER TABLE spins DROP COLUMN pick_count;
+ALTER TABLE spins DROP COLUMN pick_position;
@@ -0,0 +1,2 @@
+ALTER TABLE spins ADD COLUMN pick_position INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE spins ADD COLUMN pick_count INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_spins_round_id;
+
+ALTER TABLE spins DROP COLUMN round_id;
+
+DROP TABLE IF EXISTS elimination_rounds;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS elimination_rounds (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  wheel_id INTEGER NOT NULL REFERENCES wheels(id) ON DELETE CASCADE,
+  variant TEXT NOT NULL DEFAULT 'last-standing',
+  winner_option_id INTEGER REFERENCES options(id) ON DELETE SET NULL,
+  winner_name TEXT,
+  finished_at DATETIME,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  UNIQUE (user_id, wheel_id)
+);
+
+ALTER TABLE spins ADD COLUMN round_id INTEGER REFERENCES elimination_rounds(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_spins_round_id ON spins(round_id);
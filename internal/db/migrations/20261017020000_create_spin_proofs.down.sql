@@ -0,0 +1,7 @@
+ALTER TABLE spins DROP COLUMN proof_id;
+
+DROP INDEX IF EXISTS idx_spin_proofs_user_id;
+
+DROP TABLE IF EXISTS spin_proofs;
+
+DROP TABLE IF EXISTS server_seeds;
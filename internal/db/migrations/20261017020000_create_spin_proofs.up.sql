@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS server_seeds (
+  user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  seed TEXT NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS spin_proofs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  wheel_id INTEGER NOT NULL REFERENCES wheels(id) ON DELETE CASCADE,
+  server_seed TEXT NOT NULL,
+  server_seed_hash TEXT NOT NULL,
+  client_seed TEXT NOT NULL DEFAULT '',
+  options TEXT NOT NULL,
+  pick_count INTEGER NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_spin_proofs_user_id ON spin_proofs(user_id);
+
+ALTER TABLE spins ADD COLUMN proof_id INTEGER REFERENCES spin_proofs(id) ON DELETE SET NULL;
@@ -0,0 +1,37 @@
+-- Weights run from 0 to 100, where 0 keeps the option out of every spin without deleting it.
+-- SQLite cannot change a CHECK constraint in place, so the options table is rebuilt.
+
+PRAGMA foreign_keys=OFF;
+
+CREATE TABLE options_new (
+  id INTEGER PRIMARY KEY,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP NOT NULL,
+  name TEXT NOT NULL,
+  bio TEXT,
+  duration_minutes INTEGER NULL,
+  weight INTEGER DEFAULT 1 CHECK (weight >= 0 AND weight <= 100),
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  wheel_id INTEGER NOT NULL REFERENCES wheels(id) ON DELETE CASCADE,
+  cooldown_minutes INTEGER,
+  availability TEXT,
+  CHECK (
+    duration_minutes IS NULL
+    OR (
+      duration_minutes >= 0
+      AND duration_minutes <= 1440
+    )
+  )
+);
+
+INSERT INTO options_new (id, created_at, name, bio, duration_minutes, weight, user_id, wheel_id, cooldown_minutes, availability)
+SELECT id, created_at, name, bio, duration_minutes, weight, user_id, wheel_id, cooldown_minutes, availability
+FROM options;
+
+DROP TABLE options;
+ALTER TABLE options_new RENAME TO options;
+
+CREATE INDEX idx_options_user_id ON options(user_id);
+CREATE INDEX idx_options_wheel_id ON options(wheel_id);
+CREATE INDEX idx_options_created_at ON options(created_at);
+
+PRAGMA foreign_keys=ON;
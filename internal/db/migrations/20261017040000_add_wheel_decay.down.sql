@@ -0,0 +1 @@
+ALTER TABLE wheels DROP COLUMN decay_half_life_minutes;
@@ -0,0 +1,2 @@
+-- How long a recently picked option takes to win back half of the weight it lost. NULL leaves weights as they are.
+ALTER TABLE wheels ADD COLUMN decay_half_life_minutes INTEGER;
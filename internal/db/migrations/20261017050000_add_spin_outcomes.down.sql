@@ -0,0 +1,6 @@
+ALTER TABLE users DROP COLUMN learn_from_ratings;
+
+ALTER TABLE spins DROP COLUMN completed_at;
+ALTER TABLE spins DROP COLUMN note;
+ALTER TABLE spins DROP COLUMN rating;
+ALTER TABLE spins DROP COLUMN outcome;
@@ -0,0 +1,9 @@
+-- What became of a decision: accepted, skipped or rerolled. NULL until the user says.
+ALTER TABLE spins ADD COLUMN outcome TEXT CHECK (outcome IN ('accepted', 'skipped', 'rerolled'));
+-- How the decision went once it was done, from 1 to 5, with an optional note
+ALTER TABLE spins ADD COLUMN rating INTEGER CHECK (rating BETWEEN 1 AND 5);
+ALTER TABLE spins ADD COLUMN note TEXT;
+ALTER TABLE spins ADD COLUMN completed_at DATETIME;
+
+-- Whether rating a decision nudges the weight of the option it picked
+ALTER TABLE users ADD COLUMN learn_from_ratings INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+ALTER TABLE spins DROP COLUMN veto_number;
+ALTER TABLE spins DROP COLUMN vetoed_spin_id;
+
+ALTER TABLE wheels DROP COLUMN veto_limit;
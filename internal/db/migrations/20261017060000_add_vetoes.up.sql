@@ -0,0 +1,6 @@
+-- How many times a decision on the wheel can be vetoed and rerolled. NULL allows any number of vetoes.
+ALTER TABLE wheels ADD COLUMN veto_limit INTEGER CHECK (veto_limit >= 0);
+
+-- The spin a reroll vetoed, and how many vetoes the decision had used up when this spin was made
+ALTER TABLE spins ADD COLUMN vetoed_spin_id INTEGER REFERENCES spins(id) ON DELETE SET NULL;
+ALTER TABLE spins ADD COLUMN veto_number INTEGER NOT NULL DEFAULT 0;
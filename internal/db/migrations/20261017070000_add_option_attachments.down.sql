@@ -0,0 +1,4 @@
+DROP TABLE images;
+
+ALTER TABLE options DROP COLUMN image_key;
+ALTER TABLE options DROP COLUMN links;
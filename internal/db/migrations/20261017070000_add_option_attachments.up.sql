@@ -0,0 +1,13 @@
+-- The links on an option as a JSON array of URLs. NULL when the option has none.
+ALTER TABLE options ADD COLUMN links TEXT;
+
+-- The key of the option's uploaded image. Duplicated options share the key of the image they were copied with.
+ALTER TABLE options ADD COLUMN image_key TEXT;
+
+-- Uploaded images and their thumbnails, when they are stored in the database rather than a directory
+CREATE TABLE images (
+  key TEXT PRIMARY KEY,
+  image BLOB NOT NULL,
+  thumbnail BLOB NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+ALTER TABLE spins DROP COLUMN tag_expression;
+ALTER TABLE spins DROP COLUMN include_untagged;
+ALTER TABLE spins DROP COLUMN exclude_tags;
+ALTER TABLE spins DROP COLUMN tag_mode;
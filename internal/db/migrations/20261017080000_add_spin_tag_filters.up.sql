@@ -0,0 +1,6 @@
+-- The rest of the tag filter a spin was made with. tags holds the included tags.
+ALTER TABLE spins ADD COLUMN tag_mode TEXT NOT NULL DEFAULT 'any' CHECK (tag_mode IN ('any', 'all'));
+ALTER TABLE spins ADD COLUMN exclude_tags TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE spins ADD COLUMN include_untagged INTEGER NOT NULL DEFAULT 1;
+-- The tag expression as it was written, or empty when there was none
+ALTER TABLE spins ADD COLUMN tag_expression TEXT NOT NULL DEFAULT '';
@@ -4,9 +4,111 @@ SELECT
 FROM
   options
 WHERE
-  user_id = ?
+  wheel_id = ? AND user_id = ?
 ORDER BY
-  created_at;
+  created_at,
+  id;
+
+-- name: GetOptionsWithTags :many
+SELECT
+  sqlc.embed(o),
+  CAST(
+    (
+      SELECT
+        json_group_array(name)
+      FROM
+        (
+          SELECT
+            t.name
+          FROM
+            option_tags ot
+            INNER JOIN tags t ON t.id = ot.tag_id
+          WHERE
+            ot.option_id = o.id
+          ORDER BY
+            ot.created_at,
+            ot.rowid
+        )
+    ) AS TEXT
+  ) AS tags
+FROM
+  options o
+WHERE
+  o.wheel_id = ? AND o.user_id = ?
+ORDER BY
+  o.created_at,
+  o.id;
+
+-- name: GetOptionsFilteredByTags :many
+SELECT
+  sqlc.embed(o),
+  CAST(
+    (
+      SELECT
+        json_group_array(name)
+      FROM
+        (
+          SELECT
+            t.name
+          FROM
+            option_tags ot
+            INNER JOIN tags t ON t.id = ot.tag_id
+          WHERE
+            ot.option_id = o.id
+          ORDER BY
+            ot.created_at,
+            ot.rowid
+        )
+    ) AS TEXT
+  ) AS tags
+FROM
+  options o
+WHERE
+  o.wheel_id = sqlc.arg(wheel_id) AND o.user_id = sqlc.arg(user_id)
+  AND (
+    (
+      NOT EXISTS (
+        SELECT
+          1
+        FROM
+          option_tags ot
+        WHERE
+          ot.option_id = o.id
+      )
+      AND CAST(sqlc.arg(include_untagged) AS BOOLEAN)
+    )
+    OR (
+      EXISTS (
+        SELECT
+          1
+        FROM
+          option_tags ot
+        WHERE
+          ot.option_id = o.id
+      )
+      AND CAST(sqlc.arg(min_matches) AS INTEGER) <= (
+        SELECT
+          COUNT(DISTINCT t.name)
+        FROM
+          option_tags ot
+          INNER JOIN tags t ON t.id = ot.tag_id
+        WHERE
+          ot.option_id = o.id AND t.name IN (sqlc.slice(include_tags))
+      )
+      AND (
+        SELECT
+          COUNT(*)
+        FROM
+          option_tags ot
+          INNER JOIN tags t ON t.id = ot.tag_id
+        WHERE
+          ot.option_id = o.id AND t.name IN (sqlc.slice(exclude_tags))
+      ) = 0
+    )
+  )
+ORDER BY
+  o.created_at,
+  o.id;
 
 -- name: GetOption :one
 SELECT
@@ -20,15 +122,20 @@ LIMIT
 
 -- name: CreateOption :one
 INSERT INTO
-  options (name, bio, duration_minutes, weight, user_id)
+  options (
+    name,
+    bio,
+    duration_minutes,
+    weight,
+    cooldown_minutes,
+    availability,
+    links,
+    image_key,
+    user_id,
+    wheel_id
+  )
 VALUES
-  (?, ?, ?, ?, ?) RETURNING id,
-  name,
-  bio,
-  duration_minutes,
-  weight,
-  user_id,
-  created_at;
+  (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: UpdateOption :exec
 UPDATE options
@@ -36,10 +143,42 @@ SET
   name = ?,
   bio = ?,
   duration_minutes = ?,
-  weight = ?
+  weight = ?,
+  cooldown_minutes = ?,
+  availability = ?,
+  links = ?
 WHERE
   id = ? AND user_id = ?;
 
+-- name: GetCooldowns :many
+SELECT
+  o.id,
+  CAST(
+    strftime('%s', MAX(s.created_at)) + o.cooldown_minutes * 60 AS INTEGER
+  ) AS available_at
+FROM
+  options o
+  INNER JOIN spins s ON s.option_id = o.id
+WHERE
+  o.wheel_id = sqlc.arg(wheel_id) AND o.user_id = sqlc.arg(user_id) AND o.cooldown_minutes > 0
+  AND s.round_id IS NULL AND (s.outcome IS NULL OR s.outcome = 'accepted')
+GROUP BY
+  o.id
+HAVING
+  strftime('%s', MAX(s.created_at)) + o.cooldown_minutes * 60 > CAST(sqlc.arg(now) AS INTEGER);
+
+-- name: GetOptionByName :one
+SELECT
+  *
+FROM
+  options
+WHERE
+  wheel_id = ? AND user_id = ? AND LOWER(name) = LOWER(sqlc.arg(name))
+ORDER BY
+  created_at
+LIMIT
+  1;
+
 -- name: UpdateDuration :exec
 UPDATE options
 SET
@@ -47,6 +186,36 @@ SET
 WHERE
   id = ? AND user_id = ?;
 
+-- name: SetOptionImage :exec
+UPDATE options
+SET
+  image_key = ?
+WHERE
+  id = ? AND user_id = ?;
+
+-- name: CountOptionsWithImage :one
+SELECT
+  COUNT(*)
+FROM
+  options
+WHERE
+  image_key = ?;
+
+-- name: GetImageKeysForWheel :many
+SELECT DISTINCT
+  image_key
+FROM
+  options
+WHERE
+  wheel_id = ? AND user_id = ? AND image_key IS NOT NULL;
+
+-- name: UpdateBio :exec
+UPDATE options
+SET
+  bio = ?
+WHERE
+  id = ? AND user_id = ?;
+
 -- name: UpdateWeight :exec
 UPDATE options
 SET
@@ -59,11 +228,26 @@ DELETE FROM options
 WHERE
   id = ? AND user_id = ?;
 
+-- name: DetachOptionSpins :exec
+UPDATE spins
+SET option_id = NULL
+WHERE option_id = ? AND user_id = ?;
+
+-- name: DetachOptionRounds :exec
+UPDATE elimination_rounds
+SET winner_option_id = NULL
+WHERE winner_option_id = ? AND user_id = ?;
+
+-- name: DetachSyncedLocalOption :exec
+UPDATE synced_local_options
+SET option_id = NULL
+WHERE option_id = ? AND user_id = ?;
+
 -- name: GetOrCreateTag :one
 INSERT INTO
-  tags (name, user_id)
+  tags (name, user_id, wheel_id)
 VALUES
-  (LOWER(?), ?) ON CONFLICT (name, user_id) DO
+  (LOWER(?), ?, ?) ON CONFLICT (name, wheel_id) DO
 UPDATE
 SET
   name = LOWER(excluded.name) RETURNING *;
@@ -73,6 +257,7 @@ SELECT
   t.id,
   t.name,
   t.user_id,
+  t.wheel_id,
   t.created_at
 FROM
   tags t
@@ -93,14 +278,16 @@ DELETE FROM option_tags
 WHERE
   option_id = ?;
 
--- name: DeleteUnusedTags :exec
+-- name: DeleteTagIfUnused :exec
 DELETE FROM tags
 WHERE
-  user_id = ? AND id NOT IN (
-    SELECT DISTINCT
-      tag_id
+  id = ? AND user_id = ? AND NOT EXISTS (
+    SELECT
+      1
     FROM
       option_tags
+    WHERE
+      tag_id = tags.id
   );
 
 -- name: GetAllTags :many
@@ -108,15 +295,81 @@ SELECT DISTINCT
   t.id,
   t.name,
   t.user_id,
+  t.wheel_id,
   t.created_at
 FROM
   tags t
   INNER JOIN option_tags ot ON t.id = ot.tag_id
 WHERE
-  t.user_id = ?
+  t.wheel_id = ? AND t.user_id = ?
 ORDER BY
   t.name;
 
+-- name: GetTagsForWheel :many
+SELECT
+  t.id,
+  t.name,
+  t.wheel_id,
+  t.created_at,
+  COUNT(ot.option_id) AS option_count
+FROM
+  tags t
+  LEFT JOIN option_tags ot ON t.id = ot.tag_id
+WHERE
+  t.wheel_id = ? AND t.user_id = ?
+GROUP BY
+  t.id
+ORDER BY
+  t.name;
+
+-- name: GetTag :one
+SELECT
+  t.id,
+  t.name,
+  t.wheel_id,
+  t.created_at,
+  COUNT(ot.option_id) AS option_count
+FROM
+  tags t
+  LEFT JOIN option_tags ot ON t.id = ot.tag_id
+WHERE
+  t.id = ? AND t.user_id = ?
+GROUP BY
+  t.id
+LIMIT
+  1;
+
+-- name: TagExists :one
+SELECT
+  COUNT(*) > 0 AS tag_exists
+FROM
+  tags
+WHERE
+  name = LOWER(?) AND wheel_id = ?;
+
+-- name: CreateTag :one
+INSERT INTO
+  tags (name, user_id, wheel_id)
+VALUES
+  (LOWER(?), ?, ?) RETURNING *;
+
+-- name: RenameTag :exec
+UPDATE tags
+SET
+  name = LOWER(?)
+WHERE
+  id = ? AND user_id = ?;
+
+-- name: ClearOptionsForTag :exec
+DELETE FROM option_tags
+WHERE
+  tag_id = ?;
+
+-- name: DeleteTag :exec
+DELETE FROM tags
+WHERE
+  id = ? AND user_id = ?;
+
 -- name: CreateUser :one
 INSERT INTO users (email, password_hash)
 VALUES (?, ?)
@@ -136,6 +389,24 @@ SELECT * FROM users
 WHERE id = ?
 LIMIT 1;
 
+-- name: GetUserTimeZone :one
+SELECT time_zone FROM users
+WHERE id = ?;
+
+-- name: UpdateUserTimeZone :exec
+UPDATE users
+SET time_zone = ?
+WHERE id = ?;
+
+-- name: GetUserLearnFromRatings :one
+SELECT learn_from_ratings FROM users
+WHERE id = ?;
+
+-- name: UpdateUserLearnFromRatings :exec
+UPDATE users
+SET learn_from_ratings = ?
+WHERE id = ?;
+
 -- Session queries
 
 -- name: InsertSession :exec
@@ -160,3 +431,270 @@ WHERE token = ?;
 DELETE FROM sessions
 WHERE user_id = ?
 AND created_at < datetime('now', '-7 days');
+
+-- API token queries
+
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scope)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetAPITokens :many
+SELECT * FROM api_tokens
+WHERE user_id = ? AND revoked_at IS NULL
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetAPITokenByHash :one
+SELECT * FROM api_tokens
+WHERE token_hash = ? AND revoked_at IS NULL
+LIMIT 1;
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens
+SET last_used_at = ?
+WHERE id = ?;
+
+-- name: RevokeAPIToken :execrows
+UPDATE api_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = ? AND user_id = ? AND revoked_at IS NULL;
+
+-- Local sync queries
+
+-- name: ClaimLocalOption :execrows
+INSERT INTO synced_local_options (user_id, local_id)
+VALUES (?, ?)
+ON CONFLICT(user_id, local_id) DO NOTHING;
+
+-- name: SetSyncedLocalOption :exec
+UPDATE synced_local_options
+SET option_id = ?
+WHERE user_id = ? AND local_id = ?;
+
+-- Spin queries
+
+-- name: CreateSpin :one
+INSERT INTO spins (user_id, wheel_id, option_id, option_name, time_constraint_minutes, tags, tag_mode, exclude_tags, include_untagged, tag_expression, strategy, probability, target_at, pick_position, pick_count, round_id, proof_id, vetoed_spin_id, veto_number)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetSpins :many
+SELECT * FROM spins
+WHERE wheel_id = ? AND user_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ? OFFSET ?;
+
+-- name: GetSpin :one
+SELECT * FROM spins
+WHERE id = ? AND user_id = ?;
+
+-- name: GetLastDecision :one
+SELECT * FROM spins
+WHERE wheel_id = ? AND user_id = ? AND pick_count = 1 AND round_id IS NULL
+ORDER BY created_at DESC, id DESC
+LIMIT 1;
+
+-- name: SetSpinOutcome :one
+UPDATE spins
+SET outcome = ?
+WHERE id = ? AND user_id = ?
+RETURNING *;
+
+-- name: VetoSpin :execrows
+UPDATE spins
+SET outcome = 'rerolled'
+WHERE id = ? AND user_id = ? AND completed_at IS NULL AND (outcome IS NULL OR outcome = 'accepted');
+
+-- name: CompleteSpin :one
+UPDATE spins
+SET
+  outcome = 'accepted',
+  rating = ?,
+  note = ?,
+  completed_at = COALESCE(completed_at, CURRENT_TIMESTAMP)
+WHERE id = ? AND user_id = ?
+RETURNING *;
+
+-- name: CountSpins :one
+SELECT COUNT(*) FROM spins
+WHERE wheel_id = ? AND user_id = ?;
+
+-- name: GetUnfilteredPicks :many
+SELECT option_id, probability FROM spins
+WHERE wheel_id = ? AND user_id = ? AND strategy = ? AND pick_count = 1 AND option_id IS NOT NULL AND veto_number = 0
+  AND time_constraint_minutes IS NULL AND tags = '[]' AND exclude_tags = '[]' AND include_untagged = 1 AND tag_expression = '';
+
+-- name: GetRecentPickedOptionIDs :many
+SELECT option_id FROM spins
+WHERE wheel_id = ? AND user_id = ? AND option_id IS NOT NULL
+  AND round_id IS NULL AND (outcome IS NULL OR outcome = 'accepted')
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: GetLastPicks :many
+SELECT
+  option_id,
+  CAST(strftime('%s', MAX(created_at)) AS INTEGER) AS picked_at
+FROM spins
+WHERE wheel_id = ? AND user_id = ? AND option_id IS NOT NULL
+  AND round_id IS NULL AND (outcome IS NULL OR outcome = 'accepted')
+GROUP BY option_id;
+
+-- Elimination round queries
+
+-- name: GetEliminationRound :one
+SELECT * FROM elimination_rounds
+WHERE user_id = ? AND wheel_id = ?
+LIMIT 1;
+
+-- name: CreateEliminationRound :one
+INSERT INTO elimination_rounds (user_id, wheel_id, variant)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: SetEliminationWinner :exec
+UPDATE elimination_rounds
+SET winner_option_id = ?, winner_name = ?
+WHERE id = ? AND user_id = ?;
+
+-- name: FinishEliminationRound :one
+UPDATE elimination_rounds
+SET finished_at = CURRENT_TIMESTAMP
+WHERE id = ? AND user_id = ?
+RETURNING finished_at;
+
+-- name: DetachRoundSpins :exec
+UPDATE spins
+SET round_id = NULL
+WHERE round_id IN (
+  SELECT r.id FROM elimination_rounds r WHERE r.user_id = ? AND r.wheel_id = ?
+);
+
+-- name: DeleteEliminationRound :exec
+DELETE FROM elimination_rounds
+WHERE user_id = ? AND wheel_id = ?;
+
+-- name: GetRoundPicks :many
+SELECT option_id, option_name FROM spins
+WHERE round_id = ? AND user_id = ?
+ORDER BY id;
+
+-- Provably fair queries
+
+-- name: GetServerSeed :one
+SELECT seed FROM server_seeds
+WHERE user_id = ?;
+
+-- name: SetServerSeed :exec
+INSERT INTO server_seeds (user_id, seed)
+VALUES (?, ?)
+ON CONFLICT (user_id) DO UPDATE SET seed = excluded.seed, created_at = CURRENT_TIMESTAMP;
+
+-- name: CreateSpinProof :one
+INSERT INTO spin_proofs (user_id, wheel_id, server_seed, server_seed_hash, client_seed, options, pick_count)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetSpinProof :one
+SELECT * FROM spin_proofs
+WHERE id = ? AND user_id = ?;
+
+-- name: GetProofPicks :many
+SELECT option_id, option_name FROM spins
+WHERE proof_id = ? AND user_id = ?
+ORDER BY pick_position;
+
+-- name: DeleteSpinProofsForWheel :exec
+DELETE FROM spin_proofs
+WHERE wheel_id = ? AND user_id = ?;
+
+-- Wheel queries
+
+-- name: CreateWheel :one
+INSERT INTO wheels (user_id, name)
+VALUES (?, ?)
+RETURNING *;
+
+-- name: GetWheels :many
+SELECT * FROM wheels
+WHERE user_id = ?
+ORDER BY created_at, id;
+
+-- name: GetWheel :one
+SELECT * FROM wheels
+WHERE id = ? AND user_id = ?
+LIMIT 1;
+
+-- name: GetActiveWheel :one
+SELECT w.* FROM wheels w
+INNER JOIN users u ON u.active_wheel_id = w.id
+WHERE u.id = ?
+LIMIT 1;
+
+-- name: SetActiveWheel :exec
+UPDATE users
+SET active_wheel_id = ?
+WHERE id = ?;
+
+-- name: RenameWheel :exec
+UPDATE wheels
+SET name = ?
+WHERE id = ? AND user_id = ?;
+
+-- name: SetWheelDecay :exec
+UPDATE wheels
+SET decay_half_life_minutes = ?
+WHERE id = ? AND user_id = ?;
+
+-- name: SetWheelVetoLimit :exec
+UPDATE wheels
+SET veto_limit = ?
+WHERE id = ? AND user_id = ?;
+
+-- name: DeleteWheel :exec
+DELETE FROM wheels
+WHERE id = ? AND user_id = ?;
+
+-- name: ClearOptionTagsForWheel :exec
+DELETE FROM option_tags
+WHERE option_id IN (
+  SELECT id FROM options WHERE wheel_id = ? AND user_id = ?
+);
+
+-- name: DeleteOptionsForWheel :exec
+DELETE FROM options
+WHERE wheel_id = ? AND user_id = ?;
+
+-- name: DeleteTagsForWheel :exec
+DELETE FROM tags
+WHERE wheel_id = ? AND user_id = ?;
+
+-- name: DeleteSpinsForWheel :exec
+DELETE FROM spins
+WHERE wheel_id = ? AND user_id = ?;
+
+-- name: SaveImage :exec
+INSERT INTO
+  images (key, image, thumbnail)
+VALUES
+  (?, ?, ?);
+
+-- name: GetImage :one
+SELECT
+  image
+FROM
+  images
+WHERE
+  key = ?;
+
+-- name: GetImageThumbnail :one
+SELECT
+  thumbnail
+FROM
+  images
+WHERE
+  key = ?;
+
+-- name: DeleteImage :exec
+DELETE FROM images
+WHERE key = ?;
@@ -86,9 +86,40 @@
     return options.map(opt => renderOptionRow(opt, totalWeight)).join('');
   }
 
-  // Render result card
-  function renderResult(option) {
-    const probability = ((option.weight / LocalStorageManager.getTotalWeight(LocalStorageManager.getOptions())) * 100).toFixed(1);
+  // Render the chance of every eligible option (matches server-side template)
+  function renderDistribution(selection) {
+    if (selection.eligible.length <= 1) return '';
+
+    const pickedIDs = new Set(selection.picks.map(pick => pick.option.id));
+    const rows = [...selection.eligible]
+      .sort((a, b) => b.probability - a.probability)
+      .map(opt => {
+        const selectedClass = pickedIDs.has(opt.id)
+          ? 'bg-blue-500/20 text-white font-medium'
+          : 'bg-white/5 text-white/70';
+        const percent = (opt.probability * 100).toFixed(1);
+        return `
+          <div class="relative rounded-md px-3 py-1 text-sm overflow-hidden ${selectedClass}">
+            <div class="absolute inset-y-0 left-0 bg-blue-400/20" style="width: ${percent}%"></div>
+            <div class="relative flex justify-between gap-3">
+              <span class="truncate">${escapeHTML(opt.text)}</span>
+              <span class="font-mono">${percent}%</span>
+            </div>
+          </div>
+        `;
+      }).join('');
+
+    return `
+      <div class="text-left">
+        <div class="text-white/50 text-xs mb-2 text-center">Chosen from ${selection.eligible.length} options:</div>
+        <div class="space-y-1 max-h-40 overflow-y-auto pr-1" id="distribution">${rows}</div>
+      </div>
+    `;
+  }
+
+  // Render the duration and chance badges of a picked option (matches server-side template)
+  function renderPickBadges(pick) {
+    const option = pick.option;
     const durationHTML = option.duration !== null && option.duration !== undefined
       ? `<span class="badge">
           <svg xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" stroke-width="1.5" stroke="currentColor">
@@ -98,24 +129,64 @@
         </span>`
       : '';
 
+    return `
+      ${durationHTML}
+      <span class="badge">
+        <svg xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" stroke-width="1.5" stroke="currentColor">
+          <path stroke-linecap="round" stroke-linejoin="round" d="M5.25 5.653c0-.856.917-1.398 1.667-.986l11.54 6.347a1.125 1.125 0 0 1 0 1.972l-11.54 6.347a1.125 1.125 0 0 1-1.667-.986V5.653Z"></path>
+        </svg>
+        ${(pick.probability * 100).toFixed(1)}%
+      </span>
+    `;
+  }
+
+  // Render the picked options, one large name for a single pick or a numbered list for several
+  function renderPicks(picks) {
+    if (picks.length === 1) {
+      return `
+        <div class="text-5xl md:text-6xl font-bold text-transparent bg-clip-text bg-gradient-to-r from-blue-400 via-indigo-400 to-purple-400 animate-subtle-glow py-2">
+          ${escapeHTML(picks[0].option.text)}
+        </div>
+        <div class="flex justify-center gap-3 flex-wrap">
+          ${renderPickBadges(picks[0])}
+        </div>
+      `;
+    }
+
+    const items = picks.map((pick, i) => `
+      <li class="flex items-center gap-4 rounded-xl bg-white/10 border border-white/20 px-4 py-3">
+        <span class="flex-none w-8 h-8 rounded-full bg-blue-500/30 text-white font-bold flex items-center justify-center">${i + 1}</span>
+        <div class="min-w-0 flex-1 space-y-2">
+          <div class="text-2xl font-bold text-transparent bg-clip-text bg-gradient-to-r from-blue-400 via-indigo-400 to-purple-400 truncate">
+            ${escapeHTML(pick.option.text)}
+          </div>
+          <div class="flex gap-2 flex-wrap">
+            ${renderPickBadges(pick)}
+          </div>
+        </div>
+      </li>
+    `).join('');
+    return `<ol class="space-y-3 text-left" id="result-picks">${items}</ol>`;
+  }
+
+  // Render result card
+  function renderResult(selection) {
+    const picks = selection.picks;
+    const shortHTML = picks.length < selection.requested
+      ? `<div class="text-amber-200 text-sm" id="result-short">
+          Only ${picks.length} of ${selection.requested} could be picked with these filters
+        </div>`
+      : '';
+
     return `
       <div class="bg-white/15 backdrop-blur-md rounded-2xl p-8 border border-white/30 shadow-2xl animate-scale-in" id="result-card">
         <div class="text-center space-y-5">
           <div class="text-white/70 text-sm uppercase tracking-wider font-medium">
-            🎯 Your Decision:
-          </div>
-          <div class="text-5xl md:text-6xl font-bold text-transparent bg-clip-text bg-gradient-to-r from-blue-400 via-indigo-400 to-purple-400 animate-subtle-glow py-2">
-            ${escapeHTML(option.text)}
-          </div>
-          <div class="flex justify-center gap-3 flex-wrap">
-            ${durationHTML}
-            <span class="badge">
-              <svg xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" stroke-width="1.5" stroke="currentColor">
-                <path stroke-linecap="round" stroke-linejoin="round" d="M5.25 5.653c0-.856.917-1.398 1.667-.986l11.54 6.347a1.125 1.125 0 0 1 0 1.972l-11.54 6.347a1.125 1.125 0 0 1-1.667-.986V5.653Z"></path>
-              </svg>
-              ${probability}%
-            </span>
+            ${picks.length > 1 ? '🎯 Your Picks:' : '🎯 Your Decision:'}
           </div>
+          ${renderPicks(picks)}
+          ${shortHTML}
+          ${renderDistribution(selection)}
           <div class="pt-2">
             <button 
               data-dismiss-result
@@ -256,6 +327,17 @@
       .slice(0, 5);
   }
 
+  // Parse the tag filter controls of the spin form. Untagged options are included unless turned off.
+  function parseTagFilter(formData) {
+    const clean = values => [...new Set(values.map(t => t.trim().toLowerCase()).filter(t => t.length > 0))];
+    return {
+      mode: formData.get('tag_mode') || 'any',
+      include: clean(formData.getAll('tags[]')),
+      exclude: clean(formData.getAll('exclude_tags[]')),
+      includeUntagged: formData.get('include_untagged') !== 'false'
+    };
+  }
+
   // Set up event delegation for local storage operations
   function setupEventDelegation() {
     // Handle form submissions and button clicks
@@ -346,9 +428,13 @@
       
       const formData = new FormData(event.detail.elt);
       const timeConstraint = parseTimeConstraint(formData);
-      const tags = parseTags(formData);
+      const tagFilter = parseTagFilter(formData);
+
+      const strategy = formData.get('strategy') || 'weighted';
+      const noRepeat = formData.get('no_repeat');
+      const count = formData.get('count');
 
-      const selected = LocalStorageManager.selectRandom(timeConstraint, tags);
+      const selected = LocalStorageManager.selectRandom(timeConstraint, tagFilter, strategy, noRepeat, count);
       // event.detail.target is the selector string (e.g., "#result")
       // We need to use querySelector to get the element
       const target = typeof event.detail.target === 'string' 
@@ -466,12 +552,14 @@
         headers: {
           'Content-Type': 'application/json',
         },
-        body: JSON.stringify(options)
+        // Local IDs let the server skip options it has already imported
+        body: JSON.stringify({ options: options })
       });
 
       if (response.ok) {
+        const result = await response.json();
         LocalStorageManager.clear();
-        console.log('Local storage options synced to server');
+        console.log(`Local storage options synced to server: ${result.created} created, ${result.merged} merged, ${result.skipped} skipped`);
       }
     } catch (error) {
       console.error('Failed to sync local storage:', error);
@@ -3,6 +3,11 @@
 const LocalStorageManager = (function() {
   const STORAGE_KEY = 'wheel_options';
   const EXPIRY_KEY = 'wheel_options_expiry';
+  const HISTORY_KEY = 'wheel_history';
+  const MAX_HISTORY = 100;
+  const MAX_PICKS = 10; // matches selection.MaxPicks
+  const MAX_WEIGHT = 100; // matches selection.MaxWeight
+  const MAX_DESCRIPTION_LENGTH = 5000; // matches markdown.MaxLength
   const TTL_DAYS = 7;
   const TTL_MS = TTL_DAYS * 24 * 60 * 60 * 1000;
 
@@ -58,12 +63,14 @@ const LocalStorageManager = (function() {
     return `local-${timestamp}-${random}`;
   }
 
-  // Validate and clamp values
+  // Validate and clamp values. A weight of 0 keeps the option from coming up.
   function validateOption(option) {
+    const weight = parseInt(option.weight, 10);
     return {
       id: option.id || generateID(),
       text: (option.text || '').trim(),
-      weight: Math.max(1, Math.min(10, parseInt(option.weight, 10) || 1)),
+      description: typeof option.description === 'string' ? Array.from(option.description.trim()).slice(0, MAX_DESCRIPTION_LENGTH).join('') : '',
+      weight: Number.isNaN(weight) ? 1 : Math.max(0, Math.min(MAX_WEIGHT, weight)),
       duration: option.duration === null || option.duration === undefined ? null : Math.max(0, Math.min(1440, parseInt(option.duration, 10))),
       tags: Array.isArray(option.tags) ? option.tags.slice(0, 5).map(t => t.trim().toLowerCase()) : []
     };
@@ -126,6 +133,62 @@ const LocalStorageManager = (function() {
   function clear() {
     localStorage.removeItem(STORAGE_KEY);
     localStorage.removeItem(EXPIRY_KEY);
+    localStorage.removeItem(HISTORY_KEY);
+  }
+
+  // Get IDs of the most recent picks, newest first
+  function getHistory() {
+    try {
+      const data = localStorage.getItem(HISTORY_KEY);
+      if (!data) return [];
+      const history = JSON.parse(data);
+      return Array.isArray(history) ? history : [];
+    } catch (e) {
+      console.error('Failed to parse history from localStorage:', e);
+      return [];
+    }
+  }
+
+  // Record a pick at the front of the history
+  function recordPick(id) {
+    const history = [id, ...getHistory()].slice(0, MAX_HISTORY);
+    try {
+      localStorage.setItem(HISTORY_KEY, JSON.stringify(history));
+    } catch (e) {
+      console.error('Failed to save history to localStorage:', e);
+    }
+  }
+
+  // Apply the selection strategy (matches internal/selection)
+  function eligibleForStrategy(options, strategy, noRepeat) {
+    const history = getHistory();
+    const ids = new Set(options.map(opt => opt.id));
+
+    switch (strategy) {
+      case 'uniform':
+        return options.map(opt => ({ ...opt, weight: 1 }));
+      case 'shuffle': {
+        // Replay the history oldest first, refilling the bag every time it runs empty
+        const drawn = new Set();
+        for (let i = history.length - 1; i >= 0; i--) {
+          if (!ids.has(history[i])) continue;
+          drawn.add(history[i]);
+          if (drawn.size === options.length) drawn.clear();
+        }
+        return options.filter(opt => !drawn.has(opt.id));
+      }
+      case 'no-repeat': {
+        const limit = Math.min(Math.max(parseInt(noRepeat, 10) || 1, 1), options.length - 1);
+        const excluded = new Set();
+        for (const id of history) {
+          if (excluded.size >= limit) break;
+          if (ids.has(id)) excluded.add(id);
+        }
+        return options.filter(opt => !excluded.has(opt.id));
+      }
+      default:
+        return options;
+    }
   }
 
   // Get all unique tags
@@ -147,9 +210,33 @@ const LocalStorageManager = (function() {
     return options.reduce((sum, opt) => sum + opt.weight, 0);
   }
 
+  // Check whether an option's tags pass the tag filter. Matches selection.TagFilter on the server:
+  // untagged options pass only if includeUntagged is set, tagged options with an excluded tag never pass,
+  // and otherwise they need any of the included tags, or all of them in "all" mode.
+  function matchesTagFilter(optionTags, tagFilter) {
+    const tags = Array.isArray(optionTags) ? optionTags : [];
+    if (tags.length === 0) {
+      return tagFilter.includeUntagged !== false;
+    }
+
+    const include = tagFilter.include || [];
+    const exclude = tagFilter.exclude || [];
+    if (tags.some(tag => exclude.includes(tag))) {
+      return false;
+    }
+    if (include.length === 0) {
+      return true;
+    }
+    if (tagFilter.mode === 'all') {
+      return include.every(tag => tags.includes(tag));
+    }
+    return include.some(tag => tags.includes(tag));
+  }
+
   // Filter options by time constraint and tags
-  function filterOptions(timeConstraint, tags) {
-    let options = getOptions();
+  function filterOptions(timeConstraint, tagFilter) {
+    // Options weighted 0 never come up
+    let options = getOptions().filter(opt => opt.weight > 0);
 
     // Filter by time constraint
     if (timeConstraint && timeConstraint !== 'any') {
@@ -160,25 +247,16 @@ const LocalStorageManager = (function() {
       });
     }
 
-    // Filter by tags (must have ALL specified tags)
-    if (tags && tags.length > 0) {
-      options = options.filter(opt => {
-        if (!Array.isArray(opt.tags)) return false;
-        return tags.every(tag => opt.tags.includes(tag.toLowerCase()));
-      });
+    // Filter by tags
+    if (tagFilter) {
+      options = options.filter(opt => matchesTagFilter(opt.tags, tagFilter));
     }
 
     return options;
   }
 
-  // Select random option using weighted selection
-  function selectRandom(timeConstraint, tags) {
-    const options = filterOptions(timeConstraint, tags);
-
-    if (options.length === 0) {
-      return null;
-    }
-
+  // Weighted pick from the options (matches selection.Pick)
+  function pickWeighted(options) {
     const totalWeight = getTotalWeight(options);
     let random = Math.random() * totalWeight;
 
@@ -188,11 +266,87 @@ const LocalStorageManager = (function() {
         return option;
       }
     }
-
-    // Fallback (should never reach here)
     return options[options.length - 1];
   }
 
+  // Chance each option has of being one of count weighted picks without replacement (matches selection.InclusionProbabilities).
+  // Integrates over the arrival time of the option, where every option arrives after an exponential time with its weight as the rate
+  // and the count earliest arrivals are picked.
+  function inclusionProbabilities(options, count) {
+    if (count >= options.length) {
+      return options.map(() => 1);
+    }
+    const totalWeight = getTotalWeight(options);
+    if (count === 1) {
+      return options.map(opt => opt.weight / totalWeight);
+    }
+
+    const weights = options.map(opt => opt.weight);
+    const lightest = Math.min(...weights);
+    const heaviest = Math.max(...weights);
+    const step = 1 / 32;
+    const byWeight = new Map();
+
+    return options.map((option, i) => {
+      if (byWeight.has(option.weight)) return byWeight.get(option.weight);
+
+      let sum = 0;
+      for (let x = Math.log(1e-9 / heaviest); x <= Math.log(40 / lightest); x += step) {
+        const t = Math.exp(x);
+        // Chance that exactly c of the other options have arrived by t
+        const counts = new Array(count).fill(0);
+        counts[0] = 1;
+        options.forEach((other, j) => {
+          if (j === i) return;
+          const arrived = -Math.expm1(-other.weight * t);
+          for (let c = count - 1; c > 0; c--) {
+            counts[c] = counts[c] * (1 - arrived) + counts[c - 1] * arrived;
+          }
+          counts[0] *= 1 - arrived;
+        });
+        const fewer = counts.reduce((total, p) => total + p, 0);
+        sum += option.weight * t * Math.exp(-option.weight * t) * fewer;
+      }
+
+      const probability = Math.min(Math.max(sum * step, 0), 1);
+      byWeight.set(option.weight, probability);
+      return probability;
+    });
+  }
+
+  // Select count distinct options using the selection strategy
+  // Returns the picks in the order they were drawn along with the eligible options and their chance of being picked
+  function selectRandom(timeConstraint, tagFilter, strategy, noRepeat, count) {
+    const filtered = filterOptions(timeConstraint, tagFilter);
+    const options = eligibleForStrategy(filtered, strategy, noRepeat);
+
+    if (options.length === 0) {
+      return null;
+    }
+
+    count = Math.min(Math.max(parseInt(count, 10) || 1, 1), MAX_PICKS);
+    const probabilities = inclusionProbabilities(options, count);
+    const eligible = options.map((opt, i) => ({ ...opt, probability: probabilities[i] }));
+
+    const remaining = [...eligible];
+    const picks = [];
+    while (picks.length < count && remaining.length > 0) {
+      const selected = pickWeighted(remaining);
+      remaining.splice(remaining.indexOf(selected), 1);
+      recordPick(selected.id);
+      picks.push({
+        option: filtered.find(opt => opt.id === selected.id) || selected,
+        probability: selected.probability
+      });
+    }
+
+    return {
+      picks: picks,
+      requested: count,
+      eligible: eligible
+    };
+  }
+
   // Get options count
   function getCount() {
     return getOptions().length;
@@ -212,6 +366,8 @@ const LocalStorageManager = (function() {
     getAllTags,
     getTotalWeight,
     filterOptions,
+    matchesTagFilter,
+    getHistory,
     selectRandom,
     getCount
   };
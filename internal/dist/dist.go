@@ -6,3 +6,8 @@ import (
 
 //go:embed all:assets
 var AssetsDir embed.FS
+
+// OpenAPI is the OpenAPI document describing the /api/v1 JSON API.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
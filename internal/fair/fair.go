@@ -0,0 +1,135 @@
+// Package fair makes spins provably fair with a commit and reveal scheme.
+//
+// Before a spin the server commits to a secret server seed by publishing its SHA-256 hash. The spin combines the
+// server seed with a client seed chosen by the person spinning, then reveals the server seed. Anyone can check the
+// revealed seed against the hash published beforehand and replay the spin from the seeds and the options it drew
+// from, so neither side could have steered the pick. A server seed is only ever used for one spin.
+//
+// Randomness comes from HMAC-SHA256 keyed with the server seed over "<client seed>:<draw>", where draw counts up
+// from 0 for every number the spin needs. The first 8 bytes of each digest are read as a big-endian unsigned integer
+// and reduced modulo n. Values from the top of the range that would make some results more likely are skipped by
+// moving on to the next draw.
+package fair
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Piszmog/make-a-decision/internal/selection"
+)
+
+// MaxClientSeedLength is the longest client seed accepted
+const MaxClientSeedLength = 64
+
+// serverSeedBytes is how many random bytes a server seed has
+const serverSeedBytes = 32
+
+var (
+	// ErrClientSeedTooLong is returned when a client seed is longer than MaxClientSeedLength
+	ErrClientSeedTooLong = errors.New("client seed can be at most 64 characters")
+	// ErrInvalidServerSeed is returned when a server seed is not 64 hexadecimal characters
+	ErrInvalidServerSeed = errors.New("server seed must be 64 hexadecimal characters")
+)
+
+// NewServerSeed returns a new random server seed, hex encoded
+func NewServerSeed() (string, error) {
+	b := make([]byte, serverSeedBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ValidateServerSeed checks the seed looks like one made by NewServerSeed
+func ValidateServerSeed(seed string) error {
+	b, err := hex.DecodeString(seed)
+	if err != nil || len(b) != serverSeedBytes {
+		return ErrInvalidServerSeed
+	}
+	return nil
+}
+
+// CleanClientSeed trims the client seed and checks its length. A blank client seed is allowed.
+func CleanClientSeed(seed string) (string, error) {
+	seed = strings.TrimSpace(seed)
+	if len(seed) > MaxClientSeedLength {
+		return "", ErrClientSeedTooLong
+	}
+	return seed, nil
+}
+
+// Hash returns the commitment published for a server seed before it is used
+func Hash(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches reports whether the server seed is the one committed to by the hash
+func Matches(serverSeed, hash string) bool {
+	return hmac.Equal([]byte(Hash(serverSeed)), []byte(strings.ToLower(strings.TrimSpace(hash))))
+}
+
+// Source is a selection.Source whose numbers are fixed by a server seed and a client seed
+type Source struct {
+	serverSeed []byte
+	clientSeed string
+	draw       uint64
+}
+
+// NewSource returns the source for a spin with the seeds
+func NewSource(serverSeed, clientSeed string) *Source {
+	return &Source{serverSeed: []byte(serverSeed), clientSeed: clientSeed}
+}
+
+// next returns the number for the next draw
+func (s *Source) next() uint64 {
+	mac := hmac.New(sha256.New, s.serverSeed)
+	mac.Write([]byte(s.clientSeed + ":" + strconv.FormatUint(s.draw, 10)))
+	s.draw++
+	return binary.BigEndian.Uint64(mac.Sum(nil)[:8])
+}
+
+// Int64N returns a number in [0, n)
+func (s *Source) Int64N(n int64) int64 {
+	bound := uint64(n)
+	// Values at or above limit fall in an incomplete last cycle of the modulo, so they are skipped
+	limit := math.MaxUint64 - math.MaxUint64%bound
+	for {
+		if v := s.next(); v < limit {
+			return int64(v % bound)
+		}
+	}
+}
+
+// Option is an option as it stood when a spin drew from it. The weight is the one the spin picked by,
+// after the strategy adjusted it.
+type Option struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Weight int64  `json:"weight"`
+}
+
+// Replay draws count options from the snapshot with the seeds, returning them in the order they were drawn.
+// A spin made with the same seeds and options always draws the same options.
+func Replay(serverSeed, clientSeed string, options []Option, count int) []Option {
+	candidates := make([]selection.Option, len(options))
+	byID := make(map[int64]Option, len(options))
+	for i, opt := range options {
+		candidates[i] = selection.Option{ID: opt.ID, Weight: opt.Weight}
+		byID[opt.ID] = opt
+	}
+
+	picked := selection.PickN(NewSource(serverSeed, clientSeed), candidates, count)
+	replayed := make([]Option, len(picked))
+	for i, opt := range picked {
+		replayed[i] = byID[opt.ID]
+	}
+	return replayed
+}
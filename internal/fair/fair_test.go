@@ -0,0 +1,94 @@
+package fair_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/fair"
+	"github.com/stretchr/testify/require"
+)
+
+const serverSeed = "3f1c5e2a9b7d4c6e8f0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60"
+
+func TestHash(t *testing.T) {
+	require.Equal(t, "03ac674216f3e15c761ee1a5e255f067953623c8b388b4459e13f978d7c846f4", fair.Hash("1234"))
+	require.True(t, fair.Matches("1234", "03AC674216F3E15C761EE1A5E255F067953623C8B388B4459E13F978D7C846F4 "))
+	require.False(t, fair.Matches("1235", fair.Hash("1234")))
+}
+
+func TestNewServerSeed(t *testing.T) {
+	seed, err := fair.NewServerSeed()
+	require.NoError(t, err)
+	require.NoError(t, fair.ValidateServerSeed(seed))
+
+	other, err := fair.NewServerSeed()
+	require.NoError(t, err)
+	require.NotEqual(t, seed, other)
+
+	for _, invalid := range []string{"", "abc", strings.Repeat("z", 64), seed[:62]} {
+		require.ErrorIs(t, fair.ValidateServerSeed(invalid), fair.ErrInvalidServerSeed, invalid)
+	}
+}
+
+func TestCleanClientSeed(t *testing.T) {
+	seed, err := fair.CleanClientSeed("  lucky  ")
+	require.NoError(t, err)
+	require.Equal(t, "lucky", seed)
+
+	_, err = fair.CleanClientSeed(strings.Repeat("a", fair.MaxClientSeedLength+1))
+	require.ErrorIs(t, err, fair.ErrClientSeedTooLong)
+}
+
+func TestSourceDraws(t *testing.T) {
+	// Each draw is the HMAC of "<client seed>:<draw>" keyed with the server seed
+	src := fair.NewSource(serverSeed, "lucky")
+	for draw := range 3 {
+		mac := hmac.New(sha256.New, []byte(serverSeed))
+		mac.Write([]byte("lucky:" + strconv.Itoa(draw)))
+		expected := binary.BigEndian.Uint64(mac.Sum(nil)[:8]) % (1 << 20)
+		require.Equal(t, int64(expected), src.Int64N(1<<20))
+	}
+}
+
+func TestSourceIsEven(t *testing.T) {
+	src := fair.NewSource(serverSeed, "")
+	counts := make([]int, 4)
+	for range 20000 {
+		counts[src.Int64N(4)]++
+	}
+	for _, count := range counts {
+		require.InDelta(t, 5000, count, 300)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	options := []fair.Option{
+		{ID: 1, Name: "Hike", Weight: 1},
+		{ID: 2, Name: "Museum", Weight: 2},
+		{ID: 3, Name: "Picnic", Weight: 5},
+		{ID: 4, Name: "Cinema", Weight: 10},
+	}
+
+	picks := fair.Replay(serverSeed, "lucky", options, 3)
+	require.Len(t, picks, 3)
+	require.Equal(t, picks, fair.Replay(serverSeed, "lucky", options, 3), "the same seeds always pick the same options")
+
+	seen := make(map[int64]bool, len(picks))
+	for _, pick := range picks {
+		require.False(t, seen[pick.ID], "%s picked twice", pick.Name)
+		seen[pick.ID] = true
+	}
+
+	// Changing the client seed changes the picks
+	changed := false
+	for _, clientSeed := range []string{"a", "b", "c", "d", "e"} {
+		if fair.Replay(serverSeed, clientSeed, options, 3)[0] != picks[0] || fair.Replay(serverSeed, clientSeed, options, 3)[1] != picks[1] {
+			changed = true
+		}
+	}
+	require.True(t, changed)
+}
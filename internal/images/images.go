@@ -0,0 +1,221 @@
+// Package images resizes the images uploaded for options and stores them, in the database or in a directory.
+//
+// Uploads can be JPEG, PNG, GIF or WebP. Every upload is re-encoded as a JPEG no larger than MaxDimension on its longest
+// side, along with a thumbnail no larger than ThumbnailDimension, so what is stored never carries the original file's
+// metadata. Photos are turned upright using their EXIF orientation first.
+package images
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	_ "image/png" // register the PNG decoder
+	"io"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+const (
+	// MaxUploadSize is the largest file, in bytes, that can be uploaded
+	MaxUploadSize = 10 << 20
+	// MaxDimension is the longest side, in pixels, of a stored image
+	MaxDimension = 1600
+	// ThumbnailDimension is the longest side, in pixels, of a thumbnail
+	ThumbnailDimension = 320
+	// ContentType is the media type every stored image is served with
+	ContentType = "image/jpeg"
+
+	// maxPixels keeps a small file that decodes to a huge image from using up memory
+	maxPixels = 50_000_000
+	quality   = 85
+)
+
+var (
+	ErrTooLarge      = fmt.Errorf("image must be at most %d MB", MaxUploadSize>>20)
+	ErrTooManyPixels = fmt.Errorf("image must be at most %d megapixels", maxPixels/1_000_000)
+	ErrUnsupported   = errors.New("image must be a JPEG, PNG, GIF or WebP")
+)
+
+// Processed is an upload resized for storage
+type Processed struct {
+	Image     []byte
+	Thumbnail []byte
+}
+
+// NewKey returns a random key to store an image under
+func NewKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate image key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Process reads an upload and resizes it into an image and a thumbnail
+func Process(r io.Reader) (Processed, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxUploadSize+1))
+	if err != nil {
+		return Processed{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > MaxUploadSize {
+		return Processed{}, ErrTooLarge
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return Processed{}, ErrUnsupported
+	}
+	if config.Width*config.Height > maxPixels {
+		return Processed{}, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, ErrUnsupported
+	}
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(data)
+	}
+
+	full, err := encode(orient(fit(img, MaxDimension), orientation))
+	if err != nil {
+		return Processed{}, err
+	}
+	thumbnail, err := encode(orient(fit(img, ThumbnailDimension), orientation))
+	if err != nil {
+		return Processed{}, err
+	}
+	return Processed{Image: full, Thumbnail: thumbnail}, nil
+}
+
+// fit scales the image down so its longest side is at most size, flattening any transparency onto white
+func fit(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if longest := max(width, height); longest > size {
+		width = max(1, width*size/longest)
+		height = max(1, height*size/longest)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	if width == bounds.Dx() && height == bounds.Dy() {
+		draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	} else {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, xdraw.Over, nil)
+	}
+	return dst
+}
+
+// orient turns the image upright for its EXIF orientation, from 1, already upright, to 8
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // upside down
+				dx, dy = w-1-x, h-1-y
+			case 4: // upside down and mirrored
+				dx, dy = x, h-1-y
+			case 5: // on its side and mirrored
+				dx, dy = y, x
+			case 6: // needs turning clockwise
+				dx, dy = h-1-y, x
+			case 7: // on its other side and mirrored
+				dx, dy = h-1-y, w-1-x
+			case 8: // needs turning counterclockwise
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+	return dst
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 when it has none
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// Walk the segments before the image data for the EXIF one
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i = end
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first directory of EXIF data
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := range count {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
@@ -0,0 +1,192 @@
+package images_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/images"
+	"github.com/stretchr/testify/require"
+)
+
+// halves returns an image with its left half red and its right half blue
+func halves(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, image.Rect(0, 0, width/2, height), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(width/2, 0, width, height), image.NewUniform(color.RGBA{B: 255, A: 255}), image.Point{}, draw.Src)
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// encodeJPEG encodes the image as a JPEG with an EXIF orientation
+func encodeJPEG(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	data := buf.Bytes()
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00")
+	binary.BigEndian.PutUint16(tiff[18:], orientation)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func decode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, format, err := image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", format)
+	return img
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name      string
+		width     int
+		height    int
+		full      image.Point
+		thumbnail image.Point
+	}{
+		{name: "wide", width: 3200, height: 1600, full: image.Pt(1600, 800), thumbnail: image.Pt(320, 160)},
+		{name: "tall", width: 400, height: 2000, full: image.Pt(320, 1600), thumbnail: image.Pt(64, 320)},
+		{name: "small is not enlarged", width: 100, height: 50, full: image.Pt(100, 50), thumbnail: image.Pt(100, 50)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			processed, err := images.Process(bytes.NewReader(encodePNG(t, halves(test.width, test.height))))
+			require.NoError(t, err)
+			require.Equal(t, test.full, decode(t, processed.Image).Bounds().Size())
+			require.Equal(t, test.thumbnail, decode(t, processed.Thumbnail).Bounds().Size())
+		})
+	}
+}
+
+func TestProcessFlattensTransparency(t *testing.T) {
+	processed, err := images.Process(bytes.NewReader(encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 10, 10)))))
+	require.NoError(t, err)
+
+	r, g, b, _ := decode(t, processed.Image).At(5, 5).RGBA()
+	require.Greater(t, r, uint32(0xF000))
+	require.Greater(t, g, uint32(0xF000))
+	require.Greater(t, b, uint32(0xF000))
+}
+
+func TestProcessOrientation(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation uint16
+		size        image.Point
+		red         image.Point
+	}{
+		{name: "upright", orientation: 1, size: image.Pt(200, 100), red: image.Pt(10, 50)},
+		{name: "upside down", orientation: 3, size: image.Pt(200, 100), red: image.Pt(190, 50)},
+		{name: "turned clockwise", orientation: 6, size: image.Pt(100, 200), red: image.Pt(50, 10)},
+		{name: "turned counterclockwise", orientation: 8, size: image.Pt(100, 200), red: image.Pt(50, 190)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			processed, err := images.Process(bytes.NewReader(encodeJPEG(t, halves(200, 100), test.orientation)))
+			require.NoError(t, err)
+
+			img := decode(t, processed.Image)
+			require.Equal(t, test.size, img.Bounds().Size())
+			require.True(t, isRed(img.At(test.red.X, test.red.Y)))
+		})
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	// A GIF header claiming a huge image, which is rejected before it is decoded
+	huge := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "too large", data: bytes.Repeat([]byte{0}, images.MaxUploadSize+1), err: images.ErrTooLarge},
+		{name: "not an image", data: []byte("hello"), err: images.ErrUnsupported},
+		{name: "svg", data: []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), err: images.ErrUnsupported},
+		{name: "too many pixels", data: huge, err: images.ErrTooManyPixels},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := images.Process(bytes.NewReader(test.data))
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	first, err := images.NewKey()
+	require.NoError(t, err)
+	second, err := images.NewKey()
+	require.NoError(t, err)
+
+	require.Len(t, first, 32)
+	require.NotEqual(t, first, second)
+}
+
+func TestDirStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := images.NewDirStore(t.TempDir() + "/images")
+	require.NoError(t, err)
+
+	key, err := images.NewKey()
+	require.NoError(t, err)
+	_, err = store.Load(ctx, key, images.Full)
+	require.ErrorIs(t, err, images.ErrNotFound)
+
+	require.NoError(t, store.Save(ctx, key, images.Processed{Image: []byte("full"), Thumbnail: []byte("thumb")}))
+	full, err := store.Load(ctx, key, images.Full)
+	require.NoError(t, err)
+	require.Equal(t, []byte("full"), full)
+	thumbnail, err := store.Load(ctx, key, images.Thumbnail)
+	require.NoError(t, err)
+	require.Equal(t, []byte("thumb"), thumbnail)
+
+	require.NoError(t, store.Delete(ctx, key))
+	_, err = store.Load(ctx, key, images.Thumbnail)
+	require.ErrorIs(t, err, images.ErrNotFound)
+	// Deleting again is fine
+	require.NoError(t, store.Delete(ctx, key))
+}
+
+func TestDirStoreRejectsKeysOutsideTheDirectory(t *testing.T) {
+	ctx := context.Background()
+	store, err := images.NewDirStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../secret", "ABC", strings.Repeat("a", 10) + "/b"} {
+		require.Error(t, store.Save(ctx, key, images.Processed{Image: []byte("x"), Thumbnail: []byte("x")}))
+		_, err := store.Load(ctx, key, images.Full)
+		require.ErrorIs(t, err, images.ErrNotFound)
+	}
+}
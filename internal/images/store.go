@@ -0,0 +1,176 @@
+package images
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Piszmog/make-a-decision/internal/db"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+)
+
+// ErrNotFound is returned when no image is stored under a key
+var ErrNotFound = errors.New("image not found")
+
+// Size is which size of a stored image to load
+type Size int
+
+const (
+	Full Size = iota
+	Thumbnail
+)
+
+// Store keeps processed images under the keys they were saved with
+type Store interface {
+	Save(ctx context.Context, key string, img Processed) error
+	// Load returns the image of the size stored under the key. Returns ErrNotFound if there is none.
+	Load(ctx context.Context, key string, size Size) ([]byte, error)
+	// Delete removes the image stored under the key. Deleting a missing image is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// DBStore stores images in the database
+type DBStore struct {
+	Database db.Database
+}
+
+var _ Store = (*DBStore)(nil)
+
+// NewDBStore returns a store that keeps images in the database
+func NewDBStore(database db.Database) *DBStore {
+	return &DBStore{Database: database}
+}
+
+func (s *DBStore) Save(ctx context.Context, key string, img Processed) error {
+	if err := s.Database.Queries().SaveImage(ctx, queries.SaveImageParams{
+		Key:       key,
+		Image:     img.Image,
+		Thumbnail: img.Thumbnail,
+	}); err != nil {
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) Load(ctx context.Context, key string, size Size) ([]byte, error) {
+	q := s.Database.Queries()
+	var data []byte
+	var err error
+	if size == Thumbnail {
+		data, err = q.GetImageThumbnail(ctx, key)
+	} else {
+		data, err = q.GetImage(ctx, key)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image: %w", err)
+	}
+	return data, nil
+}
+
+func (s *DBStore) Delete(ctx context.Context, key string) error {
+	if err := s.Database.Queries().DeleteImage(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+	return nil
+}
+
+// DirStore stores images as files in a directory, with the image and its thumbnail side by side
+type DirStore struct {
+	Dir string
+}
+
+var _ Store = (*DirStore)(nil)
+
+// NewDirStore returns a store that keeps images in the directory, creating it if needed
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create image directory: %w", err)
+	}
+	return &DirStore{Dir: dir}, nil
+}
+
+// path returns the file the image of the size is stored in. Keys are only ever hex, so a key that is not
+// can never name a file outside the directory.
+func (s *DirStore) path(key string, size Size) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	for _, r := range key {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return "", false
+		}
+	}
+	if size == Thumbnail {
+		return filepath.Join(s.Dir, key+"-thumbnail.jpg"), true
+	}
+	return filepath.Join(s.Dir, key+".jpg"), true
+}
+
+func (s *DirStore) Save(_ context.Context, key string, img Processed) error {
+	for size, data := range map[Size][]byte{Full: img.Image, Thumbnail: img.Thumbnail} {
+		path, ok := s.path(key, size)
+		if !ok {
+			return fmt.Errorf("invalid image key %q", key)
+		}
+		if err := writeFile(path, data); err != nil {
+			return fmt.Errorf("failed to save image: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *DirStore) Load(_ context.Context, key string, size Size) ([]byte, error) {
+	path, ok := s.path(key, size)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image: %w", err)
+	}
+	return data, nil
+}
+
+func (s *DirStore) Delete(_ context.Context, key string) error {
+	for _, size := range []Size{Full, Thumbnail} {
+		path, ok := s.path(key, size)
+		if !ok {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete image: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeFile writes the file in full or not at all, so a failed save never leaves half an image behind
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Package markdown renders the Markdown descriptions users write on options as HTML that is safe to show on a page.
+//
+// Descriptions are rendered as GitHub flavored Markdown, so bare links work, and the HTML is sanitized afterwards.
+// Raw HTML in a description is never rendered, and links open in a new tab without passing on the page as referrer.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// MaxLength is the longest description, in characters, an option can have
+const MaxLength = 5000
+
+// ErrTooLong is returned when a description is longer than MaxLength
+var ErrTooLong = fmt.Errorf("description must be at most %d characters", MaxLength)
+
+var (
+	renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	policy   = newPolicy()
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.RequireNoReferrerOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Normalize trims a description and uses \n for every line break. Returns ErrTooLong if it is too long to store.
+func Normalize(source string) (string, error) {
+	source = strings.TrimSpace(strings.ReplaceAll(source, "\r\n", "\n"))
+	if utf8.RuneCountInString(source) > MaxLength {
+		return "", ErrTooLong
+	}
+	return source, nil
+}
+
+// Render returns the description as sanitized HTML, or an empty string for a blank description
+func Render(source string) string {
+	if strings.TrimSpace(source) == "" {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		// Fall back to the description as plain text
+		return "<p>" + html.EscapeString(source) + "</p>"
+	}
+	return string(policy.SanitizeBytes(buf.Bytes()))
+}
@@ -0,0 +1,62 @@
+package markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/markdown"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "blank", input: "  \n ", expected: ""},
+		{name: "paragraph", input: "Try *something* new", expected: "<p>Try <em>something</em> new</p>\n"},
+		{name: "steps", input: "1. Chop\n2. Fry", expected: "<ol>\n<li>Chop</li>\n<li>Fry</li>\n</ol>\n"},
+		{
+			name:     "link opens in a new tab",
+			input:    "[Recipe](https://example.com/pasta)",
+			expected: `<p><a href="https://example.com/pasta" rel="nofollow noreferrer noopener" target="_blank">Recipe</a></p>` + "\n",
+		},
+		{
+			name:     "bare link",
+			input:    "See https://example.com",
+			expected: `<p>See <a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">https://example.com</a></p>` + "\n",
+		},
+		{name: "raw html is dropped", input: "<script>alert(1)</script>\n\nHi", expected: "\n<p>Hi</p>\n"},
+		{name: "inline html is dropped", input: `Hi <img src=x onerror="alert(1)">`, expected: "<p>Hi </p>\n"},
+		{name: "javascript links are dropped", input: "[Click](javascript:alert(1))", expected: "<p>Click</p>\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, markdown.Render(test.input))
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{name: "trims", input: "  Hi\n", expected: "Hi"},
+		{name: "line breaks", input: "a\r\nb", expected: "a\nb"},
+		{name: "longest", input: strings.Repeat("é", markdown.MaxLength), expected: strings.Repeat("é", markdown.MaxLength)},
+		{name: "too long", input: strings.Repeat("a", markdown.MaxLength+1), err: markdown.ErrTooLong},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := markdown.Normalize(test.input)
+			require.ErrorIs(t, err, test.err)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
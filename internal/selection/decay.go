@@ -0,0 +1,29 @@
+package selection
+
+import (
+	"math"
+	"time"
+)
+
+// DecayScale multiplies the weights Decay returns, so a weight part way through recovering stays a whole number.
+const DecayScale = 1000
+
+// Decay returns the options with the weights they have after recent picks. An option's weight drops to almost
+// nothing when it is picked and wins back half of what it lost every half-life, while options that have not been
+// picked keep their full weight. Picked holds when each option was last picked. Weights are multiplied by DecayScale,
+// and an option that can come up is left a weight of at least 1.
+func Decay(options []Option, picked map[int64]time.Time, now time.Time, halfLife time.Duration) []Option {
+	decayed := make([]Option, len(options))
+	for i, opt := range options {
+		weight := float64(opt.Weight * DecayScale)
+		if at, ok := picked[opt.ID]; ok && halfLife > 0 {
+			elapsed := max(now.Sub(at), 0)
+			weight *= 1 - math.Exp2(-float64(elapsed)/float64(halfLife))
+		}
+		decayed[i] = Option{ID: opt.ID, Weight: int64(math.Round(weight))}
+		if opt.Weight > 0 {
+			decayed[i].Weight = max(decayed[i].Weight, 1)
+		}
+	}
+	return decayed
+}
@@ -0,0 +1,43 @@
+package selection_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecay(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	halfLife := 2 * time.Hour
+
+	tests := []struct {
+		name     string
+		weight   int64
+		picked   *time.Time
+		expected int64
+	}{
+		{name: "never picked keeps its weight", weight: 3, expected: 3000},
+		{name: "just picked is left the least weight", weight: 3, picked: &now, expected: 1},
+		{name: "one half-life recovers half", weight: 3, picked: ptr(now.Add(-halfLife)), expected: 1500},
+		{name: "two half-lives recover three quarters", weight: 4, picked: ptr(now.Add(-2 * halfLife)), expected: 3000},
+		{name: "picked in the future counts as just picked", weight: 2, picked: ptr(now.Add(time.Hour)), expected: 1},
+		{name: "never stays never", weight: 0, picked: ptr(now.Add(-halfLife)), expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			picked := map[int64]time.Time{}
+			if test.picked != nil {
+				picked[1] = *test.picked
+			}
+			decayed := selection.Decay([]selection.Option{{ID: 1, Weight: test.weight}}, picked, now, halfLife)
+			require.Equal(t, []selection.Option{{ID: 1, Weight: test.expected}}, decayed)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
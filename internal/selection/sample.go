@@ -0,0 +1,116 @@
+package selection
+
+import "math"
+
+// MaxPicks is the most options a single spin can pick.
+const MaxPicks = 10
+
+// MaxWeight is the heaviest an option can be weighted. An option weighted 0 never comes up.
+const MaxWeight = 100
+
+// inclusionStep is the spacing, in log time, of the points inclusion probabilities are integrated over.
+const inclusionStep = 1.0 / 32
+
+// PickN performs weighted random sampling without replacement, picking up to n distinct options in the order they were drawn.
+// Each draw is a weighted pick from the options not drawn yet. Fewer than n options are returned when there are not enough to pick from.
+func PickN(src Source, options []Option, n int) []Option {
+	remaining := append([]Option(nil), options...)
+	picked := make([]Option, 0, min(n, len(options)))
+	for len(picked) < n {
+		opt, ok := Pick(src, remaining)
+		if !ok {
+			break
+		}
+		picked = append(picked, opt)
+		for i := range remaining {
+			if remaining[i].ID == opt.ID {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+	return picked
+}
+
+// InclusionProbabilities returns the chance each option has of being one of the n options picked by PickN, in the same order as the options.
+// The chances add up to n, or to the number of options when there are fewer than n. Weights must be positive.
+func InclusionProbabilities(options []Option, n int) []float64 {
+	probabilities := make([]float64, len(options))
+	if n <= 0 || len(options) == 0 {
+		return probabilities
+	}
+	if n >= len(options) {
+		for i := range probabilities {
+			probabilities[i] = 1
+		}
+		return probabilities
+	}
+
+	total := TotalWeight(options)
+	if n == 1 {
+		for i, opt := range options {
+			probabilities[i] = float64(opt.Weight) / float64(total)
+		}
+		return probabilities
+	}
+
+	// Options with the same weight have the same chance
+	byWeight := make(map[int64]float64)
+	for i, opt := range options {
+		p, ok := byWeight[opt.Weight]
+		if !ok {
+			p = inclusionProbability(options, i, n)
+			byWeight[opt.Weight] = p
+		}
+		probabilities[i] = p
+	}
+	return probabilities
+}
+
+// inclusionProbability returns the chance the option at index i is one of n weighted picks without replacement.
+//
+// Drawing without replacement in proportion to weight picks the same options, in the same order, as giving every option
+// an exponentially distributed arrival time with its weight as the rate and taking the n earliest arrivals.
+// The option is picked when fewer than n of the others arrive before it does:
+//
+//	P(i) = ∫₀^∞ wᵢ·e^(-wᵢ·t) · P(fewer than n others arrive by t) dt
+//
+// Another option j has arrived by t with chance 1 - e^(-wⱼ·t). Substituting t = eˣ makes every term a smooth bump,
+// which the trapezoidal rule integrates accurately. The range covers everything but a negligible sliver at either end.
+func inclusionProbability(options []Option, i, n int) float64 {
+	weight := float64(options[i].Weight)
+	lightest, heaviest := weight, weight
+	for _, opt := range options {
+		lightest = min(lightest, float64(opt.Weight))
+		heaviest = max(heaviest, float64(opt.Weight))
+	}
+
+	// integrand returns the chance of the option arriving at t, scaled by t for the substitution,
+	// times the chance that fewer than n of the others have arrived by then
+	counts := make([]float64, n)
+	integrand := func(t float64) float64 {
+		clear(counts)
+		counts[0] = 1
+		for j, opt := range options {
+			if j == i {
+				continue
+			}
+			arrived := -math.Expm1(-float64(opt.Weight) * t)
+			for c := n - 1; c > 0; c-- {
+				counts[c] = counts[c]*(1-arrived) + counts[c-1]*arrived
+			}
+			counts[0] *= 1 - arrived
+		}
+		var fewer float64
+		for _, p := range counts {
+			fewer += p
+		}
+		return weight * t * math.Exp(-weight*t) * fewer
+	}
+
+	var sum float64
+	for x := math.Log(1e-9 / heaviest); x <= math.Log(40/lightest); x += inclusionStep {
+		sum += integrand(math.Exp(x))
+	}
+	return min(max(sum*inclusionStep, 0), 1)
+}
@@ -0,0 +1,96 @@
+package selection_test
+
+import (
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/stretchr/testify/require"
+)
+
+// exactInclusion returns inclusion probabilities by walking every order the options can be drawn in
+func exactInclusion(options []selection.Option, n int) []float64 {
+	probabilities := make([]float64, len(options))
+	var walk func(drawn []bool, depth int, chance float64)
+	walk = func(drawn []bool, depth int, chance float64) {
+		if depth == n {
+			return
+		}
+		var total int64
+		for i, opt := range options {
+			if !drawn[i] {
+				total += opt.Weight
+			}
+		}
+		for i, opt := range options {
+			if drawn[i] {
+				continue
+			}
+			p := chance * float64(opt.Weight) / float64(total)
+			probabilities[i] += p
+			drawn[i] = true
+			walk(drawn, depth+1, p)
+			drawn[i] = false
+		}
+	}
+	walk(make([]bool, len(options)), 0, 1)
+	return probabilities
+}
+
+func TestInclusionProbabilities(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int64
+		n       int
+	}{
+		{name: "single pick", weights: []int64{1, 2, 3, 4}, n: 1},
+		{name: "equal weights", weights: []int64{1, 1, 1, 1, 1}, n: 2},
+		{name: "mixed weights", weights: []int64{1, 2, 3, 4, 10}, n: 2},
+		{name: "three of six", weights: []int64{10, 1, 5, 1, 3, 7}, n: 3},
+		{name: "all but one", weights: []int64{10, 1, 1, 10, 2}, n: 4},
+		{name: "extreme weights", weights: []int64{1, 10, 10, 10, 1, 1, 1}, n: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := make([]selection.Option, len(test.weights))
+			for i, weight := range test.weights {
+				options[i] = selection.Option{ID: int64(i + 1), Weight: weight}
+			}
+
+			expected := exactInclusion(options, test.n)
+			actual := selection.InclusionProbabilities(options, test.n)
+			require.InDeltaSlice(t, expected, actual, 1e-6)
+
+			var sum float64
+			for _, p := range actual {
+				sum += p
+			}
+			require.InDelta(t, float64(test.n), sum, 1e-6)
+		})
+	}
+}
+
+func TestInclusionProbabilitiesFewerOptionsThanPicks(t *testing.T) {
+	options := []selection.Option{{ID: 1, Weight: 1}, {ID: 2, Weight: 9}}
+	require.Equal(t, []float64{1, 1}, selection.InclusionProbabilities(options, 3))
+	require.Equal(t, []float64{0, 0}, selection.InclusionProbabilities(options, 0))
+}
+
+func TestPickN(t *testing.T) {
+	options := []selection.Option{{ID: 1, Weight: 1}, {ID: 2, Weight: 5}, {ID: 3, Weight: 2}, {ID: 4, Weight: 8}}
+
+	for range 100 {
+		picked := selection.PickN(selection.Random, options, 3)
+		require.Len(t, picked, 3)
+
+		seen := make(map[int64]bool, len(picked))
+		for _, opt := range picked {
+			require.False(t, seen[opt.ID], "option %d picked twice", opt.ID)
+			seen[opt.ID] = true
+		}
+	}
+
+	require.Len(t, selection.PickN(selection.Random, options, 10), len(options))
+	require.Empty(t, selection.PickN(selection.Random, nil, 3))
+	require.Len(t, options, 4, "the options passed in are left alone")
+}
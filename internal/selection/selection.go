@@ -0,0 +1,284 @@
+package selection
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+)
+
+// Strategy names as submitted by the spin form.
+const (
+	StrategyWeighted   = "weighted"
+	StrategyUniform    = "uniform"
+	StrategyShuffleBag = "shuffle"
+	StrategyNoRepeat   = "no-repeat"
+	// StrategyElimination draws from the options left in an elimination round. See NewElimination.
+	StrategyElimination = "elimination"
+)
+
+// Elimination variants as submitted by the spin form.
+const (
+	// EliminationLastStanding knocks out the option drawn on each spin until one is left, which wins.
+	EliminationLastStanding = "last-standing"
+	// EliminationFirstPick makes the first option drawn the winner. Later spins rank the rest.
+	EliminationFirstPick = "first-pick"
+)
+
+var (
+	// ErrUnknownStrategy is returned when a strategy name is not recognized.
+	ErrUnknownStrategy = errors.New("unknown selection strategy")
+	// ErrUnknownEliminationVariant is returned when an elimination variant is not recognized.
+	ErrUnknownEliminationVariant = errors.New("unknown elimination variant")
+)
+
+// inverseWeightScale is divisible by every weight from 1 to 16, so those inverted weights stay whole numbers.
+// Heavier weights are rounded down by less than 0.02% of their inverse.
+const inverseWeightScale = 720720
+
+// Option is a candidate for selection along with the weight it is picked by.
+type Option struct {
+	ID     int64
+	Weight int64
+}
+
+// Strategy decides which options can come up on a spin and how likely each one is.
+type Strategy interface {
+	// Name returns the name the strategy is selected by.
+	Name() string
+	// Eligible returns the options that can be picked and the weight to pick them by.
+	// History holds the IDs of the most recent picks, newest first.
+	Eligible(options []Option, history []int64) []Option
+}
+
+// New returns the strategy with the given name. An empty name returns the weighted strategy.
+// The noRepeat count is only used by the no-repeat strategy. The elimination strategy returned starts
+// a last one standing round with nothing drawn; use NewElimination to continue a round.
+func New(name string, noRepeat int) (Strategy, error) {
+	switch name {
+	case "", StrategyWeighted:
+		return Weighted{}, nil
+	case StrategyUniform:
+		return Uniform{}, nil
+	case StrategyShuffleBag:
+		return ShuffleBag{}, nil
+	case StrategyNoRepeat:
+		return NoRepeat{Last: max(noRepeat, 1)}, nil
+	case StrategyElimination:
+		return Elimination{LastStanding: true}, nil
+	default:
+		return nil, ErrUnknownStrategy
+	}
+}
+
+// Source is where picks get their randomness from.
+type Source interface {
+	// Int64N returns a random number in [0, n). n is always positive.
+	Int64N(n int64) int64
+}
+
+// globalSource draws from the top-level math/rand/v2 functions.
+type globalSource struct{}
+
+func (globalSource) Int64N(n int64) int64 {
+	//nolint:gosec
+	return rand.Int64N(n)
+}
+
+// Random is the source picks use unless a spin needs to be reproducible.
+var Random Source = globalSource{}
+
+// Select picks an option using the strategy. Returns false if there is nothing to pick from.
+func Select(src Source, strategy Strategy, options []Option, history []int64) (Option, bool) {
+	return Pick(src, strategy.Eligible(options, history))
+}
+
+// Pick performs a weighted random pick. Returns false if there is nothing to pick from.
+// The source is asked for one number below the total weight, and the option whose share of the total covers it is picked.
+func Pick(src Source, options []Option) (Option, bool) {
+	if len(options) == 0 {
+		return Option{}, false
+	}
+
+	total := TotalWeight(options)
+	if total <= 0 {
+		return options[0], true
+	}
+
+	r := src.Int64N(total)
+	var current int64
+	for _, opt := range options {
+		current += opt.Weight
+		if r < current {
+			return opt, true
+		}
+	}
+
+	return options[0], true
+}
+
+// TotalWeight sums the weights of the options.
+func TotalWeight(options []Option) int64 {
+	var total int64
+	for _, opt := range options {
+		total += opt.Weight
+	}
+	return total
+}
+
+// Weighted picks options in proportion to their weight.
+type Weighted struct{}
+
+// Name returns the name of the strategy.
+func (Weighted) Name() string {
+	return StrategyWeighted
+}
+
+// Eligible returns every option with its own weight.
+func (Weighted) Eligible(options []Option, _ []int64) []Option {
+	return append([]Option(nil), options...)
+}
+
+// Uniform gives every option the same chance, ignoring weights.
+type Uniform struct{}
+
+// Name returns the name of the strategy.
+func (Uniform) Name() string {
+	return StrategyUniform
+}
+
+// Eligible returns every option with a weight of 1.
+func (Uniform) Eligible(options []Option, _ []int64) []Option {
+	eligible := make([]Option, len(options))
+	for i, opt := range options {
+		eligible[i] = Option{ID: opt.ID, Weight: 1}
+	}
+	return eligible
+}
+
+// ShuffleBag only repeats an option once every option has come up.
+// Options still in the bag are picked by weight.
+type ShuffleBag struct{}
+
+// Name returns the name of the strategy.
+func (ShuffleBag) Name() string {
+	return StrategyShuffleBag
+}
+
+// Eligible returns the options that have not been drawn since the bag was last refilled.
+func (ShuffleBag) Eligible(options []Option, history []int64) []Option {
+	ids := make(map[int64]bool, len(options))
+	for _, opt := range options {
+		ids[opt.ID] = true
+	}
+
+	// Replay the history oldest first, refilling the bag every time it runs empty
+	drawn := make(map[int64]bool, len(options))
+	for i := len(history) - 1; i >= 0; i-- {
+		id := history[i]
+		if !ids[id] {
+			continue
+		}
+		drawn[id] = true
+		if len(drawn) == len(options) {
+			clear(drawn)
+		}
+	}
+
+	eligible := make([]Option, 0, len(options)-len(drawn))
+	for _, opt := range options {
+		if !drawn[opt.ID] {
+			eligible = append(eligible, opt)
+		}
+	}
+	return eligible
+}
+
+// NoRepeat excludes the options picked on the last N spins.
+type NoRepeat struct {
+	Last int
+}
+
+// Name returns the name of the strategy.
+func (NoRepeat) Name() string {
+	return StrategyNoRepeat
+}
+
+// Eligible returns the options not picked recently. At least one option is always left eligible,
+// so when N covers every option the oldest picks become eligible again.
+func (s NoRepeat) Eligible(options []Option, history []int64) []Option {
+	ids := make(map[int64]bool, len(options))
+	for _, opt := range options {
+		ids[opt.ID] = true
+	}
+
+	limit := min(s.Last, len(options)-1)
+	excluded := make(map[int64]bool, max(limit, 0))
+	for _, id := range history {
+		if len(excluded) >= limit {
+			break
+		}
+		if ids[id] {
+			excluded[id] = true
+		}
+	}
+
+	eligible := make([]Option, 0, len(options)-len(excluded))
+	for _, opt := range options {
+		if !excluded[opt.ID] {
+			eligible = append(eligible, opt)
+		}
+	}
+	return eligible
+}
+
+// Elimination draws from the options that have not been drawn yet in an elimination round.
+//
+// In the last one standing variant the options drawn are knocked out, so they are drawn by the inverse
+// of their weight and heavier options are more likely to survive. In the first pick variant options are
+// drawn by weight, as the first one drawn wins.
+type Elimination struct {
+	// Drawn holds the IDs of the options drawn so far in the round.
+	Drawn        []int64
+	LastStanding bool
+}
+
+// NewElimination returns the elimination strategy for the variant with the options drawn so far.
+// An empty variant is last one standing.
+func NewElimination(variant string, drawn []int64) (Elimination, error) {
+	switch variant {
+	case "", EliminationLastStanding:
+		return Elimination{Drawn: drawn, LastStanding: true}, nil
+	case EliminationFirstPick:
+		return Elimination{Drawn: drawn}, nil
+	default:
+		return Elimination{}, ErrUnknownEliminationVariant
+	}
+}
+
+// Name returns the name of the strategy.
+func (Elimination) Name() string {
+	return StrategyElimination
+}
+
+// Variant returns the name of the elimination variant.
+func (s Elimination) Variant() string {
+	if s.LastStanding {
+		return EliminationLastStanding
+	}
+	return EliminationFirstPick
+}
+
+// Eligible returns the options not drawn yet in the round. The spin history is not used.
+func (s Elimination) Eligible(options []Option, _ []int64) []Option {
+	eligible := make([]Option, 0, len(options))
+	for _, opt := range options {
+		if slices.Contains(s.Drawn, opt.ID) {
+			continue
+		}
+		if s.LastStanding {
+			opt.Weight = max(inverseWeightScale/max(opt.Weight, 1), 1)
+		}
+		eligible = append(eligible, opt)
+	}
+	return eligible
+}
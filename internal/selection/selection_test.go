@@ -0,0 +1,63 @@
+package selection_test
+
+import (
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedSource always draws the same number, remembering the bound it was last asked for
+type fixedSource struct {
+	value int64
+	bound int64
+}
+
+func (s *fixedSource) Int64N(n int64) int64 {
+	s.bound = n
+	return s.value
+}
+
+func TestSelect(t *testing.T) {
+	// Weights 1, 3 and 2 give A the number 0, B the numbers 1 to 3 and C the numbers 4 and 5
+	options := []selection.Option{{ID: 1, Weight: 1}, {ID: 2, Weight: 3}, {ID: 3, Weight: 2}}
+
+	tests := []struct {
+		name     string
+		strategy selection.Strategy
+		history  []int64
+		value    int64
+		bound    int64
+		expected int64
+	}{
+		{name: "weighted lowest number", strategy: selection.Weighted{}, value: 0, bound: 6, expected: 1},
+		{name: "weighted start of a share", strategy: selection.Weighted{}, value: 1, bound: 6, expected: 2},
+		{name: "weighted end of a share", strategy: selection.Weighted{}, value: 3, bound: 6, expected: 2},
+		{name: "weighted highest number", strategy: selection.Weighted{}, value: 5, bound: 6, expected: 3},
+		{name: "uniform ignores weights", strategy: selection.Uniform{}, value: 1, bound: 3, expected: 2},
+		{name: "uniform last option", strategy: selection.Uniform{}, value: 2, bound: 3, expected: 3},
+		{name: "shuffle bag skips drawn options", strategy: selection.ShuffleBag{}, history: []int64{2}, value: 0, bound: 3, expected: 1},
+		{name: "shuffle bag refills when empty", strategy: selection.ShuffleBag{}, history: []int64{3, 2, 1}, value: 5, bound: 6, expected: 3},
+		{name: "no repeat skips the last pick", strategy: selection.NoRepeat{Last: 1}, history: []int64{2, 1}, value: 1, bound: 3, expected: 3},
+		{name: "no repeat skips the last two picks", strategy: selection.NoRepeat{Last: 2}, history: []int64{2, 1}, value: 0, bound: 2, expected: 3},
+		// Inverted, the weights become 720720, 240240 and 360360
+		{name: "last standing inverts weights", strategy: selection.Elimination{LastStanding: true}, value: 720719, bound: 1321320, expected: 1},
+		{name: "last standing heavy share", strategy: selection.Elimination{LastStanding: true}, value: 720720, bound: 1321320, expected: 2},
+		{name: "last standing skips drawn options", strategy: selection.Elimination{LastStanding: true, Drawn: []int64{1}}, value: 240240, bound: 600600, expected: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := &fixedSource{value: test.value}
+			picked, ok := selection.Select(src, test.strategy, options, test.history)
+			require.True(t, ok)
+			require.Equal(t, test.expected, picked.ID)
+			require.Equal(t, test.bound, src.bound)
+		})
+	}
+}
+
+func TestPickNothingToPickFrom(t *testing.T) {
+	_, ok := selection.Pick(&fixedSource{}, nil)
+	require.False(t, ok)
+}
@@ -0,0 +1,93 @@
+package selection
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/Piszmog/make-a-decision/internal/tagexpr"
+)
+
+// Tag filter modes as submitted by the spin form.
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+// ErrUnknownTagMode is returned when a tag filter mode is not recognized.
+var ErrUnknownTagMode = errors.New("unknown tag filter mode")
+
+// TagFilter limits the options that can come up on a spin by their tags.
+//
+// An untagged option passes only if IncludeUntagged is set. A tagged option is dropped if it has any of the
+// Exclude tags. Otherwise it passes if Include is empty, or if it has any of the Include tags, or all of them when All is set.
+// The local storage path in local-storage.js applies the same rules.
+//
+// On top of that an option must match the Expression, if there is one. Expressions are only evaluated on the server.
+type TagFilter struct {
+	Include         []string
+	Exclude         []string
+	All             bool
+	IncludeUntagged bool
+	Expression      tagexpr.Expr
+	// ExpressionSource is the Expression as it was written, kept to show it back
+	ExpressionSource string
+}
+
+// NewTagFilter returns the filter for the mode. An empty mode matches any of the included tags.
+func NewTagFilter(mode string, include, exclude []string, includeUntagged bool) (TagFilter, error) {
+	filter := TagFilter{Include: include, Exclude: exclude, IncludeUntagged: includeUntagged}
+	switch mode {
+	case "", TagModeAny:
+	case TagModeAll:
+		filter.All = true
+	default:
+		return TagFilter{}, ErrUnknownTagMode
+	}
+	return filter, nil
+}
+
+// Mode returns the name of the mode the filter matches included tags with.
+func (f TagFilter) Mode() string {
+	if f.All {
+		return TagModeAll
+	}
+	return TagModeAny
+}
+
+// Active reports whether the filter can drop any option.
+func (f TagFilter) Active() bool {
+	return len(f.Include) > 0 || len(f.Exclude) > 0 || !f.IncludeUntagged || f.Expression != nil
+}
+
+// MinMatches returns how many of the included tags a tagged option must have to pass.
+func (f TagFilter) MinMatches() int64 {
+	switch {
+	case len(f.Include) == 0:
+		return 0
+	case f.All:
+		return int64(len(f.Include))
+	default:
+		return 1
+	}
+}
+
+// Matches reports whether an option with the tags passes the filter.
+func (f TagFilter) Matches(tags []string) bool {
+	if f.Expression != nil && !f.Expression.Eval(tags) {
+		return false
+	}
+	if len(tags) == 0 {
+		return f.IncludeUntagged
+	}
+
+	var matched int64
+	for _, tag := range tags {
+		if slices.Contains(f.Exclude, tag) {
+			return false
+		}
+		if slices.Contains(f.Include, tag) {
+			matched++
+		}
+	}
+	return matched >= f.MinMatches()
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/dist"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+// Error codes returned in JSON error bodies
+const (
+	apiErrBadRequest       = "bad_request"
+	apiErrValidation       = "validation_failed"
+	apiErrUnauthorized     = "unauthorized"
+	apiErrNotFound         = "not_found"
+	apiErrConflict         = "conflict"
+	apiErrNoOptions        = "no_eligible_options"
+	apiErrInternal         = "internal_error"
+	maxAPIRequestBodyBytes = 1 << 20
+)
+
+var (
+	// errAPIWheelNotFound is returned when a requested wheel does not belong to the user
+	errAPIWheelNotFound = errors.New("wheel not found")
+	// errEmptyBody is returned when a request that needs a JSON body has none
+	errEmptyBody = errors.New("request body is empty")
+)
+
+// APIError is the body of every JSON error response
+type APIError struct {
+	Error APIErrorDetail `json:"error"`
+}
+
+// APIErrorDetail describes what went wrong with a request
+type APIErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// APIWheel is the JSON representation of a wheel
+type APIWheel struct {
+	ID                   int64     `json:"id"`
+	Name                 string    `json:"name"`
+	Active               bool      `json:"active"`
+	DecayHalfLifeMinutes *int64    `json:"decay_half_life_minutes"`
+	VetoLimit            *int64    `json:"veto_limit"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// writeJSON writes v as the JSON response body
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.Logger.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// writeJSONError writes a JSON error body
+func (h *Handler) writeJSONError(w http.ResponseWriter, status int, code string, message string) {
+	h.writeJSON(w, status, APIError{Error: APIErrorDetail{Code: code, Message: message}})
+}
+
+// decodeJSON reads the request body into v, rejecting unknown fields
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errEmptyBody
+		}
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// requireAPIAuth checks for an authenticated user, sending a JSON error if there is none
+func (h *Handler) requireAPIAuth(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := utils.GetUserID(r)
+	if !ok {
+		h.writeJSONError(w, http.StatusUnauthorized, apiErrUnauthorized, "Authentication required")
+		return 0, false
+	}
+	return userID, true
+}
+
+// apiPathID parses the {id} path value
+func (h *Handler) apiPathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := stringToInt64(r.PathValue("id"))
+	if err != nil {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, "Invalid ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// apiWheel returns the wheel a request applies to. A wheel ID of 0 means the active wheel.
+func (h *Handler) apiWheel(ctx context.Context, userID, wheelID int64) (queries.Wheel, error) {
+	if wheelID == 0 {
+		return h.activeWheel(ctx, userID)
+	}
+
+	wheel, err := h.Database.Queries().GetWheel(ctx, queries.GetWheelParams{
+		ID:     wheelID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return queries.Wheel{}, errAPIWheelNotFound
+	}
+	if err != nil {
+		return queries.Wheel{}, fmt.Errorf("failed to get wheel: %w", err)
+	}
+	return wheel, nil
+}
+
+// apiWheelFromQuery resolves the wheel from the optional wheel_id query parameter,
+// sending a JSON error if it cannot be found
+func (h *Handler) apiWheelFromQuery(w http.ResponseWriter, r *http.Request, userID int64) (queries.Wheel, bool) {
+	var wheelID int64
+	if value := r.URL.Query().Get("wheel_id"); value != "" {
+		id, err := stringToInt64(value)
+		if err != nil {
+			h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, "Invalid wheel_id")
+			return queries.Wheel{}, false
+		}
+		wheelID = id
+	}
+	return h.resolveAPIWheel(w, r, userID, wheelID)
+}
+
+// resolveAPIWheel resolves the wheel by ID, sending a JSON error if it cannot be found
+func (h *Handler) resolveAPIWheel(w http.ResponseWriter, r *http.Request, userID, wheelID int64) (queries.Wheel, bool) {
+	wheel, err := h.apiWheel(r.Context(), userID, wheelID)
+	if errors.Is(err, errAPIWheelNotFound) {
+		h.writeJSONError(w, http.StatusNotFound, apiErrNotFound, "Wheel not found")
+		return queries.Wheel{}, false
+	}
+	if err != nil {
+		h.Logger.Error("Failed to get wheel", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get wheel")
+		return queries.Wheel{}, false
+	}
+	return wheel, true
+}
+
+// APIListWheels handles listing the user's wheels
+func (h *Handler) APIListWheels(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	active, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get wheels")
+		return
+	}
+
+	wheels, err := h.Database.Queries().GetWheels(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get wheels", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get wheels")
+		return
+	}
+
+	apiWheels := make([]APIWheel, len(wheels))
+	for i, wheel := range wheels {
+		apiWheels[i] = APIWheel{
+			ID:        wheel.ID,
+			Name:      wheel.Name,
+			Active:    wheel.ID == active.ID,
+			CreatedAt: wheel.CreatedAt,
+		}
+		if wheel.DecayHalfLifeMinutes.Valid {
+			apiWheels[i].DecayHalfLifeMinutes = &wheel.DecayHalfLifeMinutes.Int64
+		}
+		if wheel.VetoLimit.Valid {
+			apiWheels[i].VetoLimit = &wheel.VetoLimit.Int64
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, apiWheels)
+}
+
+// APINotFound handles requests to unknown API routes
+func (h *Handler) APINotFound(w http.ResponseWriter, r *http.Request) {
+	h.writeJSONError(w, http.StatusNotFound, apiErrNotFound, "No route for "+r.Method+" "+r.URL.Path)
+}
+
+// OpenAPI handles serving the OpenAPI document describing the JSON API
+func (h *Handler) OpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(dist.OpenAPI)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(dist.OpenAPI); err != nil {
+		h.Logger.Error("Failed to write OpenAPI document", "error", err)
+	}
+}
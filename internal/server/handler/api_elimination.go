@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+)
+
+// APIRoundPick is an option drawn in an elimination round. OptionID is null once the option has been deleted.
+type APIRoundPick struct {
+	OptionID *int64 `json:"option_id"`
+	Name     string `json:"name"`
+}
+
+// APIRound is the JSON representation of an elimination round. Drawn lists the options drawn so far in the order
+// they came up, which for a last one standing round are the options knocked out.
+type APIRound struct {
+	ID         int64          `json:"id"`
+	WheelID    int64          `json:"wheel_id"`
+	Variant    string         `json:"variant"`
+	Drawn      []APIRoundPick `json:"drawn"`
+	Winner     *APIRoundPick  `json:"winner"`
+	Finished   bool           `json:"finished"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at"`
+}
+
+// toAPIRound converts the round to the JSON representation
+func (s roundState) toAPIRound() APIRound {
+	round := APIRound{
+		ID:         s.Round.ID,
+		WheelID:    s.Round.WheelID,
+		Variant:    s.Round.Variant,
+		Drawn:      []APIRoundPick{},
+		Finished:   s.Round.FinishedAt.Valid,
+		StartedAt:  s.Round.CreatedAt,
+		FinishedAt: nullTimePtr(s.Round.FinishedAt),
+	}
+	if s.Round.WinnerName.Valid {
+		round.Winner = &APIRoundPick{OptionID: nullInt64Ptr(s.Round.WinnerOptionID), Name: s.Round.WinnerName.String}
+	}
+	for _, pick := range s.drawn() {
+		round.Drawn = append(round.Drawn, APIRoundPick{OptionID: nullInt64Ptr(pick.OptionID), Name: pick.OptionName})
+	}
+	return round
+}
+
+// APIGetEliminationRound handles getting the elimination round on a wheel
+func (h *Handler) APIGetEliminationRound(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	wheel, ok := h.apiWheelFromQuery(w, r, userID)
+	if !ok {
+		return
+	}
+
+	state, err := h.currentRound(r.Context(), userID, wheel.ID)
+	if err != nil {
+		h.Logger.Error("Failed to get elimination round", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get elimination round")
+		return
+	}
+	if state == nil {
+		h.writeJSONError(w, http.StatusNotFound, apiErrNotFound, "No elimination round on this wheel")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, state.toAPIRound())
+}
+
+// APIResetEliminationRound handles throwing away the elimination round on a wheel so the next elimination spin starts a new one
+func (h *Handler) APIResetEliminationRound(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	wheel, ok := h.apiWheelFromQuery(w, r, userID)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	err := h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		return deleteRound(ctx, q, userID, wheel.ID)
+	})
+	if err != nil {
+		h.Logger.Error("Failed to delete elimination round", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to reset elimination round")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/fair"
+)
+
+// APICommitment is the hash of the server seed the next provably fair spin will use. The hash is nil until the
+// user has a server seed.
+type APICommitment struct {
+	ServerSeedHash *string `json:"server_seed_hash"`
+}
+
+// APIProof is what a provably fair spin reveals so it can be replayed. Options are the options the spin drew from,
+// in order, with the weights it picked by.
+type APIProof struct {
+	ID             int64         `json:"id"`
+	WheelID        int64         `json:"wheel_id"`
+	ServerSeed     string        `json:"server_seed"`
+	ServerSeedHash string        `json:"server_seed_hash"`
+	ClientSeed     string        `json:"client_seed"`
+	Options        []fair.Option `json:"options"`
+	PickCount      int64         `json:"pick_count"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// dbProofToAPIProof converts SQLC queries.SpinProof to the JSON representation
+func (h *Handler) dbProofToAPIProof(proof queries.SpinProof) APIProof {
+	options := []fair.Option{}
+	if err := json.Unmarshal([]byte(proof.Options), &options); err != nil {
+		h.Logger.Warn("Failed to decode spin proof options", "proof_id", proof.ID, "error", err)
+	}
+	return APIProof{
+		ID:             proof.ID,
+		WheelID:        proof.WheelID,
+		ServerSeed:     proof.ServerSeed,
+		ServerSeedHash: proof.ServerSeedHash,
+		ClientSeed:     proof.ClientSeed,
+		Options:        options,
+		PickCount:      proof.PickCount,
+		CreatedAt:      proof.CreatedAt,
+	}
+}
+
+// APIGetCommitment handles getting the hash of the server seed the next provably fair spin will use
+func (h *Handler) APIGetCommitment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	commitment, err := h.seedCommitment(r.Context(), userID)
+	if err != nil {
+		h.Logger.Error("Failed to get server seed commitment", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get server seed")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, APICommitment{ServerSeedHash: nonEmptyStringPtr(commitment)})
+}
+
+// APICreateCommitment handles making the server seed the next provably fair spin will use, if there is none yet,
+// and getting its hash
+func (h *Handler) APICreateCommitment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	commitment, err := h.createSeedCommitment(r.Context(), userID)
+	if err != nil {
+		h.Logger.Error("Failed to create server seed commitment", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to create server seed")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, APICommitment{ServerSeedHash: &commitment})
+}
+
+// APIGetProof handles getting the proof of a provably fair spin
+func (h *Handler) APIGetProof(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := h.apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	proof, err := h.Database.Queries().GetSpinProof(r.Context(), queries.GetSpinProofParams{ID: id, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		h.writeJSONError(w, http.StatusNotFound, apiErrNotFound, "Proof not found")
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Failed to get spin proof", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get proof")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.dbProofToAPIProof(proof))
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Piszmog/make-a-decision/internal/components/odds"
+)
+
+// APISimulationInput is the body for simulating spins. The filters are the same as a spin's and every field is optional.
+type APISimulationInput struct {
+	WheelID               int64    `json:"wheel_id,omitempty"`
+	Strategy              string   `json:"strategy"`
+	NoRepeat              int      `json:"no_repeat"`
+	TimeConstraintMinutes *int64   `json:"time_constraint_minutes"`
+	Tags                  []string `json:"tags"`
+	TagMode               string   `json:"tag_mode"`
+	ExcludeTags           []string `json:"exclude_tags"`
+	IncludeUntagged       *bool    `json:"include_untagged"`
+	TagExpression         string   `json:"tag_expression"`
+	At                    string   `json:"at"`
+	Spins                 int      `json:"spins"`
+}
+
+// APIOdds is how often an option came up against the chance it had of coming up
+type APIOdds struct {
+	OptionID    int64   `json:"option_id"`
+	Name        string  `json:"name"`
+	Probability float64 `json:"probability"`
+	Observed    int64   `json:"observed"`
+}
+
+// APIOddsReport compares how often options came up with the chances they had using a chi-square goodness of fit test
+type APIOddsReport struct {
+	Total            int64     `json:"total"`
+	Options          []APIOdds `json:"options"`
+	ChiSquare        float64   `json:"chi_square"`
+	DegreesOfFreedom int       `json:"degrees_of_freedom"`
+	PValue           float64   `json:"p_value"`
+}
+
+// APISimulation is the outcome of simulating spins along with how the wheel's spin history compares with its weights
+type APISimulation struct {
+	WheelID    int64         `json:"wheel_id"`
+	Strategy   string        `json:"strategy"`
+	Spins      int           `json:"spins"`
+	Simulation APIOddsReport `json:"simulation"`
+	History    APIOddsReport `json:"history"`
+}
+
+// toAPIReport converts the report to the JSON representation
+func (r oddsReport) toAPIReport() APIOddsReport {
+	options := make([]APIOdds, len(r.Rows))
+	for i, row := range r.Rows {
+		options[i] = APIOdds{OptionID: row.ID, Name: row.Name, Probability: row.Probability, Observed: row.Observed}
+	}
+	return APIOddsReport{
+		Total:            r.Total,
+		Options:          options,
+		ChiSquare:        r.Statistic,
+		DegreesOfFreedom: r.DegreesOfFreedom,
+		PValue:           r.PValue,
+	}
+}
+
+// APISimulateSpins handles simulating spins with a spin's filters without recording them
+func (h *Handler) APISimulateSpins(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var body APISimulationInput
+	if err := decodeJSON(w, r, &body); err != nil && !errors.Is(err, errEmptyBody) {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+	if body.Spins < 0 || body.Spins > odds.MaxSpins {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, fmt.Sprintf("spins must be between 1 and %d", odds.MaxSpins))
+		return
+	}
+	spins := body.Spins
+	if spins == 0 {
+		spins = defaultSimulationSpins
+	}
+
+	filters, ok := h.apiSpinFilters(w, r, userID, APISpinInput{
+		Strategy:              body.Strategy,
+		NoRepeat:              body.NoRepeat,
+		TimeConstraintMinutes: body.TimeConstraintMinutes,
+		Tags:                  body.Tags,
+		TagMode:               body.TagMode,
+		ExcludeTags:           body.ExcludeTags,
+		IncludeUntagged:       body.IncludeUntagged,
+		TagExpression:         body.TagExpression,
+		At:                    body.At,
+	})
+	if !ok {
+		return
+	}
+
+	wheel, ok := h.resolveAPIWheel(w, r, userID, body.WheelID)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	simulation, err := h.simulateSpins(ctx, userID, wheel.ID, filters, spins)
+	if errors.Is(err, errNoOptionsMatch) {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrNoOptions, "No options match the filters")
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Failed to simulate spins", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to simulate spins")
+		return
+	}
+
+	history, err := h.spinHistoryOdds(ctx, userID, wheel.ID)
+	if err != nil {
+		h.Logger.Error("Failed to compare spin history", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to simulate spins")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, APISimulation{
+		WheelID:    wheel.ID,
+		Strategy:   filters.Strategy.Name(),
+		Spins:      spins,
+		Simulation: simulation.toAPIReport(),
+		History:    history.toAPIReport(),
+	})
+}
@@ -0,0 +1,386 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/availability"
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/markdown"
+	"github.com/Piszmog/make-a-decision/internal/selection"
+)
+
+var (
+	errOptionNameRequired = errors.New("name is required")
+	errOptionDuration     = errors.New("duration_minutes must be between 0 and 1440")
+	errOptionWeight       = fmt.Errorf("weight must be between 0 and %d", selection.MaxWeight)
+	errOptionCooldown     = fmt.Errorf("cooldown_minutes must be between 0 and %d", maxCooldownMinutes)
+	errOptionTooManyTags  = errors.New("an option can have at most 5 tags")
+	errOptionWheelChange  = errors.New("wheel_id cannot be changed")
+)
+
+// maxCooldownMinutes is the longest cooldown an option can have, one year
+const maxCooldownMinutes = 365 * 24 * 60
+
+// APIOption is the JSON representation of an option
+type APIOption struct {
+	ID              int64              `json:"id"`
+	WheelID         int64              `json:"wheel_id"`
+	Name            string             `json:"name"`
+	Description     *string            `json:"description"`
+	DurationMinutes *int64             `json:"duration_minutes"`
+	Weight          int64              `json:"weight"`
+	CooldownMinutes *int64             `json:"cooldown_minutes"`
+	Availability    availability.Rules `json:"availability"`
+	Tags            []string           `json:"tags"`
+	Links           []string           `json:"links"`
+	ImageURL        *string            `json:"image_url"`
+	ThumbnailURL    *string            `json:"thumbnail_url"`
+	CreatedAt       time.Time          `json:"created_at"`
+}
+
+// APIOptionInput is the body for creating or replacing an option
+type APIOptionInput struct {
+	WheelID         int64           `json:"wheel_id,omitempty"`
+	Name            string          `json:"name"`
+	Description     *string         `json:"description"`
+	DurationMinutes *int64          `json:"duration_minutes"`
+	Weight          *int64          `json:"weight"`
+	CooldownMinutes *int64          `json:"cooldown_minutes"`
+	Availability    json.RawMessage `json:"availability"`
+	Tags            []string        `json:"tags"`
+	Links           []string        `json:"links"`
+}
+
+// optionInput is a validated option ready to be stored
+type optionInput struct {
+	Name string
+	// Description is the Markdown description, NULL when the option has none
+	Description sql.NullString
+	Duration    any
+	Weight      int64
+	Cooldown    sql.NullInt64
+	// Availability is the encoded availability rules, NULL when the option is available any time
+	Availability sql.NullString
+	Tags         []string
+	// Links is the encoded links, NULL when the option has none
+	Links sql.NullString
+}
+
+// validate checks the input and applies defaults
+func (in APIOptionInput) validate() (optionInput, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return optionInput{}, errOptionNameRequired
+	}
+
+	var description sql.NullString
+	if in.Description != nil {
+		normalized, err := markdown.Normalize(*in.Description)
+		if err != nil {
+			return optionInput{}, err
+		}
+		description = sql.NullString{String: normalized, Valid: normalized != ""}
+	}
+
+	var duration any
+	if in.DurationMinutes != nil {
+		if *in.DurationMinutes < 0 || *in.DurationMinutes > 1440 {
+			return optionInput{}, errOptionDuration
+		}
+		duration = *in.DurationMinutes
+	}
+
+	weight := int64(1)
+	if in.Weight != nil {
+		if *in.Weight < 0 || *in.Weight > selection.MaxWeight {
+			return optionInput{}, errOptionWeight
+		}
+		weight = *in.Weight
+	}
+
+	// A cooldown of zero is the same as no cooldown
+	var cooldown sql.NullInt64
+	if in.CooldownMinutes != nil {
+		if *in.CooldownMinutes < 0 || *in.CooldownMinutes > maxCooldownMinutes {
+			return optionInput{}, errOptionCooldown
+		}
+		cooldown = sql.NullInt64{Int64: *in.CooldownMinutes, Valid: *in.CooldownMinutes > 0}
+	}
+
+	var rules availability.Rules
+	if len(in.Availability) > 0 {
+		var err error
+		if rules, err = availability.Parse(string(in.Availability)); err != nil {
+			return optionInput{}, fmt.Errorf("invalid availability: %w", err)
+		}
+	}
+	storedAvailability, err := encodeAvailability(rules)
+	if err != nil {
+		return optionInput{}, err
+	}
+
+	// Tags that only differ by case or spacing are the same tag, so they are merged before counting
+	tags := cleanTags(in.Tags)
+	if len(tags) > maxOptionTags {
+		return optionInput{}, errOptionTooManyTags
+	}
+
+	links, err := normalizeLinks(in.Links)
+	if err != nil {
+		return optionInput{}, err
+	}
+	storedLinks, err := encodeLinks(links)
+	if err != nil {
+		return optionInput{}, err
+	}
+
+	return optionInput{
+		Name:         name,
+		Description:  description,
+		Duration:     duration,
+		Weight:       weight,
+		Cooldown:     cooldown,
+		Availability: storedAvailability,
+		Tags:         tags,
+		Links:        storedLinks,
+	}, nil
+}
+
+// dbOptionToAPIOption converts SQLC queries.Option to the JSON representation
+func (h *Handler) dbOptionToAPIOption(ctx context.Context, dbOpt queries.Option, userID int64) APIOption {
+	return optionToAPIOption(dbOpt, h.dbOptionToAppOption(ctx, dbOpt, userID))
+}
+
+// optionToAPIOption converts an option, with its app form holding the tags, to the API representation
+func optionToAPIOption(dbOpt queries.Option, appOpt home.Option) APIOption {
+	rules := appOpt.Availability
+	if rules == nil {
+		rules = availability.Rules{}
+	}
+	return APIOption{
+		ID:              dbOpt.ID,
+		WheelID:         dbOpt.WheelID,
+		Name:            appOpt.Text,
+		Description:     nullStringPtr(dbOpt.Bio),
+		DurationMinutes: appOpt.Duration,
+		Weight:          appOpt.Weight,
+		CooldownMinutes: appOpt.Cooldown,
+		Availability:    rules,
+		Tags:            appOpt.Tags,
+		Links:           appOpt.Links,
+		ImageURL:        nonEmptyStringPtr(appOpt.ImageURL),
+		ThumbnailURL:    nonEmptyStringPtr(appOpt.ThumbnailURL),
+		CreatedAt:       dbOpt.CreatedAt,
+	}
+}
+
+// nonEmptyStringPtr returns a pointer to the string, or nil when it is empty
+func nonEmptyStringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// apiOption fetches the option by path ID, sending a JSON error if it cannot be found
+func (h *Handler) apiOption(w http.ResponseWriter, r *http.Request, userID int64) (queries.Option, bool) {
+	id, ok := h.apiPathID(w, r)
+	if !ok {
+		return queries.Option{}, false
+	}
+
+	dbOpt, err := h.Database.Queries().GetOption(r.Context(), queries.GetOptionParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		h.writeJSONError(w, http.StatusNotFound, apiErrNotFound, "Option not found")
+		return queries.Option{}, false
+	}
+	if err != nil {
+		h.Logger.Error("Failed to get option", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get option")
+		return queries.Option{}, false
+	}
+	return dbOpt, true
+}
+
+// APIListOptions handles listing the options on a wheel
+func (h *Handler) APIListOptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	wheel, ok := h.apiWheelFromQuery(w, r, userID)
+	if !ok {
+		return
+	}
+
+	options, err := h.taggedOptions(r.Context(), wheel.ID, userID, noTagFilter)
+	if err != nil {
+		h.Logger.Error("Failed to get options", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get options")
+		return
+	}
+
+	apiOptions := make([]APIOption, len(options))
+	for i, opt := range options {
+		apiOptions[i] = optionToAPIOption(opt.Option, optionToAppOption(opt.Option, opt.Tags))
+	}
+
+	h.writeJSON(w, http.StatusOK, apiOptions)
+}
+
+// APIGetOption handles fetching a single option
+func (h *Handler) APIGetOption(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	dbOpt, ok := h.apiOption(w, r, userID)
+	if !ok {
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.dbOptionToAPIOption(r.Context(), dbOpt, userID))
+}
+
+// APICreateOption handles creating an option
+func (h *Handler) APICreateOption(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var body APIOptionInput
+	if err := decodeJSON(w, r, &body); err != nil {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	input, err := body.validate()
+	if err != nil {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, err.Error())
+		return
+	}
+
+	wheel, ok := h.resolveAPIWheel(w, r, userID, body.WheelID)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	created, err := h.createOptionWithTags(ctx, queries.CreateOptionParams{
+		Name:            input.Name,
+		Bio:             input.Description,
+		DurationMinutes: input.Duration,
+		Weight:          sql.NullInt64{Int64: input.Weight, Valid: true},
+		CooldownMinutes: input.Cooldown,
+		Availability:    input.Availability,
+		Links:           input.Links,
+		UserID:          userID,
+		WheelID:         wheel.ID,
+	}, input.Tags)
+	if err != nil {
+		h.Logger.Error("Failed to create option", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to create option")
+		return
+	}
+
+	h.Logger.Info("Option created", "id", created.ID, "name", input.Name)
+	h.writeJSON(w, http.StatusCreated, h.dbOptionToAPIOption(ctx, created, userID))
+}
+
+// APIUpdateOption handles replacing an option
+func (h *Handler) APIUpdateOption(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	dbOpt, ok := h.apiOption(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var body APIOptionInput
+	if err := decodeJSON(w, r, &body); err != nil {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	input, err := body.validate()
+	if err == nil && body.WheelID != 0 && body.WheelID != dbOpt.WheelID {
+		err = errOptionWheelChange
+	}
+	if err != nil {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	if err := h.updateOptionWithTags(ctx, queries.UpdateOptionParams{
+		Name:            input.Name,
+		Bio:             input.Description,
+		DurationMinutes: input.Duration,
+		Weight:          sql.NullInt64{Int64: input.Weight, Valid: true},
+		CooldownMinutes: input.Cooldown,
+		Availability:    input.Availability,
+		Links:           input.Links,
+		ID:              dbOpt.ID,
+		UserID:          userID,
+	}, dbOpt.WheelID, input.Tags); err != nil {
+		h.Logger.Error("Failed to update option", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to update option")
+		return
+	}
+
+	updated, err := h.Database.Queries().GetOption(ctx, queries.GetOptionParams{
+		ID:     dbOpt.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to get option", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get option")
+		return
+	}
+
+	h.Logger.Info("Option updated", "id", dbOpt.ID, "name", input.Name)
+	h.writeJSON(w, http.StatusOK, h.dbOptionToAPIOption(ctx, updated, userID))
+}
+
+// APIDeleteOption handles deleting an option
+func (h *Handler) APIDeleteOption(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	dbOpt, ok := h.apiOption(w, r, userID)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if err := h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		return deleteOption(ctx, q, dbOpt.ID, userID)
+	}); err != nil {
+		h.Logger.Error("Failed to delete option", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to delete option")
+		return
+	}
+	h.releaseImages(ctx, dbOpt.ImageKey)
+
+	h.Logger.Info("Option deleted", "id", dbOpt.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,552 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/fair"
+	"github.com/Piszmog/make-a-decision/internal/selection"
+)
+
+// maxSpinsPerPage is the largest page of spins the API returns
+const maxSpinsPerPage = 100
+
+// APISpinInput is the body for spinning a wheel. Every field is optional.
+type APISpinInput struct {
+	WheelID               int64    `json:"wheel_id,omitempty"`
+	Strategy              string   `json:"strategy"`
+	NoRepeat              int      `json:"no_repeat"`
+	TimeConstraintMinutes *int64   `json:"time_constraint_minutes"`
+	Tags                  []string `json:"tags"`
+	TagMode               string   `json:"tag_mode"`
+	ExcludeTags           []string `json:"exclude_tags"`
+	IncludeUntagged       *bool    `json:"include_untagged"`
+	TagExpression         string   `json:"tag_expression"`
+	At                    string   `json:"at"`
+	Count                 int      `json:"count"`
+	EliminationVariant    string   `json:"elimination_variant"`
+	Fair                  bool     `json:"fair"`
+	ClientSeed            string   `json:"client_seed"`
+	VetoSpinID            int64    `json:"veto_spin_id,omitempty"`
+}
+
+// APIChance is the chance an eligible option had on a spin
+type APIChance struct {
+	OptionID    int64   `json:"option_id"`
+	Name        string  `json:"name"`
+	Probability float64 `json:"probability"`
+}
+
+// APIPick is an option picked by a spin
+type APIPick struct {
+	SpinID      int64     `json:"spin_id"`
+	Position    int64     `json:"position"`
+	Option      APIOption `json:"option"`
+	Probability float64   `json:"probability"`
+}
+
+// APISpinResult is the outcome of a spin. SpinID, Option and Probability describe the first pick.
+// A provably fair spin reveals its proof along with the commitment for the next provably fair spin.
+// VetoesLeft is how many more times the pick can be vetoed, or nil when there is no limit.
+type APISpinResult struct {
+	SpinID             int64         `json:"spin_id"`
+	WheelID            int64         `json:"wheel_id"`
+	Strategy           string        `json:"strategy"`
+	Option             APIOption     `json:"option"`
+	Probability        float64       `json:"probability"`
+	Count              int           `json:"count"`
+	Picks              []APIPick     `json:"picks"`
+	Eligible           []APIChance   `json:"eligible"`
+	CoolingDown        []APICooldown `json:"cooling_down"`
+	TargetAt           *time.Time    `json:"target_at"`
+	Round              *APIRound     `json:"round,omitempty"`
+	Proof              *APIProof     `json:"proof,omitempty"`
+	NextServerSeedHash string        `json:"next_server_seed_hash,omitempty"`
+	VetoesLeft         *int64        `json:"vetoes_left"`
+}
+
+// APICooldown is an option a spin skipped because it was picked within its cooldown
+type APICooldown struct {
+	OptionID    int64     `json:"option_id"`
+	Name        string    `json:"name"`
+	AvailableAt time.Time `json:"available_at"`
+}
+
+// APISpin is the JSON representation of a recorded spin
+type APISpin struct {
+	ID                    int64      `json:"id"`
+	WheelID               int64      `json:"wheel_id"`
+	OptionID              *int64     `json:"option_id"`
+	OptionName            string     `json:"option_name"`
+	Probability           float64    `json:"probability"`
+	Strategy              string     `json:"strategy"`
+	TimeConstraintMinutes *int64     `json:"time_constraint_minutes"`
+	Tags                  []string   `json:"tags"`
+	TagMode               string     `json:"tag_mode"`
+	ExcludeTags           []string   `json:"exclude_tags"`
+	IncludeUntagged       bool       `json:"include_untagged"`
+	TagExpression         string     `json:"tag_expression"`
+	TargetAt              *time.Time `json:"target_at"`
+	PickPosition          int64      `json:"pick_position"`
+	PickCount             int64      `json:"pick_count"`
+	RoundID               *int64     `json:"round_id"`
+	ProofID               *int64     `json:"proof_id"`
+	Outcome               *string    `json:"outcome"`
+	Rating                *int64     `json:"rating"`
+	Note                  *string    `json:"note"`
+	CompletedAt           *time.Time `json:"completed_at"`
+	VetoedSpinID          *int64     `json:"vetoed_spin_id"`
+	VetoNumber            int64      `json:"veto_number"`
+	CreatedAt             time.Time  `json:"created_at"`
+}
+
+// APISpinOutcomeInput is the body for recording whether a decision was accepted or skipped
+type APISpinOutcomeInput struct {
+	Outcome string `json:"outcome"`
+}
+
+// APISpinCompletionInput is the body for marking a decision as done. The note is optional.
+type APISpinCompletionInput struct {
+	Rating int64  `json:"rating"`
+	Note   string `json:"note"`
+}
+
+// APISpinPage is a page of the spin history
+type APISpinPage struct {
+	Spins   []APISpin `json:"spins"`
+	Page    int64     `json:"page"`
+	PerPage int64     `json:"per_page"`
+	Total   int64     `json:"total"`
+}
+
+// nullTimePtr returns the time, or nil if it is NULL
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// nullInt64Ptr returns the integer, or nil if it is NULL
+func nullInt64Ptr(i sql.NullInt64) *int64 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int64
+}
+
+// nullStringPtr returns the string, or nil if it is NULL
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// dbSpinToAPISpin converts SQLC queries.Spin to the JSON representation
+func (h *Handler) dbSpinToAPISpin(dbSpin queries.Spin) APISpin {
+	var optionID *int64
+	if dbSpin.OptionID.Valid {
+		optionID = &dbSpin.OptionID.Int64
+	}
+
+	var constraint *int64
+	if dbSpin.TimeConstraintMinutes.Valid {
+		constraint = &dbSpin.TimeConstraintMinutes.Int64
+	}
+
+	return APISpin{
+		ID:                    dbSpin.ID,
+		WheelID:               dbSpin.WheelID.Int64,
+		OptionID:              optionID,
+		OptionName:            dbSpin.OptionName,
+		Probability:           dbSpin.Probability,
+		Strategy:              dbSpin.Strategy,
+		TimeConstraintMinutes: constraint,
+		Tags:                  h.decodeSpinTags(dbSpin.ID, dbSpin.Tags),
+		TagMode:               dbSpin.TagMode,
+		ExcludeTags:           h.decodeSpinTags(dbSpin.ID, dbSpin.ExcludeTags),
+		IncludeUntagged:       dbSpin.IncludeUntagged != 0,
+		TagExpression:         dbSpin.TagExpression,
+		TargetAt:              nullTimePtr(dbSpin.TargetAt),
+		PickPosition:          dbSpin.PickPosition,
+		PickCount:             dbSpin.PickCount,
+		RoundID:               nullInt64Ptr(dbSpin.RoundID),
+		ProofID:               nullInt64Ptr(dbSpin.ProofID),
+		Outcome:               nullStringPtr(dbSpin.Outcome),
+		Rating:                nullInt64Ptr(dbSpin.Rating),
+		Note:                  nullStringPtr(dbSpin.Note),
+		CompletedAt:           nullTimePtr(dbSpin.CompletedAt),
+		VetoedSpinID:          nullInt64Ptr(dbSpin.VetoedSpinID),
+		VetoNumber:            dbSpin.VetoNumber,
+		CreatedAt:             dbSpin.CreatedAt,
+	}
+}
+
+// apiSpinFilters reads the strategy and filters of a spin. A validation error is written when they are invalid.
+func (h *Handler) apiSpinFilters(w http.ResponseWriter, r *http.Request, userID int64, body APISpinInput) (spinFilters, bool) {
+	strategy, err := selection.New(body.Strategy, body.NoRepeat)
+	if err != nil {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, "Unknown strategy "+strconv.Quote(body.Strategy))
+		return spinFilters{}, false
+	}
+
+	var timeConstraintMinutes *int64
+	if body.TimeConstraintMinutes != nil && *body.TimeConstraintMinutes > 0 {
+		timeConstraintMinutes = body.TimeConstraintMinutes
+	}
+	tagFilter, err := selection.NewTagFilter(body.TagMode, cleanTags(body.Tags), cleanTags(body.ExcludeTags), body.IncludeUntagged == nil || *body.IncludeUntagged)
+	if err != nil {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, "Unknown tag mode "+strconv.Quote(body.TagMode))
+		return spinFilters{}, false
+	}
+	if err := parseTagExpression(&tagFilter, body.TagExpression); err != nil {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, "Invalid tag expression: "+err.Error())
+		return spinFilters{}, false
+	}
+
+	at, target, err := parseSpinTime(body.At, h.userLocation(r.Context(), userID), h.now())
+	if err != nil {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, "Invalid at: "+err.Error())
+		return spinFilters{}, false
+	}
+
+	return spinFilters{
+		Strategy:              strategy,
+		TimeConstraintMinutes: timeConstraintMinutes,
+		TagFilter:             tagFilter,
+		At:                    at,
+		Target:                target,
+	}, true
+}
+
+// APISpinWheel handles spinning a wheel and recording the result
+func (h *Handler) APISpinWheel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var body APISpinInput
+	if err := decodeJSON(w, r, &body); err != nil && !errors.Is(err, errEmptyBody) {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	filters, ok := h.apiSpinFilters(w, r, userID, body)
+	if !ok {
+		return
+	}
+	strategy := filters.Strategy
+
+	if body.Count < 0 || body.Count > selection.MaxPicks {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, fmt.Sprintf("count must be between 1 and %d", selection.MaxPicks))
+		return
+	}
+	count := max(body.Count, 1)
+	if strategy.Name() == selection.StrategyElimination && count > 1 {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, "Elimination spins pick one option at a time")
+		return
+	}
+
+	ctx := r.Context()
+	wheel, ok := h.resolveAPIWheel(w, r, userID, body.WheelID)
+	if !ok {
+		return
+	}
+
+	// Provably fair spins draw with the committed server seed instead of the handler's random source
+	src := h.random()
+	var seeds *fairSeeds
+	if body.Fair {
+		fairSeeds, err := h.fairSpinSeeds(ctx, userID, body.ClientSeed)
+		if errors.Is(err, fair.ErrClientSeedTooLong) {
+			h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, err.Error())
+			return
+		}
+		if err != nil {
+			h.Logger.Error("Failed to get server seed", "error", err)
+			h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get server seed")
+			return
+		}
+		seeds, src = &fairSeeds, fair.NewSource(fairSeeds.Server, fairSeeds.Client)
+	}
+
+	// Elimination spins draw one option from what is left of the round
+	var round *roundState
+	if strategy.Name() == selection.StrategyElimination {
+		state, elimination, err := h.roundForSpin(ctx, userID, wheel.ID, body.EliminationVariant)
+		if errors.Is(err, selection.ErrUnknownEliminationVariant) {
+			h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, "Unknown elimination variant "+strconv.Quote(body.EliminationVariant))
+			return
+		}
+		if err != nil {
+			h.Logger.Error("Failed to get elimination round", "error", err)
+			h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get elimination round")
+			return
+		}
+		round, strategy = &state, elimination
+	}
+
+	// A reroll vetoes the decision it replaces and picks one option, leaving out every option vetoed in the round
+	var vetoed *veto
+	if body.VetoSpinID != 0 {
+		v, err := h.vetoFor(ctx, userID, wheel, body.VetoSpinID)
+		if err == nil && round != nil {
+			err = errVetoElimination
+		}
+		if err == nil && count > 1 {
+			err = errVetoMultiPick
+		}
+		if err != nil {
+			h.writeAPIOutcomeError(w, err, "Failed to reroll")
+			return
+		}
+		vetoed = &v
+	} else if round == nil {
+		// Spinning again without accepting or skipping the last decision rerolls it
+		v, err := h.pendingVeto(ctx, userID, wheel, count)
+		if err != nil {
+			h.writeAPIOutcomeError(w, err, "Failed to select option")
+			return
+		}
+		vetoed = v
+	}
+
+	spin, noOptionsAvailable, err := h.selectRandomOption(ctx, userID, wheel.ID, strategy, src, filters.TimeConstraintMinutes, filters.TagFilter, filters.At, count, vetoed.options())
+	if err != nil {
+		h.Logger.Error("Failed to select random option", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to select option")
+		return
+	}
+	if noOptionsAvailable || len(spin.Picked) == 0 {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrNoOptions, "No options match the filters")
+		return
+	}
+
+	spin.Seeds = seeds
+	var recorded recordedSpin
+	var apiRound *APIRound
+	if round != nil {
+		var state roundState
+		state, recorded, err = h.recordRoundSpin(ctx, userID, wheel.ID, *round, spin, filters.TimeConstraintMinutes, filters.TagFilter, filters.Target)
+		if err == nil {
+			current := state.toAPIRound()
+			apiRound = &current
+		}
+	} else {
+		spin.Veto = vetoed
+		recorded, err = h.recordSpin(ctx, userID, wheel.ID, spin, strategy.Name(), filters.TimeConstraintMinutes, filters.TagFilter, filters.Target)
+	}
+	if isConflict(err) {
+		h.writeAPIOutcomeError(w, err, "Failed to reroll")
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Failed to record spin", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to record spin")
+		return
+	}
+
+	picks := make([]APIPick, len(spin.Picked))
+	for i, selected := range spin.Picked {
+		optionID, _ := stringToInt64(selected.ID)
+		dbOpt, err := h.Database.Queries().GetOption(ctx, queries.GetOptionParams{
+			ID:     optionID,
+			UserID: userID,
+		})
+		if err != nil {
+			h.Logger.Error("Failed to get option", "error", err)
+			h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get option")
+			return
+		}
+		picks[i] = APIPick{
+			SpinID:      recorded.IDs[i],
+			Position:    int64(i + 1),
+			Option:      h.dbOptionToAPIOption(ctx, dbOpt, userID),
+			Probability: spin.probability(optionID),
+		}
+	}
+
+	eligible := make([]APIChance, len(spin.Eligible))
+	for i, opt := range spin.Eligible {
+		eligible[i] = APIChance{
+			OptionID:    opt.ID,
+			Name:        spin.Names[opt.ID],
+			Probability: spin.probability(opt.ID),
+		}
+	}
+
+	coolingDown := make([]APICooldown, len(spin.CoolingDown))
+	for i, opt := range spin.CoolingDown {
+		coolingDown[i] = APICooldown{
+			OptionID:    opt.ID,
+			Name:        opt.Name,
+			AvailableAt: opt.AvailableAt.UTC(),
+		}
+	}
+
+	var proof *APIProof
+	if recorded.Proof != nil {
+		apiProof := h.dbProofToAPIProof(*recorded.Proof)
+		proof = &apiProof
+	}
+
+	// Only a spin that picked one option outside an elimination round can be vetoed
+	noVetoes := int64(0)
+	left := &noVetoes
+	if count == 1 && round == nil {
+		left = vetoesLeft(wheel, vetoed.used())
+	}
+
+	h.writeJSON(w, http.StatusOK, APISpinResult{
+		SpinID:             picks[0].SpinID,
+		WheelID:            wheel.ID,
+		Strategy:           strategy.Name(),
+		Option:             picks[0].Option,
+		Probability:        picks[0].Probability,
+		Count:              count,
+		Picks:              picks,
+		Eligible:           eligible,
+		CoolingDown:        coolingDown,
+		TargetAt:           filters.Target,
+		Round:              apiRound,
+		Proof:              proof,
+		NextServerSeedHash: recorded.NextCommitment,
+		VetoesLeft:         left,
+	})
+}
+
+// APIListSpins handles listing a page of the spin history, newest first
+func (h *Handler) APIListSpins(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	wheel, ok := h.apiWheelFromQuery(w, r, userID)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.ParseInt(r.URL.Query().Get("per_page"), 10, 64)
+	if err != nil || perPage < 1 {
+		perPage = historyPageSize
+	}
+	perPage = min(perPage, maxSpinsPerPage)
+
+	ctx := r.Context()
+	total, err := h.Database.Queries().CountSpins(ctx, queries.CountSpinsParams{
+		WheelID: nullWheelID(wheel.ID),
+		UserID:  userID,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to count spins", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get spins")
+		return
+	}
+
+	spins, err := h.Database.Queries().GetSpins(ctx, queries.GetSpinsParams{
+		WheelID: nullWheelID(wheel.ID),
+		UserID:  userID,
+		Limit:   perPage,
+		Offset:  (page - 1) * perPage,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to get spins", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get spins")
+		return
+	}
+
+	apiSpins := make([]APISpin, len(spins))
+	for i, spin := range spins {
+		apiSpins[i] = h.dbSpinToAPISpin(spin)
+	}
+
+	h.writeJSON(w, http.StatusOK, APISpinPage{
+		Spins:   apiSpins,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	})
+}
+
+// writeAPIOutcomeError sends the error from recording what became of a decision as JSON
+func (h *Handler) writeAPIOutcomeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		h.writeJSONError(w, http.StatusNotFound, apiErrNotFound, "Spin not found")
+	case isInvalidOutcome(err):
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, err.Error())
+	case isConflict(err):
+		h.writeJSONError(w, http.StatusConflict, apiErrConflict, err.Error())
+	default:
+		h.Logger.Error(message, "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, message)
+	}
+}
+
+// APISetSpinOutcome handles recording whether the decision made by a spin was accepted or skipped
+func (h *Handler) APISetSpinOutcome(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	spinID, ok := h.apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	var body APISpinOutcomeInput
+	if err := decodeJSON(w, r, &body); err != nil {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	spin, err := h.setSpinOutcome(r.Context(), userID, spinID, body.Outcome)
+	if err != nil {
+		h.writeAPIOutcomeError(w, err, "Failed to update spin")
+		return
+	}
+
+	h.Logger.Info("Spin outcome recorded", "spin_id", spin.ID, "outcome", spin.Outcome.String)
+	h.writeJSON(w, http.StatusOK, h.dbSpinToAPISpin(spin))
+}
+
+// APICompleteSpin handles marking the decision made by a spin as done with a rating and an optional note
+func (h *Handler) APICompleteSpin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	spinID, ok := h.apiPathID(w, r)
+	if !ok {
+		return
+	}
+
+	var body APISpinCompletionInput
+	if err := decodeJSON(w, r, &body); err != nil {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	spin, err := h.completeSpin(r.Context(), userID, spinID, body.Rating, body.Note)
+	if err != nil {
+		h.writeAPIOutcomeError(w, err, "Failed to update spin")
+		return
+	}
+
+	h.Logger.Info("Spin completed", "spin_id", spin.ID, "rating", spin.Rating.Int64)
+	h.writeJSON(w, http.StatusOK, h.dbSpinToAPISpin(spin))
+}
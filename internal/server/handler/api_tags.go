@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+)
+
+// maxTagNameLength is the longest name a tag can be given
+const maxTagNameLength = 30
+
+var errTagName = errors.New("name must be 1-30 characters")
+
+// APITag is the JSON representation of a tag.
+// Fields mirror the tag query rows so they can be converted directly.
+type APITag struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	WheelID     int64     `json:"wheel_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	OptionCount int64     `json:"option_count"`
+}
+
+// APITagInput is the body for creating or renaming a tag
+type APITagInput struct {
+	WheelID int64  `json:"wheel_id,omitempty"`
+	Name    string `json:"name"`
+}
+
+// validate checks and normalizes the tag name
+func (in APITagInput) validate() (string, error) {
+	name := strings.TrimSpace(strings.ToLower(in.Name))
+	if name == "" || len([]rune(name)) > maxTagNameLength || strings.Contains(name, ",") {
+		return "", errTagName
+	}
+	return name, nil
+}
+
+// apiTag fetches the tag by path ID, sending a JSON error if it cannot be found
+func (h *Handler) apiTag(w http.ResponseWriter, r *http.Request, userID int64) (queries.GetTagRow, bool) {
+	id, ok := h.apiPathID(w, r)
+	if !ok {
+		return queries.GetTagRow{}, false
+	}
+
+	tag, err := h.Database.Queries().GetTag(r.Context(), queries.GetTagParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		h.writeJSONError(w, http.StatusNotFound, apiErrNotFound, "Tag not found")
+		return queries.GetTagRow{}, false
+	}
+	if err != nil {
+		h.Logger.Error("Failed to get tag", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get tag")
+		return queries.GetTagRow{}, false
+	}
+	return tag, true
+}
+
+// tagNameTaken checks whether a tag with the name already exists on the wheel, sending a JSON error if it does
+func (h *Handler) tagNameTaken(w http.ResponseWriter, r *http.Request, name string, wheelID int64) bool {
+	exists, err := h.Database.Queries().TagExists(r.Context(), queries.TagExistsParams{
+		LOWER:   name,
+		WheelID: wheelID,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to check tag", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to check tag")
+		return true
+	}
+	if exists {
+		h.writeJSONError(w, http.StatusConflict, apiErrConflict, "A tag named "+name+" already exists")
+		return true
+	}
+	return false
+}
+
+// APIListTags handles listing the tags on a wheel
+func (h *Handler) APIListTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	wheel, ok := h.apiWheelFromQuery(w, r, userID)
+	if !ok {
+		return
+	}
+
+	tags, err := h.Database.Queries().GetTagsForWheel(r.Context(), queries.GetTagsForWheelParams{
+		WheelID: wheel.ID,
+		UserID:  userID,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to get tags", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to get tags")
+		return
+	}
+
+	apiTags := make([]APITag, len(tags))
+	for i, tag := range tags {
+		apiTags[i] = APITag(tag)
+	}
+
+	h.writeJSON(w, http.StatusOK, apiTags)
+}
+
+// APIGetTag handles fetching a single tag
+func (h *Handler) APIGetTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	tag, ok := h.apiTag(w, r, userID)
+	if !ok {
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, APITag(tag))
+}
+
+// APICreateTag handles creating a tag
+func (h *Handler) APICreateTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var body APITagInput
+	if err := decodeJSON(w, r, &body); err != nil {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	name, err := body.validate()
+	if err != nil {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, err.Error())
+		return
+	}
+
+	wheel, ok := h.resolveAPIWheel(w, r, userID, body.WheelID)
+	if !ok {
+		return
+	}
+
+	if h.tagNameTaken(w, r, name, wheel.ID) {
+		return
+	}
+
+	tag, err := h.Database.Queries().CreateTag(r.Context(), queries.CreateTagParams{
+		LOWER:   name,
+		UserID:  userID,
+		WheelID: wheel.ID,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to create tag", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to create tag")
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, APITag{
+		ID:        tag.ID,
+		WheelID:   tag.WheelID,
+		Name:      tag.Name,
+		CreatedAt: tag.CreatedAt,
+	})
+}
+
+// APIUpdateTag handles renaming a tag
+func (h *Handler) APIUpdateTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	tag, ok := h.apiTag(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var body APITagInput
+	if err := decodeJSON(w, r, &body); err != nil {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	name, err := body.validate()
+	if err == nil && body.WheelID != 0 && body.WheelID != tag.WheelID {
+		err = errOptionWheelChange
+	}
+	if err != nil {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, err.Error())
+		return
+	}
+
+	if name != tag.Name {
+		if h.tagNameTaken(w, r, name, tag.WheelID) {
+			return
+		}
+
+		if err := h.Database.Queries().RenameTag(r.Context(), queries.RenameTagParams{
+			LOWER:  name,
+			ID:     tag.ID,
+			UserID: userID,
+		}); err != nil {
+			h.Logger.Error("Failed to rename tag", "error", err)
+			h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to rename tag")
+			return
+		}
+		tag.Name = name
+	}
+
+	h.writeJSON(w, http.StatusOK, APITag(tag))
+}
+
+// APIDeleteTag handles deleting a tag and removing it from every option
+func (h *Handler) APIDeleteTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	tag, ok := h.apiTag(w, r, userID)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if err := h.Database.Queries().ClearOptionsForTag(ctx, tag.ID); err != nil {
+		h.Logger.Error("Failed to remove tag from options", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to delete tag")
+		return
+	}
+
+	if err := h.Database.Queries().DeleteTag(ctx, queries.DeleteTagParams{
+		ID:     tag.ID,
+		UserID: userID,
+	}); err != nil {
+		h.Logger.Error("Failed to delete tag", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to delete tag")
+		return
+	}
+
+	h.Logger.Info("Tag deleted", "id", tag.ID, "name", tag.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
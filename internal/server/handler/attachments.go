@@ -0,0 +1,402 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/images"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+const (
+	// maxOptionLinks is the most links an option can have
+	maxOptionLinks = 5
+	// maxLinkLength is the longest link, in characters, an option can have
+	maxLinkLength = 2048
+)
+
+var (
+	errOptionTooManyLinks = fmt.Errorf("an option can have at most %d links", maxOptionLinks)
+	errOptionLink         = fmt.Errorf("links must be http or https URLs of at most %d characters", maxLinkLength)
+)
+
+// normalizeLinks checks every link is a web address, dropping blanks and repeats
+func normalizeLinks(links []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool, len(links))
+	for _, link := range links {
+		link = strings.TrimSpace(link)
+		if link == "" || seen[link] {
+			continue
+		}
+		if len(link) > maxLinkLength {
+			return nil, errOptionLink
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errOptionLink
+		}
+		seen[link] = true
+		normalized = append(normalized, link)
+	}
+	if len(normalized) > maxOptionLinks {
+		return nil, errOptionTooManyLinks
+	}
+	return normalized, nil
+}
+
+// linksFromForm reads the links typed into the edit form, one per line
+func linksFromForm(value string) []string {
+	return strings.Fields(value)
+}
+
+// encodeLinks converts links to their stored form, NULL when there are none
+func encodeLinks(links []string) (sql.NullString, error) {
+	if len(links) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(links)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode links: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// optionLinks decodes the stored links of an option. Links that cannot be read are treated as none.
+func optionLinks(stored sql.NullString) []string {
+	links := []string{}
+	if !stored.Valid || stored.String == "" {
+		return links
+	}
+	if err := json.Unmarshal([]byte(stored.String), &links); err != nil {
+		return []string{}
+	}
+	return links
+}
+
+// optionImageURL returns where the image of the size on an option is served, or an empty string when it has none.
+// The key is part of the URL so a replaced image is never served from a cache.
+func optionImageURL(optionID int64, key sql.NullString, size images.Size) string {
+	if !key.Valid {
+		return ""
+	}
+	path := "/api/v1/options/" + strconv.FormatInt(optionID, 10) + "/image"
+	if size == images.Thumbnail {
+		path += "/thumbnail"
+	}
+	return path + "?v=" + url.QueryEscape(key.String)
+}
+
+// images returns where uploaded images are stored. Defaults to the database.
+func (h *Handler) images() images.Store {
+	if h.Images == nil {
+		return images.NewDBStore(h.Database)
+	}
+	return h.Images
+}
+
+// setOptionImage stores the image and puts it on the option in place of any image it had
+func (h *Handler) setOptionImage(ctx context.Context, userID int64, opt queries.Option, img images.Processed) (queries.Option, error) {
+	key, err := images.NewKey()
+	if err != nil {
+		return queries.Option{}, err
+	}
+	if err := h.images().Save(ctx, key, img); err != nil {
+		return queries.Option{}, err
+	}
+
+	imageKey := sql.NullString{String: key, Valid: true}
+	if err := h.Database.Queries().SetOptionImage(ctx, queries.SetOptionImageParams{
+		ImageKey: imageKey,
+		ID:       opt.ID,
+		UserID:   userID,
+	}); err != nil {
+		h.releaseImages(ctx, imageKey)
+		return queries.Option{}, fmt.Errorf("failed to set option image: %w", err)
+	}
+
+	h.releaseImages(ctx, opt.ImageKey)
+	opt.ImageKey = imageKey
+	return opt, nil
+}
+
+// removeOptionImage takes the image off the option
+func (h *Handler) removeOptionImage(ctx context.Context, userID int64, opt queries.Option) (queries.Option, error) {
+	if err := h.Database.Queries().SetOptionImage(ctx, queries.SetOptionImageParams{
+		ID:     opt.ID,
+		UserID: userID,
+	}); err != nil {
+		return queries.Option{}, fmt.Errorf("failed to remove option image: %w", err)
+	}
+
+	h.releaseImages(ctx, opt.ImageKey)
+	opt.ImageKey = sql.NullString{}
+	return opt, nil
+}
+
+// releaseImages deletes the stored images no option uses anymore. Duplicated wheels share images with the wheel
+// they were copied from, so an image is kept while any option still has it. Images stored in the database are
+// counted and deleted in one transaction. Files are deleted once it commits, which is safe because an option only
+// gets an image that is in use by copying it, and no option uses these anymore. Failures are logged rather than
+// returned since the option no longer points at the image either way.
+func (h *Handler) releaseImages(ctx context.Context, keys ...sql.NullString) {
+	for _, key := range keys {
+		if !key.Valid {
+			continue
+		}
+		var unused bool
+		err := h.Database.WithTx(ctx, func(q *queries.Queries) error {
+			count, err := q.CountOptionsWithImage(ctx, key)
+			if err != nil {
+				return fmt.Errorf("failed to count options with image: %w", err)
+			}
+			if unused = count == 0; !unused || h.Images != nil {
+				return nil
+			}
+			if err := q.DeleteImage(ctx, key.String); err != nil {
+				return fmt.Errorf("failed to delete image: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			h.Logger.Error("Failed to release image", "error", err, "key", key.String)
+			continue
+		}
+		if unused && h.Images != nil {
+			if err := h.Images.Delete(ctx, key.String); err != nil {
+				h.Logger.Error("Failed to delete image", "error", err, "key", key.String)
+			}
+		}
+	}
+}
+
+// isInvalidImage reports whether the error is from an upload that is not an image that can be stored
+func isInvalidImage(err error) bool {
+	return errors.Is(err, images.ErrTooLarge) || errors.Is(err, images.ErrTooManyPixels) || errors.Is(err, images.ErrUnsupported)
+}
+
+// serveImage sends a stored image. Requests for the current image, by its key, can be cached for good.
+func (h *Handler) serveImage(w http.ResponseWriter, r *http.Request, key string, data []byte) {
+	w.Header().Set("Content-Type", images.ContentType)
+	w.Header().Set("ETag", strconv.Quote(key))
+	if r.URL.Query().Get("v") == key {
+		w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// optionForForm fetches the option named by the {id} path value for a form handler, sending an error if it
+// cannot be found
+func (h *Handler) optionForForm(w http.ResponseWriter, r *http.Request, userID int64) (queries.Option, bool) {
+	id, err := stringToInt64(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid option ID", http.StatusBadRequest)
+		return queries.Option{}, false
+	}
+
+	opt, err := h.Database.Queries().GetOption(r.Context(), queries.GetOptionParams{ID: id, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Option not found", http.StatusNotFound)
+		return queries.Option{}, false
+	}
+	if err != nil {
+		h.Logger.Error("Failed to get option", "error", err)
+		http.Error(w, "Failed to get option", http.StatusInternalServerError)
+		return queries.Option{}, false
+	}
+	return opt, true
+}
+
+// UploadOptionImage handles uploading the image of an option from its edit form
+func (h *Handler) UploadOptionImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	opt, ok := h.optionForForm(w, r, userID)
+	if !ok {
+		return
+	}
+
+	// Leave room for the rest of the multipart body around the file
+	r.Body = http.MaxBytesReader(w, r.Body, images.MaxUploadSize+1<<20)
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		message := "Choose an image to upload"
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			message = images.ErrTooLarge.Error()
+		}
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, "Could not upload: "+message))
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	img, err := images.Process(file)
+	if isInvalidImage(err) {
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, "Could not upload: "+err.Error()))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Failed to process image", "error", err)
+		http.Error(w, "Failed to upload image", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+	opt, err = h.setOptionImage(ctx, userID, opt, img)
+	if err != nil {
+		h.Logger.Error("Failed to set option image", "error", err)
+		http.Error(w, "Failed to upload image", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Option image uploaded", "id", opt.ID, "size", len(img.Image))
+	h.html(ctx, w, http.StatusOK, home.ImageInputSection(optionToAppOption(opt, nil)))
+}
+
+// RemoveOptionImage handles removing the image of an option from its edit form
+func (h *Handler) RemoveOptionImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	opt, ok := h.optionForForm(w, r, userID)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	opt, err := h.removeOptionImage(ctx, userID, opt)
+	if err != nil {
+		h.Logger.Error("Failed to remove option image", "error", err)
+		http.Error(w, "Failed to remove image", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Option image removed", "id", opt.ID)
+	h.html(ctx, w, http.StatusOK, home.ImageInputSection(optionToAppOption(opt, nil)))
+}
+
+// APIGetOptionImage handles fetching the image of an option
+func (h *Handler) APIGetOptionImage(w http.ResponseWriter, r *http.Request) {
+	h.apiServeOptionImage(w, r, images.Full)
+}
+
+// APIGetOptionThumbnail handles fetching the thumbnail of an option's image
+func (h *Handler) APIGetOptionThumbnail(w http.ResponseWriter, r *http.Request) {
+	h.apiServeOptionImage(w, r, images.Thumbnail)
+}
+
+func (h *Handler) apiServeOptionImage(w http.ResponseWriter, r *http.Request, size images.Size) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	opt, ok := h.apiOption(w, r, userID)
+	if !ok {
+		return
+	}
+	if !opt.ImageKey.Valid {
+		h.writeJSONError(w, http.StatusNotFound, apiErrNotFound, "Option has no image")
+		return
+	}
+
+	data, err := h.images().Load(r.Context(), opt.ImageKey.String, size)
+	if errors.Is(err, images.ErrNotFound) {
+		h.writeJSONError(w, http.StatusNotFound, apiErrNotFound, "Option has no image")
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Failed to load image", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to load image")
+		return
+	}
+
+	h.serveImage(w, r, opt.ImageKey.String, data)
+}
+
+// APIPutOptionImage handles uploading the image of an option, sent as the request body
+func (h *Handler) APIPutOptionImage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	opt, ok := h.apiOption(w, r, userID)
+	if !ok {
+		return
+	}
+
+	img, err := images.Process(r.Body)
+	if isInvalidImage(err) {
+		h.writeJSONError(w, http.StatusUnprocessableEntity, apiErrValidation, err.Error())
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Failed to process image", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to upload image")
+		return
+	}
+
+	ctx := r.Context()
+	opt, err = h.setOptionImage(ctx, userID, opt, img)
+	if err != nil {
+		h.Logger.Error("Failed to set option image", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to upload image")
+		return
+	}
+
+	h.Logger.Info("Option image uploaded", "id", opt.ID, "size", len(img.Image))
+	h.writeJSON(w, http.StatusOK, h.dbOptionToAPIOption(ctx, opt, userID))
+}
+
+// APIDeleteOptionImage handles removing the image of an option
+func (h *Handler) APIDeleteOptionImage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	opt, ok := h.apiOption(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if _, err := h.removeOptionImage(r.Context(), userID, opt); err != nil {
+		h.Logger.Error("Failed to remove option image", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to remove image")
+		return
+	}
+
+	h.Logger.Info("Option image removed", "id", opt.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,334 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/images"
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// pngImage returns a PNG of the size filled with one color
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{G: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// apiOption fetches the option with the ID from the API
+func (e testEnv) apiOption(t *testing.T, id string) handler.APIOption {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/options/"+id, nil)
+	r.SetPathValue("id", id)
+	status, body := e.page(t, e.handler.APIGetOption, r)
+	require.Equal(t, http.StatusOK, status)
+
+	var opt handler.APIOption
+	require.NoError(t, json.Unmarshal([]byte(body), &opt))
+	return opt
+}
+
+// imageKey returns the key of the image on the option with the ID
+func (e testEnv) imageKey(t *testing.T, id string) sql.NullString {
+	t.Helper()
+	intID, err := strconv.ParseInt(id, 10, 64)
+	require.NoError(t, err)
+	opt, err := e.db.Queries().GetOption(context.Background(), queries.GetOptionParams{ID: intID, UserID: e.userID})
+	require.NoError(t, err)
+	return opt.ImageKey
+}
+
+// imageStored reports whether an image is stored under the key
+func (e testEnv) imageStored(t *testing.T, key sql.NullString) bool {
+	t.Helper()
+	require.True(t, key.Valid)
+	_, err := images.NewDBStore(e.db).Load(context.Background(), key.String, images.Thumbnail)
+	if errors.Is(err, images.ErrNotFound) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
+
+// uploadImage uploads the file as the image of the option with the ID from the edit form
+func (e testEnv) uploadImage(t *testing.T, id string, file []byte) (int, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("image", "photo.png")
+	require.NoError(t, err)
+	_, err = part.Write(file)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/api/options/"+id+"/image", &body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	r.SetPathValue("id", id)
+	return e.page(t, e.handler.UploadOptionImage, r)
+}
+
+func TestOptionLinksFromAPI(t *testing.T) {
+	e := newTestEnv(t)
+
+	status := e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Pizza", "links": [" https://example.com/menu ", "https://example.com/menu", "http://maps.example.com/?q=pizza"]}`)
+	require.Equal(t, http.StatusCreated, status)
+	id := e.optionID(t, "Pizza")
+
+	opt := e.apiOption(t, id)
+	require.Equal(t, []string{"https://example.com/menu", "http://maps.example.com/?q=pizza"}, opt.Links)
+	require.Nil(t, opt.ImageURL)
+	require.Nil(t, opt.ThumbnailURL)
+
+	tests := []struct {
+		name  string
+		links string
+	}{
+		{name: "not a web address", links: `["ftp://example.com/file"]`},
+		{name: "script", links: `["javascript:alert(1)"]`},
+		{name: "relative", links: `["/menu"]`},
+		{name: "too long", links: `["https://example.com/` + strings.Repeat("a", 2048) + `"]`},
+		{name: "too many", links: `["https://a.com", "https://b.com", "https://c.com", "https://d.com", "https://e.com", "https://f.com"]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := e.serve(t, e.handler.APIUpdateOption, http.MethodPut, "/api/v1/options/"+id, "application/json", `{"name": "Pizza", "links": `+test.links+`}`, "id", id)
+			require.Equal(t, http.StatusUnprocessableEntity, status)
+			require.Len(t, e.apiOption(t, id).Links, 2)
+		})
+	}
+
+	// Replacing an option without links clears them
+	status = e.serve(t, e.handler.APIUpdateOption, http.MethodPut, "/api/v1/options/"+id, "application/json", `{"name": "Pizza"}`, "id", id)
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, e.apiOption(t, id).Links)
+}
+
+func TestUpdateOptionLinksFromForm(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Pizza"}`)
+	id := e.optionID(t, "Pizza")
+
+	tests := []struct {
+		name     string
+		links    string
+		status   int
+		expected []string
+	}{
+		{name: "one per line", links: "https://example.com/menu\r\n\r\nhttps://example.com/map\n", status: http.StatusOK, expected: []string{"https://example.com/menu", "https://example.com/map"}},
+		{name: "invalid", links: "example.com", status: http.StatusBadRequest, expected: []string{"https://example.com/menu", "https://example.com/map"}},
+		{name: "blank clears", links: " ", status: http.StatusOK, expected: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := e.postForm(t, e.handler.UpdateOptionDetails, "/api/options/update", url.Values{
+				"id":     {id},
+				"text":   {"Pizza"},
+				"weight": {"1"},
+				"links":  {test.links},
+			})
+			require.Equal(t, test.status, status)
+			require.Equal(t, test.expected, e.apiOption(t, id).Links)
+		})
+	}
+}
+
+func TestOptionImage(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Pizza"}`)
+	id := e.optionID(t, "Pizza")
+
+	status, body := e.uploadImage(t, id, pngImage(t, 2400, 1200))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `id="image-input-`+id+`"`)
+	key := e.imageKey(t, id)
+	require.True(t, e.imageStored(t, key))
+
+	opt := e.apiOption(t, id)
+	require.NotNil(t, opt.ImageURL)
+	require.NotNil(t, opt.ThumbnailURL)
+	require.Equal(t, "/api/v1/options/"+id+"/image?v="+key.String, *opt.ImageURL)
+	require.Contains(t, body, *opt.ThumbnailURL)
+
+	// The image is resized and served as a JPEG that can be cached for good
+	r := httptest.NewRequest(http.MethodGet, *opt.ImageURL, nil)
+	r.SetPathValue("id", id)
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	e.handler.APIGetOptionImage(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, images.ContentType, w.Header().Get("Content-Type"))
+	require.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+	served, _, err := image.DecodeConfig(w.Body)
+	require.NoError(t, err)
+	require.Equal(t, images.MaxDimension, served.Width)
+
+	// A request that already has the image is told it is unchanged
+	r = httptest.NewRequest(http.MethodGet, *opt.ThumbnailURL, nil)
+	r.SetPathValue("id", id)
+	r.Header.Set("If-None-Match", strconv.Quote(key.String))
+	r = utils.SetUserID(r, e.userID)
+	w = httptest.NewRecorder()
+	e.handler.APIGetOptionThumbnail(w, r)
+	require.Equal(t, http.StatusNotModified, w.Code)
+
+	// Replacing the image deletes the one it replaced
+	status = e.serve(t, e.handler.APIPutOptionImage, http.MethodPut, "/api/v1/options/"+id+"/image", "image/png", string(pngImage(t, 10, 10)), "id", id)
+	require.Equal(t, http.StatusOK, status)
+	replaced := e.imageKey(t, id)
+	require.NotEqual(t, key, replaced)
+	require.False(t, e.imageStored(t, key))
+
+	status = e.serve(t, e.handler.APIDeleteOptionImage, http.MethodDelete, "/api/v1/options/"+id+"/image", "", "", "id", id)
+	require.Equal(t, http.StatusNoContent, status)
+	require.False(t, e.imageKey(t, id).Valid)
+	require.False(t, e.imageStored(t, replaced))
+	require.Nil(t, e.apiOption(t, id).ImageURL)
+
+	status = e.serve(t, e.handler.APIGetOptionImage, http.MethodGet, "/api/v1/options/"+id+"/image", "", "", "id", id)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestOptionImageRejectsInvalidUploads(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Pizza"}`)
+	id := e.optionID(t, "Pizza")
+
+	status, _ := e.uploadImage(t, id, []byte("<svg></svg>"))
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status = e.serve(t, e.handler.APIPutOptionImage, http.MethodPut, "/api/v1/options/"+id+"/image", "image/png", "not an image", "id", id)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.False(t, e.imageKey(t, id).Valid)
+}
+
+func TestOptionImageBelongsToItsUser(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Pizza"}`)
+	id := e.optionID(t, "Pizza")
+	status, _ := e.uploadImage(t, id, pngImage(t, 10, 10))
+	require.Equal(t, http.StatusOK, status)
+
+	other, err := e.db.Queries().CreateUser(context.Background(), queries.CreateUserParams{Email: "b@example.com", PasswordHash: "x"})
+	require.NoError(t, err)
+	intruder := e
+	intruder.userID = other.ID
+
+	status = intruder.serve(t, e.handler.APIGetOptionImage, http.MethodGet, "/api/v1/options/"+id+"/image", "", "", "id", id)
+	require.Equal(t, http.StatusNotFound, status)
+	status = intruder.serve(t, e.handler.APIDeleteOptionImage, http.MethodDelete, "/api/v1/options/"+id+"/image", "", "", "id", id)
+	require.Equal(t, http.StatusNotFound, status)
+	status, _ = intruder.uploadImage(t, id, pngImage(t, 10, 10))
+	require.Equal(t, http.StatusNotFound, status)
+
+	// Signed out requests are turned away
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/options/"+id+"/image", nil)
+	r.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+	e.handler.APIGetOptionImage(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestOptionImageIsSharedWithDuplicatedWheel(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Pizza"}`)
+	id := e.optionID(t, "Pizza")
+	status, _ := e.uploadImage(t, id, pngImage(t, 10, 10))
+	require.Equal(t, http.StatusOK, status)
+	key := e.imageKey(t, id)
+
+	status = e.postForm(t, e.handler.DuplicateWheel, "/api/wheels/duplicate", url.Values{})
+	require.Equal(t, http.StatusOK, status)
+	copyID := e.optionID(t, "Pizza")
+	require.NotEqual(t, id, copyID)
+	require.Equal(t, key, e.imageKey(t, copyID))
+
+	// The copy keeps the image after the original option is deleted
+	status = e.serve(t, e.handler.APIDeleteOption, http.MethodDelete, "/api/v1/options/"+id, "", "", "id", id)
+	require.Equal(t, http.StatusNoContent, status)
+	require.True(t, e.imageStored(t, key))
+
+	// Deleting the wheel of the last option with the image deletes the image
+	wheelID := strconv.FormatInt(e.wheelID(t), 10)
+	status = e.serve(t, e.handler.DeleteWheel, http.MethodDelete, "/api/wheels/"+wheelID, "", "", "id", wheelID)
+	require.Equal(t, http.StatusOK, status)
+	require.False(t, e.imageStored(t, key))
+}
+
+func TestOptionImageInDirectory(t *testing.T) {
+	e := newTestEnv(t)
+	store, err := images.NewDirStore(t.TempDir())
+	require.NoError(t, err)
+	e.handler.Images = store
+	e.createOptions(t, `{"name": "Pizza"}`)
+	id := e.optionID(t, "Pizza")
+
+	status, _ := e.uploadImage(t, id, pngImage(t, 10, 10))
+	require.Equal(t, http.StatusOK, status)
+	key := e.imageKey(t, id)
+	_, err = store.Load(context.Background(), key.String, images.Full)
+	require.NoError(t, err)
+	require.False(t, e.imageStored(t, key), "the image is not stored in the database")
+
+	status = e.serve(t, e.handler.APIDeleteOption, http.MethodDelete, "/api/v1/options/"+id, "", "", "id", id)
+	require.Equal(t, http.StatusNoContent, status)
+	_, err = store.Load(context.Background(), key.String, images.Full)
+	require.ErrorIs(t, err, images.ErrNotFound)
+}
+
+func TestResultShowsAttachments(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Pizza", "links": ["https://www.example.com/menu"]}`)
+	id := e.optionID(t, "Pizza")
+	status, _ := e.uploadImage(t, id, pngImage(t, 10, 10))
+	require.Equal(t, http.StatusOK, status)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/random", nil)
+	r.PostForm = url.Values{}
+	status, body := e.page(t, e.handler.RandomPicker, r)
+
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `id="result-image"`)
+	require.Contains(t, body, `src="/api/v1/options/`+id+`/image?v=`+e.imageKey(t, id).String+`"`)
+	require.Contains(t, body, `id="result-links"`)
+	require.Contains(t, body, `href="https://www.example.com/menu"`)
+	require.Contains(t, body, `rel="noopener noreferrer nofollow"`)
+	require.Contains(t, body, "example.com")
+}
+
+func TestTransferLinks(t *testing.T) {
+	e := newTestEnv(t)
+	csv := "name,links\nPizza,https://example.com/menu https://example.com/map\nBad,javascript:alert(1)\n"
+	status := e.serve(t, e.handler.APIImport, http.MethodPost, "/api/v1/import", "text/csv", csv)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string][]string{"Pizza": {}}, e.options(t))
+	require.Equal(t, []string{"https://example.com/menu", "https://example.com/map"}, e.apiOption(t, e.optionID(t, "Pizza")).Links)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/export?format=csv", nil)
+	status, body := e.page(t, e.handler.APIExport, r)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "Pizza,,1,,,https://example.com/menu https://example.com/map,,\n")
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/availability"
+)
+
+// spinTimeLayout is how the spin form's datetime-local input writes a time, in the user's time zone
+const spinTimeLayout = "2006-01-02T15:04"
+
+// maxSpinTimeAhead is how far in the future a spin can target
+const maxSpinTimeAhead = 366 * 24 * time.Hour
+
+var errSpinTimeTooFar = errors.New("spins can target at most a year ahead")
+
+// optionAvailability returns the availability rules stored on an option. Rules are validated before they are
+// stored, so rules that cannot be parsed are treated as no rules rather than hiding the option.
+func optionAvailability(stored sql.NullString) availability.Rules {
+	if !stored.Valid {
+		return nil
+	}
+	rules, err := availability.Parse(stored.String)
+	if err != nil {
+		return nil
+	}
+	return rules
+}
+
+// encodeAvailability returns the rules as they are stored on an option. Options without rules store NULL.
+func encodeAvailability(rules availability.Rules) (sql.NullString, error) {
+	if len(rules) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode availability: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// userLocation returns the user's configured time zone, falling back to UTC if it cannot be loaded
+func (h *Handler) userLocation(ctx context.Context, userID int64) *time.Location {
+	name, err := h.Database.Queries().GetUserTimeZone(ctx, userID)
+	if err != nil {
+		h.Logger.Warn("Failed to get time zone", "user_id", userID, "error", err)
+		return time.UTC
+	}
+	loc, err := loadTimeZone(name)
+	if err != nil {
+		h.Logger.Warn("Invalid stored time zone", "user_id", userID, "time_zone", name, "error", err)
+		return time.UTC
+	}
+	return loc
+}
+
+// loadTimeZone loads an IANA time zone such as America/Chicago. The server's local time zone is not accepted.
+func loadTimeZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// parseSpinTime returns the time a spin is for in the user's time zone. A blank value is now.
+// Values with an offset, such as RFC 3339 times, are accepted as well as wall clock times in the user's time zone.
+// The returned target is nil when the spin is for now.
+func parseSpinTime(value string, loc *time.Location, now time.Time) (time.Time, *time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return now.In(loc), nil, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		at, err = time.ParseInLocation(spinTimeLayout, value, loc)
+	}
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("time %q must be written as YYYY-MM-DDTHH:MM", value)
+	}
+	if at.Sub(now) > maxSpinTimeAhead {
+		return time.Time{}, nil, errSpinTimeTooFar
+	}
+
+	at = at.In(loc)
+	return at, &at, nil
+}
@@ -0,0 +1,152 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/stretchr/testify/require"
+)
+
+// wheelID returns the ID of the user's active wheel
+func (e testEnv) wheelID(t *testing.T) int64 {
+	t.Helper()
+	wheel, err := e.db.Queries().GetActiveWheel(context.Background(), e.userID)
+	require.NoError(t, err)
+	return wheel.ID
+}
+
+func TestSpinAvailability(t *testing.T) {
+	e := newTestEnv(t)
+	require.Equal(t, http.StatusOK, e.postForm(t, e.handler.UpdateTimeZone, "/api/settings/time-zone", url.Values{"time_zone": {"America/Chicago"}}))
+
+	options := []string{
+		`{"name": "Lunch spot", "availability": [{"days": ["mon", "tue", "wed", "thu", "fri"], "start": "11:00", "end": "14:00"}]}`,
+		`{"name": "Hike", "availability": [{"days": ["sat", "sun"]}]}`,
+		`{"name": "Late show", "availability": [{"days": ["fri", "sat"], "start": "22:00", "end": "02:00"}]}`,
+		`{"name": "Reading"}`,
+	}
+	for _, body := range options {
+		require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", body))
+	}
+
+	tests := []struct {
+		name     string
+		at       string
+		expected []string
+	}{
+		{name: "weekday lunch", at: "2026-10-19T12:30", expected: []string{"Lunch spot", "Reading"}},
+		{name: "weekday evening", at: "2026-10-19T19:00", expected: []string{"Reading"}},
+		{name: "saturday evening", at: "2026-10-17T23:00", expected: []string{"Hike", "Late show", "Reading"}},
+		{name: "after midnight", at: "2026-10-18T01:00", expected: []string{"Hike", "Late show", "Reading"}},
+		{name: "offset is converted to the time zone", at: "2026-10-19T17:30:00Z", expected: []string{"Lunch spot", "Reading"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, result := e.spin(t, `{"at": "`+test.at+`"}`)
+			require.Equal(t, http.StatusOK, status)
+
+			eligible := make([]string, len(result.Eligible))
+			for i, chance := range result.Eligible {
+				eligible[i] = chance.Name
+			}
+			slices.Sort(eligible)
+			require.Equal(t, test.expected, eligible)
+
+			require.NotNil(t, result.TargetAt)
+			_, offset := result.TargetAt.Zone()
+			require.Equal(t, -5*60*60, offset)
+		})
+	}
+}
+
+func TestSpinAvailabilityRecordsTarget(t *testing.T) {
+	e := newTestEnv(t)
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Reading"}`))
+
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, result.TargetAt)
+
+	status, result = e.spin(t, `{"at": "2026-10-17T18:00:00Z"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, result.TargetAt)
+
+	spins, err := e.db.Queries().GetSpins(context.Background(), queries.GetSpinsParams{WheelID: sql.NullInt64{Int64: e.wheelID(t), Valid: true}, UserID: e.userID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, spins, 2)
+	require.True(t, spins[0].TargetAt.Valid)
+	require.True(t, time.Date(2026, time.October, 17, 18, 0, 0, 0, time.UTC).Equal(spins[0].TargetAt.Time))
+	require.False(t, spins[1].TargetAt.Valid)
+}
+
+func TestSpinInvalidTime(t *testing.T) {
+	e := newTestEnv(t)
+
+	for _, body := range []string{`{"at": "saturday"}`, `{"at": "2099-01-01T00:00"}`} {
+		status, _ := e.spin(t, body)
+		require.Equal(t, http.StatusUnprocessableEntity, status, body)
+	}
+}
+
+func TestCreateOptionInvalidAvailability(t *testing.T) {
+	e := newTestEnv(t)
+
+	bodies := []string{
+		`{"name": "A", "availability": {"days": ["mon"]}}`,
+		`{"name": "A", "availability": [{"days": []}]}`,
+		`{"name": "A", "availability": [{"days": ["mon"], "start": "11:00"}]}`,
+		`{"name": "A", "availability": [{"days": ["someday"]}]}`,
+	}
+	for _, body := range bodies {
+		status := e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", body)
+		require.Equal(t, http.StatusUnprocessableEntity, status, body)
+	}
+}
+
+func TestUpdateOptionAvailabilityFromForm(t *testing.T) {
+	e := newTestEnv(t)
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Lunch spot"}`))
+	id := e.optionID(t, "Lunch spot")
+
+	form := url.Values{
+		"id":           {id},
+		"text":         {"Lunch spot"},
+		"weight":       {"1"},
+		"availability": {`[{"days": ["mon", "tue"], "start": "11:00", "end": "14:00"}]`},
+	}
+	require.Equal(t, http.StatusOK, e.postForm(t, e.handler.UpdateOptionDetails, "/api/options/update", form))
+
+	opt, err := e.db.Queries().GetOptionByName(context.Background(), queries.GetOptionByNameParams{WheelID: e.wheelID(t), UserID: e.userID, Name: "Lunch spot"})
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"days": ["mon", "tue"], "start": "11:00", "end": "14:00"}]`, opt.Availability.String)
+
+	form.Set("availability", `[{"days": ["mon"], "start": "25:00", "end": "14:00"}]`)
+	require.Equal(t, http.StatusBadRequest, e.postForm(t, e.handler.UpdateOptionDetails, "/api/options/update", form))
+
+	// Clearing the editor makes the option available any time
+	form.Set("availability", "")
+	require.Equal(t, http.StatusOK, e.postForm(t, e.handler.UpdateOptionDetails, "/api/options/update", form))
+	opt, err = e.db.Queries().GetOptionByName(context.Background(), queries.GetOptionByNameParams{WheelID: e.wheelID(t), UserID: e.userID, Name: "Lunch spot"})
+	require.NoError(t, err)
+	require.False(t, opt.Availability.Valid)
+}
+
+func TestUpdateTimeZone(t *testing.T) {
+	e := newTestEnv(t)
+
+	for _, name := range []string{"", "Local", "Mars/Olympus_Mons"} {
+		require.Equal(t, http.StatusBadRequest, e.postForm(t, e.handler.UpdateTimeZone, "/api/settings/time-zone", url.Values{"time_zone": {name}}), name)
+	}
+
+	require.Equal(t, http.StatusOK, e.postForm(t, e.handler.UpdateTimeZone, "/api/settings/time-zone", url.Values{"time_zone": {"Europe/Paris"}}))
+	timeZone, err := e.db.Queries().GetUserTimeZone(context.Background(), e.userID)
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Paris", timeZone)
+}
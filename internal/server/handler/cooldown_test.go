@@ -0,0 +1,230 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// spin spins the active wheel through the API and decodes the result when the spin succeeds
+func (e testEnv) spin(t *testing.T, body string) (int, handler.APISpinResult) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/spins", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	e.handler.APISpinWheel(w, r)
+
+	var result handler.APISpinResult
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	}
+	return w.Code, result
+}
+
+func TestSpinSkipsOptionsCoolingDown(t *testing.T) {
+	e := newTestEnv(t)
+
+	cooldown := int64(3 * 24 * 60)
+	body, err := json.Marshal(handler.APIOptionInput{Name: "Favorite", CooldownMinutes: &cooldown})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", string(body)))
+
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "Favorite", result.Option.Name)
+	require.Equal(t, &cooldown, result.Option.CooldownMinutes)
+	require.Empty(t, result.CoolingDown)
+
+	// The only option was just picked, so nothing can come up
+	status, _ = e.spin(t, `{}`)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Other"}`))
+
+	// Options without a cooldown can come up again straight away
+	for range 3 {
+		status, result = e.spin(t, `{}`)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "Other", result.Option.Name)
+		require.Len(t, result.Eligible, 1)
+		require.Len(t, result.CoolingDown, 1)
+		require.Equal(t, "Favorite", result.CoolingDown[0].Name)
+		require.WithinDuration(t, time.Now().Add(3*24*time.Hour), result.CoolingDown[0].AvailableAt, time.Minute)
+	}
+}
+
+func TestDeletedOptionCooldownIsNotInherited(t *testing.T) {
+	e := newTestEnv(t)
+
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Favorite", "cooldown_minutes": 60}`))
+	status, _ := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	id := e.optionID(t, "Favorite")
+	status = e.serve(t, e.handler.APIDeleteOption, http.MethodDelete, "/api/v1/options/"+id, "", "", "id", id)
+	require.Equal(t, http.StatusNoContent, status)
+
+	// The new option is given the deleted option's ID, but none of its spins
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Newcomer", "cooldown_minutes": 60}`))
+	require.Equal(t, id, e.optionID(t, "Newcomer"))
+
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "Newcomer", result.Option.Name)
+	require.Empty(t, result.CoolingDown)
+}
+
+func TestRejectedPickDoesNotCoolDown(t *testing.T) {
+	e := newTestEnv(t)
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Favorite", "cooldown_minutes": 60}`))
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Other"}`))
+
+	// Spin until the option with the cooldown comes up, then veto it
+	var result handler.APISpinResult
+	for result.Option.Name != "Favorite" {
+		var status int
+		status, result = e.spin(t, `{}`)
+		require.Equal(t, http.StatusOK, status)
+	}
+	status, rerolled := e.reroll(t, result.SpinID)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "Other", rerolled.Option.Name)
+
+	status, result = e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, result.Eligible, 2)
+	require.Empty(t, result.CoolingDown)
+}
+
+func TestSpinAfterCooldownEnds(t *testing.T) {
+	e := newTestEnv(t)
+
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Favorite", "cooldown_minutes": 60}`))
+	status, _ := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+
+	// Spin again once the cooldown is over
+	later := time.Now().Add(61 * time.Minute)
+	e.handler.Now = func() time.Time { return later }
+
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "Favorite", result.Option.Name)
+	require.Empty(t, result.CoolingDown)
+}
+
+func TestCreateOptionInvalidCooldown(t *testing.T) {
+	e := newTestEnv(t)
+
+	for _, body := range []string{`{"name": "A", "cooldown_minutes": -1}`, `{"name": "A", "cooldown_minutes": 525601}`} {
+		status := e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", body)
+		require.Equal(t, http.StatusUnprocessableEntity, status, body)
+	}
+}
+
+func TestUpdateOptionCooldownFromForm(t *testing.T) {
+	e := newTestEnv(t)
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Favorite"}`))
+	id := e.optionID(t, "Favorite")
+
+	tests := []struct {
+		name     string
+		amount   string
+		unit     string
+		expected int64
+	}{
+		{name: "days", amount: "3", unit: "days", expected: 3 * 24 * 60},
+		{name: "hours", amount: "12", unit: "hours", expected: 12 * 60},
+		{name: "minutes", amount: "90", unit: "minutes", expected: 90},
+		{name: "clamped to a year", amount: "1000", unit: "days", expected: 365 * 24 * 60},
+		{name: "zero clears", amount: "0", unit: "days", expected: 0},
+		{name: "blank clears", amount: "", unit: "hours", expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := e.postForm(t, e.handler.UpdateOptionDetails, "/api/options/update", url.Values{
+				"id":            {id},
+				"text":          {"Favorite"},
+				"weight":        {"1"},
+				"cooldown":      {test.amount},
+				"cooldown_unit": {test.unit},
+			})
+			require.Equal(t, http.StatusOK, status)
+
+			optionID, err := strconv.ParseInt(id, 10, 64)
+			require.NoError(t, err)
+			opt, err := e.db.Queries().GetOption(context.Background(), queries.GetOptionParams{ID: optionID, UserID: e.userID})
+			require.NoError(t, err)
+			require.Equal(t, test.expected > 0, opt.CooldownMinutes.Valid)
+			require.Equal(t, test.expected, opt.CooldownMinutes.Int64)
+		})
+	}
+}
+
+func TestTransferCooldownAndAvailability(t *testing.T) {
+	e := newTestEnv(t)
+
+	body := `[
+		{"name": "Lunch out", "cooldown_minutes": 1440, "availability": [{"days": ["mon", "fri"], "start": "11:00", "end": "14:00"}, {"days": ["sat", "sun"]}]},
+		{"name": "Brunch", "availability": [{"days": ["someday"]}]},
+		{"name": "Nap", "cooldown_minutes": -5}
+	]`
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/import", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	status, resp := e.page(t, e.handler.APIImport, r)
+	require.Equal(t, http.StatusOK, status)
+
+	var report handler.APIImportReport
+	require.NoError(t, json.Unmarshal([]byte(resp), &report))
+	require.Equal(t, 1, report.Imported)
+	require.Len(t, report.Valid, 1)
+	require.Equal(t, int64(1440), *report.Valid[0].CooldownMinutes)
+	require.Equal(t, "Mon, Fri 11:00–14:00, Weekends", report.Valid[0].Availability.String())
+	require.Len(t, report.Rejected, 2)
+
+	opt := e.apiOption(t, e.optionID(t, "Lunch out"))
+	require.Equal(t, int64(1440), *opt.CooldownMinutes)
+	require.Equal(t, "Mon, Fri 11:00–14:00, Weekends", opt.Availability.String())
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "csv", expected: "Lunch out,,1,,,,1440,\"mon,fri 11:00-14:00; sun,sat\"\n"},
+		{format: "json", expected: `"cooldown_minutes": 1440`},
+		{format: "yaml", expected: "    cooldown_minutes: 1440\n    availability:\n      - days: [mon, fri]\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			status, body := e.page(t, e.handler.APIExport, httptest.NewRequest(http.MethodGet, "/api/v1/export?format="+test.format, nil))
+			require.Equal(t, http.StatusOK, status)
+			require.Contains(t, body, test.expected)
+		})
+	}
+}
+
+func TestRenameKeepsCooldownAndAvailability(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "cooldown_minutes": 60, "availability": [{"days": ["sat", "sun"]}]}`)
+	id := e.optionID(t, "Hike")
+
+	require.Equal(t, http.StatusOK, e.postForm(t, e.handler.UpdateOption, "/api/options/update", url.Values{"id": {id}, "text": {"Long hike"}}))
+
+	opt := e.apiOption(t, id)
+	require.Equal(t, "Long hike", opt.Name)
+	require.NotNil(t, opt.CooldownMinutes)
+	require.Equal(t, int64(60), *opt.CooldownMinutes)
+	require.Len(t, opt.Availability, 1)
+}
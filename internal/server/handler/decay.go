@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+// maxDecayHalfLifeMinutes is the longest half-life a wheel's weights can recover over, one year
+const maxDecayHalfLifeMinutes = 365 * 24 * 60
+
+// decayHalfLife returns how long the wheel's options take to win back half of the weight they lose when picked.
+// Zero means weights do not decay.
+func decayHalfLife(wheel queries.Wheel) time.Duration {
+	if !wheel.DecayHalfLifeMinutes.Valid {
+		return 0
+	}
+	return time.Duration(wheel.DecayHalfLifeMinutes.Int64) * time.Minute
+}
+
+// wheelDecay returns the wheel's decay half-life along with when each of its options was last picked.
+// The half-life is zero when the wheel's weights do not decay.
+func (h *Handler) wheelDecay(ctx context.Context, userID, wheelID int64) (time.Duration, map[int64]time.Time, error) {
+	wheel, err := h.Database.Queries().GetWheel(ctx, queries.GetWheelParams{ID: wheelID, UserID: userID})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get wheel: %w", err)
+	}
+	halfLife := decayHalfLife(wheel)
+	if halfLife == 0 {
+		return 0, nil, nil
+	}
+
+	rows, err := h.Database.Queries().GetLastPicks(ctx, queries.GetLastPicksParams{
+		WheelID: nullWheelID(wheelID),
+		UserID:  userID,
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get last picks: %w", err)
+	}
+	picks := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		picks[row.OptionID.Int64] = time.Unix(row.PickedAt, 0)
+	}
+	return halfLife, picks, nil
+}
+
+// decay returns the options with the weights they have at the time after recent picks on the wheel.
+// Options are returned as they are when the wheel's weights do not decay.
+func (h *Handler) decay(ctx context.Context, userID, wheelID int64, options []selection.Option, at time.Time) ([]selection.Option, error) {
+	halfLife, picks, err := h.wheelDecay(ctx, userID, wheelID)
+	if err != nil || halfLife == 0 {
+		return options, err
+	}
+	return selection.Decay(options, picks, at, halfLife), nil
+}
+
+// setEffectiveChances fills in the chance each option has of coming up now that its weight has decayed.
+// The chances are left unset when the wheel's weights do not decay.
+func (h *Handler) setEffectiveChances(ctx context.Context, userID, wheelID int64, options []home.Option) error {
+	halfLife, picks, err := h.wheelDecay(ctx, userID, wheelID)
+	if err != nil || halfLife == 0 {
+		return err
+	}
+
+	candidates := make([]selection.Option, 0, len(options))
+	positions := make(map[int64]int, len(options))
+	for i, opt := range options {
+		id, err := stringToInt64(opt.ID)
+		if err != nil || opt.Weight == 0 {
+			continue
+		}
+		candidates = append(candidates, selection.Option{ID: id, Weight: opt.Weight})
+		positions[id] = i
+	}
+
+	decayed := selection.Decay(candidates, picks, h.now(), halfLife)
+	total := selection.TotalWeight(decayed)
+	for _, opt := range decayed {
+		chance := float64(opt.Weight) / float64(total)
+		options[positions[opt.ID]].EffectiveChance = &chance
+	}
+	return nil
+}
+
+// UpdateWheelDecay handles setting how long options on the active wheel take to recover the weight they lose when
+// picked. A blank or zero half-life turns decay off.
+func (h *Handler) UpdateWheelDecay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to update adaptive weights", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Database.Queries().SetWheelDecay(ctx, queries.SetWheelDecayParams{
+		DecayHalfLifeMinutes: minutesFromForm(r, "half_life", maxDecayHalfLifeMinutes),
+		ID:                   wheel.ID,
+		UserID:               userID,
+	}); err != nil {
+		h.Logger.Error("Failed to update wheel decay", "error", err)
+		http.Error(w, "Failed to update adaptive weights", http.StatusInternalServerError)
+		return
+	}
+
+	appOptions, totalWeight, err := h.wheelOptions(ctx, wheel.ID, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get options", "error", err)
+		http.Error(w, "Failed to get options", http.StatusInternalServerError)
+		return
+	}
+
+	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
+}
@@ -0,0 +1,132 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// setDecay sets the half-life of the active wheel through the manage modal form
+func (e testEnv) setDecay(t *testing.T, amount, unit string) string {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/api/wheels/decay", nil)
+	r.PostForm = url.Values{"half_life": {amount}, "half_life_unit": {unit}}
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	e.handler.UpdateWheelDecay(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+// chances returns the chance each option had on the spin by name
+func chances(result handler.APISpinResult) map[string]float64 {
+	byName := make(map[string]float64, len(result.Eligible))
+	for _, chance := range result.Eligible {
+		byName[chance.Name] = chance.Probability
+	}
+	return byName
+}
+
+func TestSpinDecaysRecentPicks(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 1}`, `{"name": "Museum", "weight": 1}`)
+	e.setDecay(t, "1", "hours")
+
+	status, first := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	picked := first.Option.Name
+	other := map[string]string{"Hike": "Museum", "Museum": "Hike"}[picked]
+	require.InDelta(t, 0.5, chances(first)[picked], 1e-9)
+
+	// The option just picked has next to no weight left
+	status, second := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Less(t, chances(second)[picked], 0.01)
+
+	// Two half-lives after the first pick it has won back three quarters of its weight, and one half-life after
+	// the second that option has won back half of its weight
+	_, err := e.db.DB().Exec(`UPDATE spins SET created_at = datetime('now', '-120 minutes') WHERE option_name = ?`, picked)
+	require.NoError(t, err)
+	_, err = e.db.DB().Exec(`UPDATE spins SET created_at = datetime('now', '-60 minutes') WHERE option_name = ?`, other)
+	require.NoError(t, err)
+	status, third := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.InDelta(t, 0.6, chances(third)[picked], 0.001)
+	require.InDelta(t, 0.4, chances(third)[other], 0.001)
+
+	// Uniform spins ignore weights, decayed or not
+	status, fourth := e.spin(t, `{"strategy": "uniform"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.InDelta(t, 0.5, chances(fourth)[picked], 1e-9)
+}
+
+func TestRerolledPickDoesNotDecay(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 1}`, `{"name": "Museum", "weight": 1}`)
+	e.setDecay(t, "1", "hours")
+
+	status, first := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	status, second := e.reroll(t, first.SpinID)
+	require.Equal(t, http.StatusOK, status)
+
+	// Only the pick that replaced the rerolled one loses weight
+	status, third := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Greater(t, chances(third)[first.Option.Name], 0.99)
+	require.Less(t, chances(third)[second.Option.Name], 0.01)
+}
+
+func TestSpinWithoutDecayKeepsWeights(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 1}`, `{"name": "Museum", "weight": 3}`)
+
+	for range 3 {
+		status, result := e.spin(t, `{}`)
+		require.Equal(t, http.StatusOK, status)
+		require.InDelta(t, 0.25, chances(result)["Hike"], 1e-9)
+		require.InDelta(t, 0.75, chances(result)["Museum"], 1e-9)
+	}
+}
+
+func TestUpdateWheelDecay(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 1}`, `{"name": "Museum", "weight": 1}`, `{"name": "Picnic", "weight": 0}`)
+
+	tests := []struct {
+		name     string
+		amount   string
+		unit     string
+		expected int64
+	}{
+		{name: "hours", amount: "2", unit: "hours", expected: 120},
+		{name: "days", amount: "3", unit: "days", expected: 3 * 24 * 60},
+		{name: "clamped to a year", amount: "1000", unit: "days", expected: 365 * 24 * 60},
+		{name: "blank turns decay off", amount: "", unit: "hours", expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := e.setDecay(t, test.amount, test.unit)
+
+			wheel, err := e.db.Queries().GetActiveWheel(context.Background(), e.userID)
+			require.NoError(t, err)
+			require.Equal(t, test.expected > 0, wheel.DecayHalfLifeMinutes.Valid)
+			require.Equal(t, test.expected, wheel.DecayHalfLifeMinutes.Int64)
+
+			// The options list shows the chance after recent picks only while weights decay
+			if test.expected > 0 {
+				require.Equal(t, 2, strings.Count(body, "now 50.0%"))
+			} else {
+				require.NotContains(t, body, "option-effective-chance")
+			}
+		})
+	}
+}
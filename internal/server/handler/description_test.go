@@ -0,0 +1,158 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/markdown"
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/stretchr/testify/require"
+)
+
+// description returns the stored description of the option with the name on the user's active wheel
+func (e testEnv) description(t *testing.T, name string) string {
+	t.Helper()
+	ctx := context.Background()
+	wheel, err := e.db.Queries().GetActiveWheel(ctx, e.userID)
+	require.NoError(t, err)
+
+	opt, err := e.db.Queries().GetOptionByName(ctx, queries.GetOptionByNameParams{WheelID: wheel.ID, UserID: e.userID, Name: name})
+	require.NoError(t, err)
+	return opt.Bio.String
+}
+
+func TestOptionDescriptionFromAPI(t *testing.T) {
+	e := newTestEnv(t)
+	tooLong := strings.Repeat("a", markdown.MaxLength+1)
+
+	status := e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Cook", "description": "  [Recipe](https://example.com)\r\n1. Boil  "}`)
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, "[Recipe](https://example.com)\n1. Boil", e.description(t, "Cook"))
+	id := e.optionID(t, "Cook")
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/options/"+id, nil)
+	r.SetPathValue("id", id)
+	status, body := e.page(t, e.handler.APIGetOption, r)
+	require.Equal(t, http.StatusOK, status)
+	var opt handler.APIOption
+	require.NoError(t, json.Unmarshal([]byte(body), &opt))
+	require.NotNil(t, opt.Description)
+	require.Equal(t, "[Recipe](https://example.com)\n1. Boil", *opt.Description)
+
+	status = e.serve(t, e.handler.APIUpdateOption, http.MethodPut, "/api/v1/options/"+id, "application/json", `{"name": "Cook", "description": "`+tooLong+`"}`, "id", id)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Equal(t, "[Recipe](https://example.com)\n1. Boil", e.description(t, "Cook"))
+
+	// Replacing an option without a description clears it
+	status = e.serve(t, e.handler.APIUpdateOption, http.MethodPut, "/api/v1/options/"+id, "application/json", `{"name": "Cook"}`, "id", id)
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, e.description(t, "Cook"))
+
+	status = e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Long", "description": "`+tooLong+`"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+}
+
+func TestUpdateOptionDescriptionFromForm(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Cook"}`)
+	id := e.optionID(t, "Cook")
+
+	tests := []struct {
+		name        string
+		description string
+		status      int
+		expected    string
+	}{
+		{name: "saved", description: "Try the **pasta**\n", status: http.StatusOK, expected: "Try the **pasta**"},
+		{name: "too long", description: strings.Repeat("a", markdown.MaxLength+1), status: http.StatusBadRequest, expected: "Try the **pasta**"},
+		{name: "blank clears", description: "  ", status: http.StatusOK, expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := e.postForm(t, e.handler.UpdateOptionDetails, "/api/options/update", url.Values{
+				"id":          {id},
+				"text":        {"Cook"},
+				"weight":      {"1"},
+				"description": {test.description},
+			})
+			require.Equal(t, test.status, status)
+			require.Equal(t, test.expected, e.description(t, "Cook"))
+		})
+	}
+}
+
+func TestPreviewOptionDescription(t *testing.T) {
+	e := newTestEnv(t)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/options/description-preview", nil)
+	r.PostForm = url.Values{"id": {"7"}, "description": {"[Recipe](https://example.com) <script>alert(1)</script>"}}
+	status, body := e.page(t, e.handler.PreviewOptionDescription, r)
+
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `id="description-preview-7"`)
+	require.Contains(t, body, `<a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">Recipe</a>`)
+	require.NotContains(t, body, "<script>")
+}
+
+func TestResultShowsDescription(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Cook", "description": "1. Boil\n2. <b onclick=\"x()\">Eat</b>"}`)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/random", nil)
+	r.PostForm = url.Values{}
+	status, body := e.page(t, e.handler.RandomPicker, r)
+
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `id="result-description"`)
+	require.Contains(t, body, "<li>Boil</li>")
+	require.NotContains(t, body, "onclick")
+}
+
+func TestTransferDescription(t *testing.T) {
+	e := newTestEnv(t)
+	csv := "name,weight,description\nCook,2,\"Steps:\n1. Boil\"\nLong,1," + strings.Repeat("a", markdown.MaxLength+1) + "\n"
+	status := e.serve(t, e.handler.APIImport, http.MethodPost, "/api/v1/import", "text/csv", csv)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string][]string{"Cook": {}}, e.options(t))
+	require.Equal(t, "Steps:\n1. Boil", e.description(t, "Cook"))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/export?format=yaml", nil)
+	status, body := e.page(t, e.handler.APIExport, r)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "description: |-\n")
+	require.Contains(t, body, "1. Boil")
+}
+
+func TestSyncDescription(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Read", "description": "Keep"}`)
+
+	body := `{"options": [
+		{"id": "local-1", "text": "Cook", "weight": 1, "description": " Boil first "},
+		{"id": "local-2", "text": "hike", "weight": 1, "description": "Bring water"},
+		{"id": "local-3", "text": "Read", "weight": 1, "description": "Replace"},
+		{"id": "local-4", "text": "Long", "weight": 1, "description": "` + strings.Repeat("a", markdown.MaxLength+1) + `"}
+	]}`
+	r := httptest.NewRequest(http.MethodPost, "/api/sync-local-options", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	status, resp := e.page(t, e.handler.SyncLocalOptions, r)
+	require.Equal(t, http.StatusOK, status)
+
+	var synced handler.SyncResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &synced))
+	require.Equal(t, 1, synced.Created)
+	require.Equal(t, 2, synced.Merged)
+	require.Equal(t, 1, synced.Skipped)
+
+	require.Equal(t, "Boil first", e.description(t, "Cook"))
+	// Merging fills in a missing description and keeps an existing one
+	require.Equal(t, "Bring water", e.description(t, "Hike"))
+	require.Equal(t, "Keep", e.description(t, "Read"))
+}
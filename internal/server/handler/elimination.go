@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+// roundState is an elimination round along with the options drawn in it, in the order they were drawn
+type roundState struct {
+	Round queries.EliminationRound
+	Picks []queries.GetRoundPicksRow
+}
+
+// drawnIDs returns the IDs of the options drawn in the round that have not been deleted since
+func (s roundState) drawnIDs() []int64 {
+	ids := make([]int64, 0, len(s.Picks))
+	for _, pick := range s.Picks {
+		if pick.OptionID.Valid {
+			ids = append(ids, pick.OptionID.Int64)
+		}
+	}
+	return ids
+}
+
+// drawn returns the picks to list as drawn. The winner of a last one standing round was never knocked out, so it is left off.
+func (s roundState) drawn() []queries.GetRoundPicksRow {
+	if s.Round.Variant != selection.EliminationLastStanding || !s.Round.WinnerOptionID.Valid {
+		return s.Picks
+	}
+	drawn := make([]queries.GetRoundPicksRow, 0, len(s.Picks))
+	for _, pick := range s.Picks {
+		if pick.OptionID != s.Round.WinnerOptionID {
+			drawn = append(drawn, pick)
+		}
+	}
+	return drawn
+}
+
+// toAppRound converts the round for display
+func (s roundState) toAppRound() home.Round {
+	round := home.Round{
+		Variant:  s.Round.Variant,
+		Winner:   s.Round.WinnerName.String,
+		Finished: s.Round.FinishedAt.Valid,
+	}
+	for _, pick := range s.drawn() {
+		round.Drawn = append(round.Drawn, pick.OptionName)
+	}
+	return round
+}
+
+// currentRound returns the user's elimination round on the wheel, or nil if there is none
+func (h *Handler) currentRound(ctx context.Context, userID, wheelID int64) (*roundState, error) {
+	round, err := h.Database.Queries().GetEliminationRound(ctx, queries.GetEliminationRoundParams{
+		UserID:  userID,
+		WheelID: wheelID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil //nolint:nilnil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get elimination round: %w", err)
+	}
+
+	picks, err := h.Database.Queries().GetRoundPicks(ctx, queries.GetRoundPicksParams{
+		RoundID: sql.NullInt64{Int64: round.ID, Valid: true},
+		UserID:  userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get elimination round picks: %w", err)
+	}
+	return &roundState{Round: round, Picks: picks}, nil
+}
+
+// deleteRound throws away the user's elimination round on the wheel. Its spins stay in the history.
+func deleteRound(ctx context.Context, q *queries.Queries, userID, wheelID int64) error {
+	if err := q.DetachRoundSpins(ctx, queries.DetachRoundSpinsParams{UserID: userID, WheelID: wheelID}); err != nil {
+		return fmt.Errorf("failed to detach elimination round spins: %w", err)
+	}
+	if err := q.DeleteEliminationRound(ctx, queries.DeleteEliminationRoundParams{UserID: userID, WheelID: wheelID}); err != nil {
+		return fmt.Errorf("failed to delete elimination round: %w", err)
+	}
+	return nil
+}
+
+// roundForSpin returns the round an elimination spin is part of along with the strategy to draw with.
+// A new round is started when there is none, the last one has finished or the variant has changed.
+func (h *Handler) roundForSpin(ctx context.Context, userID, wheelID int64, variant string) (roundState, selection.Elimination, error) {
+	elimination, err := selection.NewElimination(variant, nil)
+	if err != nil {
+		return roundState{}, selection.Elimination{}, err
+	}
+
+	current, err := h.currentRound(ctx, userID, wheelID)
+	if err != nil {
+		return roundState{}, selection.Elimination{}, err
+	}
+	if current != nil && !current.Round.FinishedAt.Valid && current.Round.Variant == elimination.Variant() {
+		elimination.Drawn = current.drawnIDs()
+		return *current, elimination, nil
+	}
+
+	var round queries.EliminationRound
+	err = h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		if err := deleteRound(ctx, q, userID, wheelID); err != nil {
+			return err
+		}
+		round, err = q.CreateEliminationRound(ctx, queries.CreateEliminationRoundParams{
+			UserID:  userID,
+			WheelID: wheelID,
+			Variant: elimination.Variant(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create elimination round: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return roundState{}, selection.Elimination{}, err
+	}
+	return roundState{Round: round}, elimination, nil
+}
+
+// advanceRound records the option an elimination spin drew on the round and returns the round as it stands after it.
+//
+// A last one standing round is won by the only option left once the others are knocked out. A first pick round is
+// won by the first option drawn and finishes once every option has been drawn.
+func (h *Handler) advanceRound(ctx context.Context, userID int64, state roundState, spin spinResult) (roundState, error) {
+	picked := spin.Picked[0]
+	pickedID, err := stringToInt64(picked.ID)
+	if err != nil {
+		return roundState{}, fmt.Errorf("invalid option ID %q: %w", picked.ID, err)
+	}
+	state.Picks = append(state.Picks, queries.GetRoundPicksRow{
+		OptionID:   sql.NullInt64{Int64: pickedID, Valid: true},
+		OptionName: picked.Text,
+	})
+
+	var left []int64
+	for _, opt := range spin.Eligible {
+		if opt.ID != pickedID {
+			left = append(left, opt.ID)
+		}
+	}
+
+	var setWinner, finished bool
+	var winnerID int64
+	var winnerName string
+	switch state.Round.Variant {
+	case selection.EliminationLastStanding:
+		// The last option left wins. If the option just drawn was the only one left, it wins instead.
+		switch len(left) {
+		case 0:
+			setWinner, winnerID, winnerName = true, pickedID, picked.Text
+		case 1:
+			setWinner, winnerID, winnerName = true, left[0], spin.Names[left[0]]
+		}
+		finished = setWinner
+	default:
+		setWinner, winnerID, winnerName = !state.Round.WinnerOptionID.Valid, pickedID, picked.Text
+		finished = len(left) == 0
+	}
+	if !setWinner && !finished {
+		return state, nil
+	}
+
+	err = h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		if setWinner {
+			if err := q.SetEliminationWinner(ctx, queries.SetEliminationWinnerParams{
+				WinnerOptionID: sql.NullInt64{Int64: winnerID, Valid: true},
+				WinnerName:     sql.NullString{String: winnerName, Valid: true},
+				ID:             state.Round.ID,
+				UserID:         userID,
+			}); err != nil {
+				return fmt.Errorf("failed to set elimination winner: %w", err)
+			}
+			state.Round.WinnerOptionID = sql.NullInt64{Int64: winnerID, Valid: true}
+			state.Round.WinnerName = sql.NullString{String: winnerName, Valid: true}
+		}
+		if finished {
+			finishedAt, err := q.FinishEliminationRound(ctx, queries.FinishEliminationRoundParams{ID: state.Round.ID, UserID: userID})
+			if err != nil {
+				return fmt.Errorf("failed to finish elimination round: %w", err)
+			}
+			state.Round.FinishedAt = finishedAt
+		}
+		return nil
+	})
+	if err != nil {
+		return roundState{}, err
+	}
+	return state, nil
+}
+
+// recordRoundSpin records an elimination spin as part of its round, then advances the round.
+// Unlike other spins the round depends on the spin being saved, so failing to save it is an error.
+func (h *Handler) recordRoundSpin(ctx context.Context, userID, wheelID int64, state roundState, spin spinResult, timeConstraintMinutes *int64, tagFilter selection.TagFilter, target *time.Time) (roundState, recordedSpin, error) {
+	spin.RoundID = state.Round.ID
+	recorded, err := h.recordSpin(ctx, userID, wheelID, spin, selection.StrategyElimination, timeConstraintMinutes, tagFilter, target)
+	if err != nil {
+		return roundState{}, recordedSpin{}, err
+	}
+	state, err = h.advanceRound(ctx, userID, state, spin)
+	if err != nil {
+		return roundState{}, recordedSpin{}, err
+	}
+	return state, recorded, nil
+}
+
+// remainingNames returns the names of the options an elimination spin left in the running, most likely to be drawn first
+func remainingNames(spin spinResult) []string {
+	names := make([]string, 0, len(spin.Eligible))
+	for _, chance := range spin.distribution() {
+		if !chance.Selected {
+			names = append(names, chance.Text)
+		}
+	}
+	return names
+}
+
+// EliminationRound handles showing the state of the elimination round on the active wheel
+func (h *Handler) EliminationRound(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to get elimination round", http.StatusInternalServerError)
+		return
+	}
+
+	state, err := h.currentRound(ctx, userID, wheel.ID)
+	if err != nil {
+		h.Logger.Error("Failed to get elimination round", "error", err)
+		http.Error(w, "Failed to get elimination round", http.StatusInternalServerError)
+		return
+	}
+
+	var round *home.Round
+	if state != nil {
+		appRound := state.toAppRound()
+		round = &appRound
+	}
+	h.html(ctx, w, http.StatusOK, home.EliminationRound(round))
+}
+
+// ResetEliminationRound handles throwing away the elimination round on the active wheel so the next spin starts a new one
+func (h *Handler) ResetEliminationRound(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to reset round", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		return deleteRound(ctx, q, userID, wheel.ID)
+	})
+	if err != nil {
+		h.Logger.Error("Failed to delete elimination round", "error", err)
+		http.Error(w, "Failed to reset round", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", `{"success": "Started a new round"}`)
+	h.html(ctx, w, http.StatusOK, home.EliminationRound(nil))
+}
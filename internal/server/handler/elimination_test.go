@@ -0,0 +1,205 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// round gets the elimination round on the active wheel through the API and decodes it when there is one
+func (e testEnv) round(t *testing.T) (int, handler.APIRound) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/elimination", nil)
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	e.handler.APIGetEliminationRound(w, r)
+
+	var round handler.APIRound
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&round))
+	}
+	return w.Code, round
+}
+
+// createOptions adds options to the active wheel through the API
+func (e testEnv) createOptions(t *testing.T, bodies ...string) {
+	t.Helper()
+	for _, body := range bodies {
+		require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", body))
+	}
+}
+
+func roundNames(picks []handler.APIRoundPick) []string {
+	names := make([]string, len(picks))
+	for i, pick := range picks {
+		names[i] = pick.Name
+	}
+	return names
+}
+
+func TestEliminationLastStanding(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 1}`, `{"name": "Museum", "weight": 2}`, `{"name": "Picnic", "weight": 5}`, `{"name": "Cinema", "weight": 10}`)
+
+	status, _ := e.round(t)
+	require.Equal(t, http.StatusNotFound, status)
+
+	status, result := e.spin(t, `{"strategy": "elimination"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "elimination", result.Strategy)
+	require.NotNil(t, result.Round)
+	require.Equal(t, "last-standing", result.Round.Variant)
+
+	// Heavier options are less likely to be knocked out
+	probabilities := make(map[string]float64, len(result.Eligible))
+	for _, chance := range result.Eligible {
+		probabilities[chance.Name] = chance.Probability
+	}
+	require.Len(t, probabilities, 4)
+	require.Greater(t, probabilities["Hike"], probabilities["Museum"])
+	require.Greater(t, probabilities["Picnic"], probabilities["Cinema"])
+
+	knockedOut := []string{result.Option.Name}
+	for left := 3; left > 1; left-- {
+		status, result = e.spin(t, `{"strategy": "elimination"}`)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, result.Eligible, left, "options already knocked out cannot come up again")
+		require.NotContains(t, knockedOut, result.Option.Name)
+		knockedOut = append(knockedOut, result.Option.Name)
+	}
+
+	// Knocking out all but one option finishes the round with the last one left as the winner
+	require.True(t, result.Round.Finished)
+	require.NotNil(t, result.Round.FinishedAt)
+	require.Equal(t, knockedOut, roundNames(result.Round.Drawn))
+	require.NotNil(t, result.Round.Winner)
+	require.NotContains(t, knockedOut, result.Round.Winner.Name)
+	require.NotNil(t, result.Round.Winner.OptionID)
+
+	status, round := e.round(t)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, *result.Round, round)
+
+	// Every spin of the round is linked to it
+	spins, err := e.db.Queries().GetSpins(context.Background(), queries.GetSpinsParams{WheelID: sql.NullInt64{Int64: e.wheelID(t), Valid: true}, UserID: e.userID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, spins, 3)
+	for _, spin := range spins {
+		require.Equal(t, "elimination", spin.Strategy)
+		require.Equal(t, sql.NullInt64{Int64: round.ID, Valid: true}, spin.RoundID)
+	}
+
+	// The next spin starts a new round with every option back in the running
+	status, result = e.spin(t, `{"strategy": "elimination"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, result.Eligible, 4)
+	require.NotEqual(t, round.ID, result.Round.ID)
+	require.False(t, result.Round.Finished)
+	require.Len(t, result.Round.Drawn, 1)
+	require.Nil(t, result.Round.Winner)
+}
+
+func TestEliminationFirstPick(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`, `{"name": "Picnic"}`)
+
+	status, first := e.spin(t, `{"strategy": "elimination", "elimination_variant": "first-pick"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "first-pick", first.Round.Variant)
+	require.NotNil(t, first.Round.Winner)
+	require.Equal(t, first.Option.Name, first.Round.Winner.Name)
+	require.False(t, first.Round.Finished)
+
+	status, result := e.spin(t, `{"strategy": "elimination", "elimination_variant": "first-pick"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, result.Eligible, 2)
+	require.False(t, result.Round.Finished)
+
+	// Drawing the last option finishes the round, and the first one drawn stays the winner
+	status, result = e.spin(t, `{"strategy": "elimination", "elimination_variant": "first-pick"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, result.Eligible, 1)
+	require.True(t, result.Round.Finished)
+	require.Equal(t, first.Option.Name, result.Round.Winner.Name)
+	require.ElementsMatch(t, []string{"Hike", "Museum", "Picnic"}, roundNames(result.Round.Drawn))
+	require.Equal(t, first.Option.Name, result.Round.Drawn[0].Name)
+}
+
+func TestEliminationVariantChangeStartsNewRound(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`, `{"name": "Picnic"}`)
+
+	status, result := e.spin(t, `{"strategy": "elimination"}`)
+	require.Equal(t, http.StatusOK, status)
+	roundID := result.Round.ID
+
+	status, result = e.spin(t, `{"strategy": "elimination", "elimination_variant": "first-pick"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEqual(t, roundID, result.Round.ID)
+	require.Len(t, result.Eligible, 3)
+	require.Len(t, result.Round.Drawn, 1)
+}
+
+func TestEliminationIgnoresOtherSpins(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`, `{"name": "Picnic"}`)
+
+	status, result := e.spin(t, `{"strategy": "elimination"}`)
+	require.Equal(t, http.StatusOK, status)
+	roundID := result.Round.ID
+
+	status, result = e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, result.Round)
+
+	status, round := e.round(t)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, roundID, round.ID)
+	require.Len(t, round.Drawn, 1)
+}
+
+func TestResetEliminationRound(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`, `{"name": "Picnic"}`)
+
+	status, _ := e.spin(t, `{"strategy": "elimination"}`)
+	require.Equal(t, http.StatusOK, status)
+
+	require.Equal(t, http.StatusNoContent, e.serve(t, e.handler.APIResetEliminationRound, http.MethodDelete, "/api/v1/elimination", "", ""))
+	status, _ = e.round(t)
+	require.Equal(t, http.StatusNotFound, status)
+
+	// Resetting keeps the spins in the history
+	spins, err := e.db.Queries().GetSpins(context.Background(), queries.GetSpinsParams{WheelID: sql.NullInt64{Int64: e.wheelID(t), Valid: true}, UserID: e.userID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, spins, 1)
+	require.False(t, spins[0].RoundID.Valid)
+
+	status, result := e.spin(t, `{"strategy": "elimination"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, result.Eligible, 3)
+}
+
+func TestEliminationInvalidInput(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`)
+
+	for _, body := range []string{
+		`{"strategy": "elimination", "elimination_variant": "sudden-death"}`,
+		`{"strategy": "elimination", "count": 2}`,
+	} {
+		status, _ := e.spin(t, body)
+		require.Equal(t, http.StatusUnprocessableEntity, status, body)
+	}
+
+	status, _ := e.round(t)
+	require.Equal(t, http.StatusNotFound, status, "invalid spins do not start a round")
+}
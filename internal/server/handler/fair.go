@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Piszmog/make-a-decision/internal/components/fairness"
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/fair"
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+// fairSeeds are the seeds a provably fair spin draws with
+type fairSeeds struct {
+	Server string
+	Client string
+}
+
+// serverSeed returns the server seed the user's next provably fair spin will use, making one the first time it is needed.
+// Only requests that change something call it, so reading the commitment never writes.
+func (h *Handler) serverSeed(ctx context.Context, userID int64) (string, error) {
+	seed, err := h.Database.Queries().GetServerSeed(ctx, userID)
+	if err == nil {
+		return seed, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to get server seed: %w", err)
+	}
+
+	seed, err = fair.NewServerSeed()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate server seed: %w", err)
+	}
+	if err := h.Database.Queries().SetServerSeed(ctx, queries.SetServerSeedParams{UserID: userID, Seed: seed}); err != nil {
+		return "", fmt.Errorf("failed to save server seed: %w", err)
+	}
+	return seed, nil
+}
+
+// seedCommitment returns the hash of the server seed the user's next provably fair spin will use, or an empty string
+// if the user has no server seed yet
+func (h *Handler) seedCommitment(ctx context.Context, userID int64) (string, error) {
+	seed, err := h.Database.Queries().GetServerSeed(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get server seed: %w", err)
+	}
+	return fair.Hash(seed), nil
+}
+
+// createSeedCommitment returns the hash of the server seed the user's next provably fair spin will use, making the
+// seed if the user has none yet
+func (h *Handler) createSeedCommitment(ctx context.Context, userID int64) (string, error) {
+	seed, err := h.serverSeed(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return fair.Hash(seed), nil
+}
+
+// fairSpinSeeds returns the seeds for a provably fair spin with the client seed
+func (h *Handler) fairSpinSeeds(ctx context.Context, userID int64, clientSeed string) (fairSeeds, error) {
+	clientSeed, err := fair.CleanClientSeed(clientSeed)
+	if err != nil {
+		return fairSeeds{}, err
+	}
+	serverSeed, err := h.serverSeed(ctx, userID)
+	if err != nil {
+		return fairSeeds{}, err
+	}
+	return fairSeeds{Server: serverSeed, Client: clientSeed}, nil
+}
+
+// snapshot returns the options a spin drew from, in the order it drew from them, with the weights it picked by
+func (s spinResult) snapshot() []fair.Option {
+	options := make([]fair.Option, len(s.Eligible))
+	for i, opt := range s.Eligible {
+		options[i] = fair.Option{ID: opt.ID, Name: s.Names[opt.ID], Weight: opt.Weight}
+	}
+	return options
+}
+
+// revealSeeds stores the proof of a provably fair spin and replaces the server seed it used, so the seed can be
+// revealed without giving away the next one. It runs in the transaction that records the spin's picks and returns
+// the proof along with the commitment for the next spin.
+func revealSeeds(ctx context.Context, q *queries.Queries, userID, wheelID int64, seeds fairSeeds, spin spinResult) (queries.SpinProof, string, error) {
+	options, err := json.Marshal(spin.snapshot())
+	if err != nil {
+		return queries.SpinProof{}, "", fmt.Errorf("failed to encode options: %w", err)
+	}
+	next, err := fair.NewServerSeed()
+	if err != nil {
+		return queries.SpinProof{}, "", fmt.Errorf("failed to generate server seed: %w", err)
+	}
+
+	proof, err := q.CreateSpinProof(ctx, queries.CreateSpinProofParams{
+		UserID:         userID,
+		WheelID:        wheelID,
+		ServerSeed:     seeds.Server,
+		ServerSeedHash: fair.Hash(seeds.Server),
+		ClientSeed:     seeds.Client,
+		Options:        string(options),
+		PickCount:      int64(len(spin.Picked)),
+	})
+	if err != nil {
+		return queries.SpinProof{}, "", fmt.Errorf("failed to create spin proof: %w", err)
+	}
+	if err := q.SetServerSeed(ctx, queries.SetServerSeedParams{UserID: userID, Seed: next}); err != nil {
+		return queries.SpinProof{}, "", fmt.Errorf("failed to replace server seed: %w", err)
+	}
+	return proof, fair.Hash(next), nil
+}
+
+// appProof converts the proof of a provably fair spin for display, or returns nil if the spin was not provably fair
+func (r recordedSpin) appProof() *home.Proof {
+	if r.Proof == nil {
+		return nil
+	}
+	proof := r.Proof
+	return &home.Proof{
+		ID:             strconv.FormatInt(proof.ID, 10),
+		ServerSeed:     proof.ServerSeed,
+		ServerSeedHash: proof.ServerSeedHash,
+		ClientSeed:     proof.ClientSeed,
+	}
+}
+
+// verifySpin replays a provably fair spin from its seeds and option snapshot
+func verifySpin(input fairness.VerifyInput) fairness.VerifyResult {
+	if err := fair.ValidateServerSeed(strings.TrimSpace(input.ServerSeed)); err != nil {
+		return fairness.VerifyResult{Error: err.Error()}
+	}
+	if _, err := fair.CleanClientSeed(input.ClientSeed); err != nil {
+		return fairness.VerifyResult{Error: err.Error()}
+	}
+	if input.Count < 1 || input.Count > selection.MaxPicks {
+		return fairness.VerifyResult{Error: fmt.Sprintf("Picks must be between 1 and %d", selection.MaxPicks)}
+	}
+
+	var options []fair.Option
+	if err := json.Unmarshal([]byte(input.Options), &options); err != nil {
+		return fairness.VerifyResult{Error: "Options must be the JSON list of options the spin drew from"}
+	}
+	if len(options) == 0 {
+		return fairness.VerifyResult{Error: "Options must list at least one option"}
+	}
+	for _, opt := range options {
+		if opt.Weight <= 0 {
+			return fairness.VerifyResult{Error: fmt.Sprintf("Option %q must have a positive weight", opt.Name)}
+		}
+	}
+
+	serverSeed := strings.TrimSpace(input.ServerSeed)
+	result := fairness.VerifyResult{
+		Hash:        fair.Hash(serverSeed),
+		HashMatches: fair.Matches(serverSeed, input.ServerSeedHash),
+	}
+	for _, opt := range fair.Replay(serverSeed, strings.TrimSpace(input.ClientSeed), options, input.Count) {
+		result.Picks = append(result.Picks, opt.Name)
+	}
+	return result
+}
+
+// verifyInputFromForm reads the verification form
+func verifyInputFromForm(r *http.Request) fairness.VerifyInput {
+	count, err := strconv.Atoi(r.FormValue("count"))
+	if err != nil {
+		count = 1
+	}
+	return fairness.VerifyInput{
+		ServerSeed:     r.FormValue("server_seed"),
+		ServerSeedHash: r.FormValue("server_seed_hash"),
+		ClientSeed:     r.FormValue("client_seed"),
+		Options:        r.FormValue("options"),
+		Count:          count,
+	}
+}
+
+// storedProof returns the user's spin proof as verification input along with the options the spin recorded as picked
+func (h *Handler) storedProof(ctx context.Context, userID int64, value string) (fairness.VerifyInput, []string, error) {
+	proofID, err := stringToInt64(value)
+	if err != nil {
+		return fairness.VerifyInput{}, nil, sql.ErrNoRows
+	}
+	proof, err := h.Database.Queries().GetSpinProof(ctx, queries.GetSpinProofParams{ID: proofID, UserID: userID})
+	if err != nil {
+		return fairness.VerifyInput{}, nil, err
+	}
+	picks, err := h.Database.Queries().GetProofPicks(ctx, queries.GetProofPicksParams{
+		ProofID: sql.NullInt64{Int64: proof.ID, Valid: true},
+		UserID:  userID,
+	})
+	if err != nil {
+		return fairness.VerifyInput{}, nil, err
+	}
+
+	recorded := make([]string, len(picks))
+	for i, pick := range picks {
+		recorded[i] = pick.OptionName
+	}
+	input := fairness.VerifyInput{
+		ServerSeed:     proof.ServerSeed,
+		ServerSeedHash: proof.ServerSeedHash,
+		ClientSeed:     proof.ClientSeed,
+		Options:        proof.Options,
+		Count:          int(proof.PickCount),
+	}
+	return input, recorded, nil
+}
+
+// SeedCommitment handles showing the hash of the server seed the next provably fair spin will use
+func (h *Handler) SeedCommitment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	commitment, err := h.seedCommitment(r.Context(), userID)
+	if err != nil {
+		h.Logger.Error("Failed to get server seed commitment", "error", err)
+		http.Error(w, "Failed to get server seed", http.StatusInternalServerError)
+		return
+	}
+	h.html(r.Context(), w, http.StatusOK, home.FairCommitment(commitment))
+}
+
+// CreateSeedCommitment handles making the server seed the next provably fair spin will use, if there is none yet,
+// and showing its hash
+func (h *Handler) CreateSeedCommitment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	commitment, err := h.createSeedCommitment(r.Context(), userID)
+	if err != nil {
+		h.Logger.Error("Failed to get server seed commitment", "error", err)
+		http.Error(w, "Failed to get server seed", http.StatusInternalServerError)
+		return
+	}
+	h.html(r.Context(), w, http.StatusOK, home.FairCommitment(commitment))
+}
+
+// VerifyPage handles the page that replays provably fair spins. Anyone can use it, and signed in users can open
+// one of their own spins by its proof ID to have it filled in and checked against what was recorded.
+func (h *Handler) VerifyPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	input := fairness.VerifyInput{Count: 1}
+	var result *fairness.VerifyResult
+
+	if value := r.URL.Query().Get("proof"); value != "" {
+		userID, ok := utils.GetUserID(r)
+		if !ok {
+			http.Redirect(w, r, "/signin", http.StatusSeeOther)
+			return
+		}
+		stored, recorded, err := h.storedProof(ctx, userID, value)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Spin proof not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			h.Logger.Error("Failed to get spin proof", "error", err)
+			http.Error(w, "Failed to get spin proof", http.StatusInternalServerError)
+			return
+		}
+
+		verified := verifySpin(stored)
+		verified.Recorded = recorded
+		input, result = stored, &verified
+	}
+
+	h.html(ctx, w, http.StatusOK, fairness.Page(utils.GetUserEmail(r), input, result))
+}
+
+// Verify handles replaying a provably fair spin from the verification form
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.html(r.Context(), w, http.StatusOK, fairness.Result(verifySpin(verifyInputFromForm(r))))
+}
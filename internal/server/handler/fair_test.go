@@ -0,0 +1,222 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/fair"
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// failOnSpin makes saving a spin fail, after a provably fair spin stored its proof
+const failOnSpin = `
+CREATE TRIGGER inject_spin_failure BEFORE INSERT ON spins
+BEGIN
+  SELECT RAISE(ABORT, 'injected failure');
+END;`
+
+// commitment gets the hash of the server seed the next provably fair spin will use through the API, making the
+// seed if there is none yet
+func (e testEnv) commitment(t *testing.T) string {
+	t.Helper()
+	hash := e.fetchCommitment(t, http.MethodPost, e.handler.APICreateCommitment)
+	require.NotNil(t, hash)
+	return *hash
+}
+
+// fetchCommitment gets the commitment through the API handler, which is nil when there is no server seed
+func (e testEnv) fetchCommitment(t *testing.T, method string, h http.HandlerFunc) *string {
+	t.Helper()
+	r := httptest.NewRequest(method, "/api/v1/fairness", nil)
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	h(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var commitment handler.APICommitment
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&commitment))
+	return commitment.ServerSeedHash
+}
+
+// hasServerSeed reports whether the user has a server seed
+func (e testEnv) hasServerSeed(t *testing.T) bool {
+	t.Helper()
+	_, err := e.db.Queries().GetServerSeed(context.Background(), e.userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
+
+// page serves a request and returns the status and the rendered body
+func (e testEnv) page(t *testing.T, h http.HandlerFunc, r *http.Request) (int, string) {
+	t.Helper()
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	h(w, r)
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	return w.Code, string(body)
+}
+
+func TestFairSpin(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 1}`, `{"name": "Museum", "weight": 2}`, `{"name": "Picnic", "weight": 5}`, `{"name": "Cinema", "weight": 10}`)
+
+	commitment := e.commitment(t)
+	require.Equal(t, commitment, e.commitment(t), "the commitment holds until a spin reveals it")
+
+	status, result := e.spin(t, `{"fair": true, "client_seed": "giveaway-2026", "count": 2}`)
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, result.Proof)
+
+	// The revealed seed is the one committed to, and a new one is committed to for the next spin
+	require.Equal(t, commitment, result.Proof.ServerSeedHash)
+	require.True(t, fair.Matches(result.Proof.ServerSeed, commitment))
+	require.Equal(t, "giveaway-2026", result.Proof.ClientSeed)
+	require.NotEqual(t, commitment, result.NextServerSeedHash)
+	require.Equal(t, result.NextServerSeedHash, e.commitment(t))
+
+	// Replaying the spin from the proof picks the same options
+	replayed := fair.Replay(result.Proof.ServerSeed, result.Proof.ClientSeed, result.Proof.Options, int(result.Proof.PickCount))
+	require.Len(t, replayed, len(result.Picks))
+	for i, pick := range result.Picks {
+		require.Equal(t, pick.Option.ID, replayed[i].ID)
+	}
+
+	status = e.serve(t, e.handler.APIGetProof, http.MethodGet, "/api/v1/proofs/"+strconv.FormatInt(result.Proof.ID, 10), "", "", "id", strconv.FormatInt(result.Proof.ID, 10))
+	require.Equal(t, http.StatusOK, status)
+
+	spins, err := e.db.Queries().GetSpins(context.Background(), queries.GetSpinsParams{WheelID: sql.NullInt64{Int64: e.wheelID(t), Valid: true}, UserID: e.userID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, spins, 2)
+	for _, spin := range spins {
+		require.Equal(t, sql.NullInt64{Int64: result.Proof.ID, Valid: true}, spin.ProofID)
+	}
+
+	// The verification page replays the stored proof and checks it against the recorded picks
+	status, body := e.page(t, e.handler.VerifyPage, httptest.NewRequest(http.MethodGet, "/verify?proof="+strconv.FormatInt(result.Proof.ID, 10), nil))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "The server seed matches the hash shown before the spin")
+	require.Contains(t, body, "The spin picked the same options")
+}
+
+func TestReadingCommitmentDoesNotMakeSeed(t *testing.T) {
+	e := newTestEnv(t)
+
+	require.Nil(t, e.fetchCommitment(t, http.MethodGet, e.handler.APIGetCommitment))
+	status, body := e.page(t, e.handler.SeedCommitment, httptest.NewRequest(http.MethodGet, "/fairness/commitment", nil))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `hx-post="/fairness/commitment"`)
+	require.NotContains(t, body, "server-seed-hash")
+	require.False(t, e.hasServerSeed(t))
+
+	// Asking to make one makes it once, and reading it after gives the same hash
+	status, body = e.page(t, e.handler.CreateSeedCommitment, httptest.NewRequest(http.MethodPost, "/fairness/commitment", nil))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `id="server-seed-hash"`)
+	require.True(t, e.hasServerSeed(t))
+
+	commitment := e.commitment(t)
+	require.Contains(t, body, commitment)
+	require.Equal(t, &commitment, e.fetchCommitment(t, http.MethodGet, e.handler.APIGetCommitment))
+}
+
+func TestFairSpinMakesMissingSeed(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`)
+
+	status, result := e.spin(t, `{"fair": true}`)
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, result.Proof)
+	require.True(t, fair.Matches(result.Proof.ServerSeed, result.Proof.ServerSeedHash))
+	require.Equal(t, result.NextServerSeedHash, e.commitment(t))
+}
+
+func TestFairSpinIsAllOrNothing(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`)
+	commitment := e.commitment(t)
+
+	_, err := e.db.DB().Exec(failOnSpin)
+	require.NoError(t, err)
+
+	status, _ := e.spin(t, `{"fair": true}`)
+	require.Equal(t, http.StatusInternalServerError, status)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/random", strings.NewReader(url.Values{"fair": {"on"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	status, _ = e.page(t, e.handler.RandomPicker, r)
+	require.Equal(t, http.StatusInternalServerError, status)
+
+	// No proof was kept without its picks and the seed was not revealed
+	var proofs int
+	require.NoError(t, e.db.DB().QueryRow(`SELECT COUNT(*) FROM spin_proofs`).Scan(&proofs))
+	require.Zero(t, proofs)
+	require.Equal(t, commitment, e.commitment(t))
+}
+
+func TestUnfairSpinHasNoProof(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`)
+	commitment := e.commitment(t)
+
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, result.Proof)
+	require.Empty(t, result.NextServerSeedHash)
+	require.Equal(t, commitment, e.commitment(t))
+}
+
+func TestFairSpinClientSeedTooLong(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`)
+
+	status, _ := e.spin(t, `{"fair": true, "client_seed": "`+strings.Repeat("a", fair.MaxClientSeedLength+1)+`"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+}
+
+func TestVerify(t *testing.T) {
+	e := newTestEnv(t)
+	serverSeed, err := fair.NewServerSeed()
+	require.NoError(t, err)
+	options := `[{"id": 1, "name": "Hike", "weight": 1}, {"id": 2, "name": "Cinema", "weight": 3}]`
+	expected := fair.Replay(serverSeed, "lucky", []fair.Option{{ID: 1, Name: "Hike", Weight: 1}, {ID: 2, Name: "Cinema", Weight: 3}}, 1)[0]
+
+	verify := func(values url.Values) string {
+		r := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(values.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		status, body := e.page(t, e.handler.Verify, r)
+		require.Equal(t, http.StatusOK, status)
+		return body
+	}
+
+	body := verify(url.Values{"server_seed": {serverSeed}, "server_seed_hash": {fair.Hash(serverSeed)}, "client_seed": {"lucky"}, "options": {options}, "count": {"1"}})
+	require.Contains(t, body, "The server seed matches")
+	require.Contains(t, body, expected.Name)
+
+	body = verify(url.Values{"server_seed": {serverSeed}, "server_seed_hash": {fair.Hash("swapped")}, "client_seed": {"lucky"}, "options": {options}, "count": {"1"}})
+	require.Contains(t, body, "does not match")
+
+	for _, values := range []url.Values{
+		{"server_seed": {"not a seed"}, "options": {options}, "count": {"1"}},
+		{"server_seed": {serverSeed}, "options": {"Hike, Cinema"}, "count": {"1"}},
+		{"server_seed": {serverSeed}, "options": {`[{"id": 1, "name": "Hike", "weight": 0}]`}, "count": {"1"}},
+		{"server_seed": {serverSeed}, "options": {options}, "count": {"11"}},
+	} {
+		require.Contains(t, verify(values), `id="verify-error"`, values.Encode())
+	}
+}
@@ -2,10 +2,14 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/Piszmog/make-a-decision/internal/db"
+	"github.com/Piszmog/make-a-decision/internal/images"
+	"github.com/Piszmog/make-a-decision/internal/selection"
 	"github.com/a-h/templ"
 )
 
@@ -13,6 +17,30 @@ import (
 type Handler struct {
 	Logger   *slog.Logger
 	Database db.Database
+	// Random is where spins get their randomness from. Defaults to selection.Random.
+	Random selection.Source
+	// Now tells the time. Defaults to time.Now.
+	Now func() time.Time
+	// SpinDelay is how long a spin from the page takes, so the wheel has time to spin. Defaults to no delay.
+	SpinDelay time.Duration
+	// Images is where uploaded option images are stored. Defaults to the database.
+	Images images.Store
+}
+
+// random returns the source spins draw from
+func (h *Handler) random() selection.Source {
+	if h.Random == nil {
+		return selection.Random
+	}
+	return h.Random
+}
+
+// now returns the current time from the handler's clock
+func (h *Handler) now() time.Time {
+	if h.Now == nil {
+		return time.Now()
+	}
+	return h.Now()
 }
 
 //nolint:unparam
@@ -24,3 +52,12 @@ func (h *Handler) html(ctx context.Context, w http.ResponseWriter, status int, t
 		h.Logger.Error("Failed to render component", "error", err)
 	}
 }
+
+// setHXTriggerEvents has htmx fire the events once the response is swapped in. Nothing is set when there are no events.
+func setHXTriggerEvents(w http.ResponseWriter, events map[string]bool) {
+	if len(events) == 0 {
+		return
+	}
+	data, _ := json.Marshal(events) // a map of strings to booleans always encodes
+	w.Header().Set("HX-Trigger", string(data))
+}
@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+const (
+	// maxPickHistory is the number of recent picks handed to the selection strategy. A shuffle bag gets every pick.
+	maxPickHistory = 100
+	// historyPageSize is the number of spins shown per page of the decision log
+	historyPageSize = 10
+)
+
+// pickHistoryLimit returns how many recent picks the strategy is handed, or -1 for all of them.
+// A shuffle bag replays every pick so it knows where the bag was last refilled.
+func pickHistoryLimit(strategy selection.Strategy) int64 {
+	if _, ok := strategy.(selection.ShuffleBag); ok {
+		return -1
+	}
+	return maxPickHistory
+}
+
+// recentPicks returns the option IDs the user kept on the wheel, newest first, as many as the strategy is handed.
+// Rerolled and skipped picks and draws in elimination rounds are left out.
+func (h *Handler) recentPicks(ctx context.Context, userID, wheelID int64, strategy selection.Strategy) ([]int64, error) {
+	ids, err := h.Database.Queries().GetRecentPickedOptionIDs(ctx, queries.GetRecentPickedOptionIDsParams{
+		WheelID: nullWheelID(wheelID),
+		UserID:  userID,
+		// SQLite reads a negative limit as no limit
+		Limit: pickHistoryLimit(strategy),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recent picks: %w", err)
+	}
+
+	picks := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id.Valid {
+			picks = append(picks, id.Int64)
+		}
+	}
+	return picks, nil
+}
+
+// recordedSpin is what recording a spin saved
+type recordedSpin struct {
+	// IDs are the saved picks, in the order they were drawn
+	IDs []int64
+	// Proof is the proof of a provably fair spin, or nil if the spin was not provably fair
+	Proof *queries.SpinProof
+	// NextCommitment is the hash of the server seed that replaced the one a provably fair spin revealed
+	NextCommitment string
+}
+
+// recordSpin saves every option picked by a spin, in the order they were drawn, along with the filters that were active.
+// A reroll also marks the decision it vetoed as rerolled, and a provably fair spin stores its proof and replaces its
+// server seed, all in the same transaction as the picks.
+func (h *Handler) recordSpin(ctx context.Context, userID, wheelID int64, spin spinResult, strategy string, timeConstraintMinutes *int64, tagFilter selection.TagFilter, target *time.Time) (recordedSpin, error) {
+	tags, err := encodeSpinTags(tagFilter.Include)
+	if err != nil {
+		return recordedSpin{}, err
+	}
+	excludeTags, err := encodeSpinTags(tagFilter.Exclude)
+	if err != nil {
+		return recordedSpin{}, err
+	}
+	var includeUntagged int64
+	if tagFilter.IncludeUntagged {
+		includeUntagged = 1
+	}
+
+	var constraint sql.NullInt64
+	if timeConstraintMinutes != nil {
+		constraint = sql.NullInt64{Int64: *timeConstraintMinutes, Valid: true}
+	}
+
+	var targetAt sql.NullTime
+	if target != nil {
+		targetAt = sql.NullTime{Time: target.UTC(), Valid: true}
+	}
+
+	var vetoedSpinID sql.NullInt64
+	var vetoNumber int64
+	if spin.Veto != nil {
+		vetoedSpinID = sql.NullInt64{Int64: spin.Veto.SpinID, Valid: true}
+		vetoNumber = spin.Veto.Number
+	}
+
+	recorded := recordedSpin{IDs: make([]int64, len(spin.Picked))}
+	err = h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		// A reroll logs the veto against the decision it replaces, unless that decision was settled since
+		if spin.Veto != nil {
+			vetoed, err := q.VetoSpin(ctx, queries.VetoSpinParams{ID: spin.Veto.SpinID, UserID: userID})
+			if err != nil {
+				return fmt.Errorf("failed to veto spin: %w", err)
+			}
+			if vetoed == 0 {
+				return errVetoed
+			}
+		}
+
+		// The proof is stored before the seed it used is revealed, and the picks point at it
+		var proofID sql.NullInt64
+		if spin.Seeds != nil {
+			proof, next, err := revealSeeds(ctx, q, userID, wheelID, *spin.Seeds, spin)
+			if err != nil {
+				return err
+			}
+			recorded.Proof, recorded.NextCommitment = &proof, next
+			proofID = sql.NullInt64{Int64: proof.ID, Valid: true}
+		}
+
+		for i, selected := range spin.Picked {
+			optionID, err := stringToInt64(selected.ID)
+			if err != nil {
+				return fmt.Errorf("invalid option ID %q: %w", selected.ID, err)
+			}
+
+			row, err := q.CreateSpin(ctx, queries.CreateSpinParams{
+				UserID:                userID,
+				WheelID:               nullWheelID(wheelID),
+				OptionID:              sql.NullInt64{Int64: optionID, Valid: true},
+				OptionName:            selected.Text,
+				TimeConstraintMinutes: constraint,
+				Tags:                  tags,
+				TagMode:               tagFilter.Mode(),
+				ExcludeTags:           excludeTags,
+				IncludeUntagged:       includeUntagged,
+				TagExpression:         tagFilter.ExpressionSource,
+				Strategy:              strategy,
+				Probability:           spin.probability(optionID),
+				TargetAt:              targetAt,
+				PickPosition:          int64(i + 1),
+				PickCount:             int64(len(spin.Picked)),
+				RoundID:               sql.NullInt64{Int64: spin.RoundID, Valid: spin.RoundID != 0},
+				ProofID:               proofID,
+				VetoedSpinID:          vetoedSpinID,
+				VetoNumber:            vetoNumber,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create spin: %w", err)
+			}
+			recorded.IDs[i] = row.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return recordedSpin{}, err
+	}
+	return recorded, nil
+}
+
+// encodeSpinTags encodes the tags of a spin's tag filter as a JSON array
+func encodeSpinTags(tags []string) (string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	encoded, err := json.Marshal(tags)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tags: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// decodeSpinTags decodes tags saved with encodeSpinTags, logging tags that cannot be read
+func (h *Handler) decodeSpinTags(spinID int64, encoded string) []string {
+	tags := []string{}
+	if err := json.Unmarshal([]byte(encoded), &tags); err != nil {
+		h.Logger.Warn("Failed to decode spin tags", "spin_id", spinID, "error", err)
+	}
+	return tags
+}
+
+// dbSpinToAppSpin converts SQLC queries.Spin to app home.Spin with its times in the user's time zone
+func (h *Handler) dbSpinToAppSpin(dbSpin queries.Spin, loc *time.Location) home.Spin {
+	var constraint *int64
+	if dbSpin.TimeConstraintMinutes.Valid {
+		constraint = &dbSpin.TimeConstraintMinutes.Int64
+	}
+
+	var target *time.Time
+	if dbSpin.TargetAt.Valid {
+		t := dbSpin.TargetAt.Time.In(loc)
+		target = &t
+	}
+
+	var proofID string
+	if dbSpin.ProofID.Valid {
+		proofID = strconv.FormatInt(dbSpin.ProofID.Int64, 10)
+	}
+
+	var completedAt *time.Time
+	if dbSpin.CompletedAt.Valid {
+		t := dbSpin.CompletedAt.Time.In(loc)
+		completedAt = &t
+	}
+
+	return home.Spin{
+		ID:             strconv.FormatInt(dbSpin.ID, 10),
+		Option:         dbSpin.OptionName,
+		Probability:    dbSpin.Probability,
+		TimeConstraint: constraint,
+		Tags:           h.decodeSpinTags(dbSpin.ID, dbSpin.Tags),
+		TagFilter: home.SpinTagFilter{
+			All:             dbSpin.TagMode == selection.TagModeAll,
+			Exclude:         h.decodeSpinTags(dbSpin.ID, dbSpin.ExcludeTags),
+			IncludeUntagged: dbSpin.IncludeUntagged != 0,
+			Expression:      dbSpin.TagExpression,
+		},
+		Strategy:    dbSpin.Strategy,
+		TargetAt:    target,
+		Position:    dbSpin.PickPosition,
+		Count:       dbSpin.PickCount,
+		ProofID:     proofID,
+		Outcome:     dbSpin.Outcome.String,
+		Rating:      nullInt64Ptr(dbSpin.Rating),
+		Note:        dbSpin.Note.String,
+		CompletedAt: completedAt,
+		VetoNumber:  dbSpin.VetoNumber,
+		CreatedAt:   dbSpin.CreatedAt.In(loc),
+	}
+}
+
+// History handles showing a page of the decision log
+func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	page, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to get history", http.StatusInternalServerError)
+		return
+	}
+	wheelID := nullWheelID(wheel.ID)
+
+	total, err := h.Database.Queries().CountSpins(ctx, queries.CountSpinsParams{
+		WheelID: wheelID,
+		UserID:  userID,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to count spins", "error", err)
+		http.Error(w, "Failed to get history", http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := max((total+historyPageSize-1)/historyPageSize, 1)
+	page = min(page, totalPages)
+
+	spins, err := h.Database.Queries().GetSpins(ctx, queries.GetSpinsParams{
+		WheelID: wheelID,
+		UserID:  userID,
+		Limit:   historyPageSize,
+		Offset:  (page - 1) * historyPageSize,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to get spins", "error", err)
+		http.Error(w, "Failed to get history", http.StatusInternalServerError)
+		return
+	}
+
+	loc := h.userLocation(ctx, userID)
+	appSpins := make([]home.Spin, len(spins))
+	for i, spin := range spins {
+		appSpins[i] = h.dbSpinToAppSpin(spin, loc)
+	}
+
+	h.html(ctx, w, http.StatusOK, home.HistoryModal(appSpins, page, totalPages))
+}
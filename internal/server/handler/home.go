@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand/v2"
 	"slices"
 	"strconv"
 	"strings"
@@ -12,14 +14,35 @@ import (
 
 	"net/http"
 
+	"github.com/Piszmog/make-a-decision/internal/availability"
 	"github.com/Piszmog/make-a-decision/internal/components/core"
 	"github.com/Piszmog/make-a-decision/internal/components/home"
 	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/fair"
+	"github.com/Piszmog/make-a-decision/internal/images"
+	"github.com/Piszmog/make-a-decision/internal/markdown"
+	"github.com/Piszmog/make-a-decision/internal/selection"
 	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/Piszmog/make-a-decision/internal/tagexpr"
 )
 
+// maxOptionTags is the most tags an option can have
+const maxOptionTags = 5
+
 // dbOptionToAppOption converts SQLC home.Option to app home.Option
 func (h *Handler) dbOptionToAppOption(ctx context.Context, dbOpt queries.Option, userID int64) home.Option {
+	// Fetch tags for this option
+	tags, err := h.fetchTagsForOption(ctx, dbOpt.ID, userID)
+	if err != nil {
+		// Log error but continue - tags are optional
+		h.Logger.Warn("Failed to fetch tags for option", "option_id", dbOpt.ID, "error", err)
+		tags = []string{}
+	}
+	return optionToAppOption(dbOpt, tags)
+}
+
+// optionToAppOption converts SQLC home.Option with its already loaded tags to app home.Option
+func optionToAppOption(dbOpt queries.Option, tags []string) home.Option {
 	var duration *int64
 	if dbOpt.DurationMinutes != nil {
 		if dur, ok := dbOpt.DurationMinutes.(int64); ok {
@@ -32,21 +55,86 @@ func (h *Handler) dbOptionToAppOption(ctx context.Context, dbOpt queries.Option,
 		weight = dbOpt.Weight.Int64
 	}
 
-	// Fetch tags for this option
-	tags, err := h.fetchTagsForOption(ctx, dbOpt.ID, userID)
-	if err != nil {
-		// Log error but continue - tags are optional
-		h.Logger.Warn("Failed to fetch tags for option", "option_id", dbOpt.ID, "error", err)
-		tags = []string{}
+	var cooldown *int64
+	if dbOpt.CooldownMinutes.Valid {
+		cooldown = &dbOpt.CooldownMinutes.Int64
 	}
 
 	return home.Option{
-		ID:       strconv.FormatInt(dbOpt.ID, 10),
-		Text:     dbOpt.Name,
-		Weight:   weight,
-		Duration: duration,
-		Tags:     tags,
+		ID:           strconv.FormatInt(dbOpt.ID, 10),
+		Text:         dbOpt.Name,
+		Weight:       weight,
+		Duration:     duration,
+		Tags:         tags,
+		Description:  dbOpt.Bio.String,
+		Cooldown:     cooldown,
+		Availability: optionAvailability(dbOpt.Availability),
+		Links:        optionLinks(dbOpt.Links),
+		ImageURL:     optionImageURL(dbOpt.ID, dbOpt.ImageKey, images.Full),
+		ThumbnailURL: optionImageURL(dbOpt.ID, dbOpt.ImageKey, images.Thumbnail),
+	}
+}
+
+// taggedOption is an option along with the names of its tags
+type taggedOption struct {
+	queries.Option
+	Tags []string
+}
+
+// noTagFilter lets every option through
+var noTagFilter = selection.TagFilter{IncludeUntagged: true}
+
+// taggedOptions loads the options on a wheel with their tags in a single query, keeping only those that pass the tag filter
+func (h *Handler) taggedOptions(ctx context.Context, wheelID, userID int64, filter selection.TagFilter) ([]taggedOption, error) {
+	return optionsWithTags(ctx, h.Database.Queries(), wheelID, userID, filter)
+}
+
+// optionsWithTags loads the options on a wheel with their tags using q, which may be bound to a transaction
+func optionsWithTags(ctx context.Context, q *queries.Queries, wheelID, userID int64, filter selection.TagFilter) ([]taggedOption, error) {
+	type row struct {
+		option queries.Option
+		tags   string
+	}
+
+	var rows []row
+	if !filter.Active() {
+		dbRows, err := q.GetOptionsWithTags(ctx, queries.GetOptionsWithTagsParams{
+			WheelID: wheelID,
+			UserID:  userID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get options: %w", err)
+		}
+		rows = make([]row, len(dbRows))
+		for i, r := range dbRows {
+			rows[i] = row{option: r.Option, tags: r.Tags}
+		}
+	} else {
+		dbRows, err := q.GetOptionsFilteredByTags(ctx, queries.GetOptionsFilteredByTagsParams{
+			WheelID:         wheelID,
+			UserID:          userID,
+			IncludeUntagged: filter.IncludeUntagged,
+			ExcludeTags:     filter.Exclude,
+			IncludeTags:     filter.Include,
+			MinMatches:      filter.MinMatches(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get options: %w", err)
+		}
+		rows = make([]row, len(dbRows))
+		for i, r := range dbRows {
+			rows[i] = row{option: r.Option, tags: r.Tags}
+		}
+	}
+
+	options := make([]taggedOption, len(rows))
+	for i, r := range rows {
+		options[i] = taggedOption{Option: r.option, Tags: []string{}}
+		if err := json.Unmarshal([]byte(r.tags), &options[i].Tags); err != nil {
+			return nil, fmt.Errorf("failed to decode tags for option %d: %w", r.option.ID, err)
+		}
 	}
+	return options, nil
 }
 
 // stringToInt64 converts string ID to int64 with error handling
@@ -56,7 +144,12 @@ func stringToInt64(id string) (int64, error) {
 
 // fetchTagsForOption retrieves all tags for a given option
 func (h *Handler) fetchTagsForOption(ctx context.Context, optionID, userID int64) ([]string, error) {
-	tags, err := h.Database.Queries().GetTagsForOption(ctx, queries.GetTagsForOptionParams{
+	return tagsForOption(ctx, h.Database.Queries(), optionID, userID)
+}
+
+// tagsForOption retrieves all tags for a given option using q, which may be bound to a transaction
+func tagsForOption(ctx context.Context, q *queries.Queries, optionID, userID int64) ([]string, error) {
+	tags, err := q.GetTagsForOption(ctx, queries.GetTagsForOptionParams{
 		OptionID: optionID,
 		UserID:   userID,
 	})
@@ -71,11 +164,65 @@ func (h *Handler) fetchTagsForOption(ctx context.Context, optionID, userID int64
 	return tagNames, nil
 }
 
-// setTagsForOption replaces all tags for an option
-func (h *Handler) setTagsForOption(ctx context.Context, optionID, userID int64, tagNames []string) error {
+// clearTagsForOption takes every tag off an option and returns the IDs of the tags it had
+func clearTagsForOption(ctx context.Context, q *queries.Queries, optionID, userID int64) ([]int64, error) {
+	tags, err := q.GetTagsForOption(ctx, queries.GetTagsForOptionParams{OptionID: optionID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	if err := q.ClearTagsForOption(ctx, optionID); err != nil {
+		return nil, fmt.Errorf("failed to clear tags: %w", err)
+	}
+
+	tagIDs := make([]int64, len(tags))
+	for i, tag := range tags {
+		tagIDs[i] = tag.ID
+	}
+	return tagIDs, nil
+}
+
+// deleteOption deletes the option along with its tags, deleting the tags it leaves unused. Foreign keys are not
+// enforced on the connection, so the spins, rounds and synced local options that point at the option are detached
+// here. Otherwise a new option given the same ID would inherit the deleted option's cooldown, decay and history.
+func deleteOption(ctx context.Context, q *queries.Queries, optionID, userID int64) error {
+	tagIDs, err := clearTagsForOption(ctx, q, optionID, userID)
+	if err != nil {
+		return err
+	}
+	id := sql.NullInt64{Int64: optionID, Valid: true}
+	if err := q.DetachOptionSpins(ctx, queries.DetachOptionSpinsParams{OptionID: id, UserID: userID}); err != nil {
+		return fmt.Errorf("failed to detach spins: %w", err)
+	}
+	if err := q.DetachOptionRounds(ctx, queries.DetachOptionRoundsParams{WinnerOptionID: id, UserID: userID}); err != nil {
+		return fmt.Errorf("failed to detach elimination rounds: %w", err)
+	}
+	if err := q.DetachSyncedLocalOption(ctx, queries.DetachSyncedLocalOptionParams{OptionID: id, UserID: userID}); err != nil {
+		return fmt.Errorf("failed to detach synced local option: %w", err)
+	}
+	if err := q.DeleteOption(ctx, queries.DeleteOptionParams{ID: optionID, UserID: userID}); err != nil {
+		return fmt.Errorf("failed to delete option: %w", err)
+	}
+	return deleteUnusedTags(ctx, q, userID, tagIDs)
+}
+
+// deleteUnusedTags deletes the tags that are no longer on any option. Only the tags an option just lost are
+// checked, so tags created on their own through the API are kept until they are used and then dropped.
+func deleteUnusedTags(ctx context.Context, q *queries.Queries, userID int64, tagIDs []int64) error {
+	for _, tagID := range tagIDs {
+		if err := q.DeleteTagIfUnused(ctx, queries.DeleteTagIfUnusedParams{ID: tagID, UserID: userID}); err != nil {
+			return fmt.Errorf("failed to delete unused tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// setTagsForOption replaces all tags for an option on the given wheel, deleting the tags it had that are left unused.
+// Run it with queries bound to the same transaction as the option write so a failure leaves no partial tags.
+func setTagsForOption(ctx context.Context, q *queries.Queries, optionID, wheelID, userID int64, tagNames []string) error {
 	// Clear existing tags
-	if err := h.Database.Queries().ClearTagsForOption(ctx, optionID); err != nil {
-		return fmt.Errorf("failed to clear tags: %w", err)
+	previous, err := clearTagsForOption(ctx, q, optionID, userID)
+	if err != nil {
+		return err
 	}
 
 	// Add new tags
@@ -86,16 +233,17 @@ func (h *Handler) setTagsForOption(ctx context.Context, optionID, userID int64,
 		}
 
 		// Get or create tag
-		tag, err := h.Database.Queries().GetOrCreateTag(ctx, queries.GetOrCreateTagParams{
-			LOWER:  tagName,
-			UserID: userID,
+		tag, err := q.GetOrCreateTag(ctx, queries.GetOrCreateTagParams{
+			LOWER:   tagName,
+			UserID:  userID,
+			WheelID: wheelID,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to get/create tag %q: %w", tagName, err)
 		}
 
 		// Link tag to option
-		if err := h.Database.Queries().AddTagToOption(ctx, queries.AddTagToOptionParams{
+		if err := q.AddTagToOption(ctx, queries.AddTagToOptionParams{
 			OptionID: optionID,
 			TagID:    tag.ID,
 		}); err != nil {
@@ -103,12 +251,31 @@ func (h *Handler) setTagsForOption(ctx context.Context, optionID, userID int64,
 		}
 	}
 
-	// Clean up unused tags
-	if err := h.Database.Queries().DeleteUnusedTags(ctx, userID); err != nil {
-		h.Logger.Warn("Failed to delete unused tags", "error", err)
-	}
+	// Clean up the tags the option no longer has
+	return deleteUnusedTags(ctx, q, userID, previous)
+}
 
-	return nil
+// createOptionWithTags creates an option and its tags in a single transaction
+func (h *Handler) createOptionWithTags(ctx context.Context, params queries.CreateOptionParams, tagNames []string) (queries.Option, error) {
+	var created queries.Option
+	err := h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		var err error
+		if created, err = q.CreateOption(ctx, params); err != nil {
+			return fmt.Errorf("failed to create option: %w", err)
+		}
+		return setTagsForOption(ctx, q, created.ID, params.WheelID, params.UserID, tagNames)
+	})
+	return created, err
+}
+
+// updateOptionWithTags updates an option and replaces its tags in a single transaction
+func (h *Handler) updateOptionWithTags(ctx context.Context, params queries.UpdateOptionParams, wheelID int64, tagNames []string) error {
+	return h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		if err := q.UpdateOption(ctx, params); err != nil {
+			return fmt.Errorf("failed to update option: %w", err)
+		}
+		return setTagsForOption(ctx, q, params.ID, wheelID, params.UserID, tagNames)
+	})
 }
 
 // parseTagsFromForm parses comma-separated tags from form input
@@ -117,40 +284,211 @@ func parseTagsFromForm(input string) []string {
 		return []string{}
 	}
 
-	parts := strings.Split(input, ",")
-	tags := make([]string, 0, len(parts))
+	return normalizeTags(strings.Split(input, ","))
+}
+
+// normalizeTags lowercases and trims tag names, dropping blanks and duplicates, and keeps at most the tags an option can have
+func normalizeTags(names []string) []string {
+	tags := cleanTags(names)
+
+	// Limit to 5 tags
+	if len(tags) > maxOptionTags {
+		tags = tags[:maxOptionTags]
+	}
+
+	return tags
+}
 
-	for _, part := range parts {
-		tag := strings.TrimSpace(strings.ToLower(part))
-		if tag != "" {
+// cleanTags lowercases and trims tag names, dropping blanks and duplicates
+func cleanTags(names []string) []string {
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		tag := strings.TrimSpace(strings.ToLower(name))
+		if tag != "" && !slices.Contains(tags, tag) {
 			tags = append(tags, tag)
 		}
 	}
+	return tags
+}
 
-	// Limit to 5 tags
-	if len(tags) > 5 {
-		tags = tags[:5]
+// tagFilterFromForm reads the tag filter controls of the spin form. Untagged options are included unless turned off.
+func tagFilterFromForm(r *http.Request) (selection.TagFilter, error) {
+	return selection.NewTagFilter(
+		r.FormValue("tag_mode"),
+		cleanTags(r.Form["tags[]"]),
+		cleanTags(r.Form["exclude_tags[]"]),
+		r.FormValue("include_untagged") != "false",
+	)
+}
+
+// timeConstraintFromForm reads the hours and minutes a spin has to fit in. Nil means there is no limit.
+func timeConstraintFromForm(r *http.Request) *int64 {
+	hoursStr := r.FormValue("hours")
+	minutesStr := r.FormValue("minutes")
+	if hoursStr == "" && minutesStr == "" {
+		return nil
 	}
 
-	return tags
+	hours, _ := strconv.ParseInt(hoursStr, 10, 64)
+	minutes, _ := strconv.ParseInt(minutesStr, 10, 64)
+	totalMinutes := (hours * 60) + minutes
+
+	// Only apply constraint if total > 0
+	if totalMinutes <= 0 {
+		return nil
+	}
+	return &totalMinutes
+}
+
+// parseTagExpression parses the tag expression of a spin into the filter. A blank expression is no expression.
+func parseTagExpression(filter *selection.TagFilter, input string) error {
+	source := strings.TrimSpace(input)
+	if source == "" {
+		return nil
+	}
+	expr, err := tagexpr.Parse(input)
+	if err != nil {
+		return err
+	}
+	filter.Expression, filter.ExpressionSource = expr, source
+	return nil
+}
+
+// spinFilters are the strategy a spin picks with and the filters that narrow down what it picks from
+type spinFilters struct {
+	Strategy              selection.Strategy
+	TimeConstraintMinutes *int64
+	TagFilter             selection.TagFilter
+	// At is the time the spin is for, and Target is the same time in the user's time zone when it is not now
+	At     time.Time
+	Target *time.Time
+}
+
+// spinResult is the outcome of a spin along with the options that were eligible for it
+type spinResult struct {
+	// Picked holds the picked options in the order they were drawn
+	Picked   []home.Option
+	Eligible []selection.Option
+	Names    map[int64]string
+	// Probabilities holds the chance each eligible option had of being picked, by option ID
+	Probabilities map[int64]float64
+	// CoolingDown holds the options that passed the filters but were skipped because they were picked too recently
+	CoolingDown []coolingOption
+	// RoundID is the elimination round the spin drew for, or 0 if it was not part of one
+	RoundID int64
+	// Seeds are the seeds of a provably fair spin, or nil if the spin was not provably fair
+	Seeds *fairSeeds
+	// Veto is the decision the spin rerolls, or nil if the spin is not a reroll
+	Veto *veto
+}
+
+// coolingOption is an option that cannot be picked again until AvailableAt
+type coolingOption struct {
+	ID          int64
+	Name        string
+	AvailableAt time.Time
+}
+
+// coolingDown converts the options skipped for their cooldown for display
+func (s spinResult) coolingDown(now time.Time) []home.Cooldown {
+	cooldowns := make([]home.Cooldown, len(s.CoolingDown))
+	for i, opt := range s.CoolingDown {
+		cooldowns[i] = home.Cooldown{Text: opt.Name, Remaining: opt.AvailableAt.Sub(now)}
+	}
+	return cooldowns
+}
+
+// probability returns the chance the option had of being picked on the spin
+func (s spinResult) probability(optionID int64) float64 {
+	return s.Probabilities[optionID]
+}
+
+// picks converts the picked options for display
+func (s spinResult) picks() []home.Pick {
+	picks := make([]home.Pick, len(s.Picked))
+	for i, opt := range s.Picked {
+		id, _ := stringToInt64(opt.ID)
+		picks[i] = home.Pick{
+			Text:         opt.Text,
+			Probability:  s.probability(id),
+			Duration:     opt.Duration,
+			Cooldown:     opt.Cooldown,
+			Description:  opt.Description,
+			Links:        opt.Links,
+			ImageURL:     opt.ImageURL,
+			ThumbnailURL: opt.ThumbnailURL,
+		}
+	}
+	return picks
 }
 
-// selectRandomOption implements weighted random selection from database with optional time constraint and tag filtering
-func (h *Handler) selectRandomOption(ctx context.Context, userID int64, timeConstraintMinutes *int64, selectedTags []string) (home.Option, bool, error) {
-	options, err := h.Database.Queries().GetOptions(ctx, userID)
+// distribution returns the chance of every eligible option, most likely first
+func (s spinResult) distribution() []home.Chance {
+	picked := make(map[string]bool, len(s.Picked))
+	for _, opt := range s.Picked {
+		picked[opt.ID] = true
+	}
+
+	chances := make([]home.Chance, len(s.Eligible))
+	for i, opt := range s.Eligible {
+		chances[i] = home.Chance{
+			Text:        s.Names[opt.ID],
+			Probability: s.probability(opt.ID),
+			Selected:    picked[strconv.FormatInt(opt.ID, 10)],
+		}
+	}
+	slices.SortStableFunc(chances, func(a, b home.Chance) int {
+		return cmp.Compare(b.Probability, a.Probability)
+	})
+	return chances
+}
+
+// spinCandidates are the options a spin can draw from before its strategy has its say
+type spinCandidates struct {
+	// Options holds the options that passed the filters, weighted as they stand after decay
+	Options []selection.Option
+	ByID    map[int64]taggedOption
+	Names   map[int64]string
+	// CoolingDown holds the options that passed the filters but were skipped because they were picked too recently
+	CoolingDown []coolingOption
+}
+
+// spinCandidates returns the options a spin on the wheel can draw from with optional time constraint and tag filtering.
+// Availability windows and cooldowns are checked at the time the spin is for, in the user's time zone.
+// Vetoed options are left out. The bool is true when the wheel has options but none pass the filters.
+func (h *Handler) spinCandidates(ctx context.Context, userID, wheelID int64, strategy selection.Strategy, timeConstraintMinutes *int64, tagFilter selection.TagFilter, at time.Time, vetoed map[int64]bool) (spinCandidates, bool, error) {
+	// Options that do not pass the tag filter are dropped by the query
+	options, err := h.taggedOptions(ctx, wheelID, userID, tagFilter)
 	if err != nil {
-		return home.Option{}, false, err
+		return spinCandidates{}, false, err
 	}
 
 	if len(options) == 0 {
-		return home.Option{ID: "", Text: "No options available", Weight: 1}, false, nil
+		if tagFilter.Active() {
+			return spinCandidates{}, true, nil // no options match the tag filter
+		}
+		return spinCandidates{}, false, nil
 	}
 
-	// Filter options by time constraint and tags if provided
+	cooldowns, err := h.cooldowns(ctx, userID, wheelID, at)
+	if err != nil {
+		return spinCandidates{}, false, err
+	}
+
+	// Filter options by time constraint, tag expression, availability and cooldown if provided
 	//nolint:prealloc
-	var eligibleOptions []queries.Option
+	var eligibleOptions []taggedOption
+	var coolingDown []coolingOption
 	for _, opt := range options {
-		// Time constraint filter
+		// Skip options weighted to never come up, and options vetoed earlier in the round a reroll is for
+		if optionWeight(opt.Option) == 0 || vetoed[opt.ID] {
+			continue
+		}
+
+		if tagFilter.Expression != nil && !tagFilter.Expression.Eval(opt.Tags) {
+			continue
+		}
+
 		// Always include options without a duration (nil duration means flexible)
 		if timeConstraintMinutes != nil && *timeConstraintMinutes > 0 && opt.DurationMinutes != nil {
 			// Include if option duration fits within constraint
@@ -161,33 +499,15 @@ func (h *Handler) selectRandomOption(ctx context.Context, userID int64, timeCons
 			}
 		}
 
-		// Tag filter
-		//nolint:nestif
-		if len(selectedTags) > 0 {
-			// Fetch tags for this option
-			optionTags, err := h.fetchTagsForOption(ctx, opt.ID, userID)
-			if err != nil {
-				h.Logger.Warn("Failed to fetch tags for filtering", "option_id", opt.ID, "error", err)
-				continue
-			}
+		// Skip options not available at the time of the spin
+		if !optionAvailability(opt.Availability).Allows(at) {
+			continue
+		}
 
-			// Include if: no tags OR has at least one matching tag
-			if len(optionTags) > 0 {
-				hasMatch := false
-				for _, selectedTag := range selectedTags {
-					if slices.Contains(optionTags, selectedTag) {
-						hasMatch = true
-						break
-					}
-					if hasMatch {
-						break
-					}
-				}
-				if !hasMatch {
-					continue // Skip if no matching tags
-				}
-			}
-			// home.Options with no tags always pass (len(optionTags) == 0)
+		// Skip options picked within their cooldown
+		if availableAt, ok := cooldowns[opt.ID]; ok {
+			coolingDown = append(coolingDown, coolingOption{ID: opt.ID, Name: opt.Name, AvailableAt: availableAt})
+			continue
 		}
 
 		eligibleOptions = append(eligibleOptions, opt)
@@ -195,38 +515,87 @@ func (h *Handler) selectRandomOption(ctx context.Context, userID int64, timeCons
 
 	// If no eligible options after filtering, return indicator
 	if len(eligibleOptions) == 0 {
-		return home.Option{}, true, nil // true indicates "no options available" due to constraint
+		return spinCandidates{CoolingDown: coolingDown}, true, nil // true indicates "no options available" due to constraint
 	}
 
-	// Weighted selection algorithm
-	var totalWeight int64
-	for _, opt := range eligibleOptions {
-		if opt.Weight.Valid {
-			totalWeight += opt.Weight.Int64
-		} else {
-			totalWeight += 1 // Default weight
+	candidates := spinCandidates{
+		Options:     make([]selection.Option, len(eligibleOptions)),
+		ByID:        make(map[int64]taggedOption, len(eligibleOptions)),
+		Names:       make(map[int64]string, len(eligibleOptions)),
+		CoolingDown: coolingDown,
+	}
+	for i, opt := range eligibleOptions {
+		candidates.Options[i] = selection.Option{ID: opt.ID, Weight: optionWeight(opt.Option)}
+		candidates.ByID[opt.ID] = opt
+		candidates.Names[opt.ID] = opt.Name
+	}
+
+	// Recently picked options come up less often while their weight recovers. Elimination rounds draw every option
+	// once, so they keep their weights.
+	if _, ok := strategy.(selection.Elimination); !ok {
+		if candidates.Options, err = h.decay(ctx, userID, wheelID, candidates.Options, at); err != nil {
+			return spinCandidates{}, false, err
 		}
 	}
+	return candidates, false, nil
+}
 
-	if totalWeight == 0 {
-		return h.dbOptionToAppOption(ctx, eligibleOptions[0], userID), false, nil
+// selectRandomOption picks count distinct options from the database using the selection strategy with optional time constraint and tag filtering.
+// The source decides the picks; provably fair spins pass one fixed by their seeds.
+// Fewer options are picked when not enough are eligible. A result without picks means the wheel has no options.
+// Availability windows and cooldowns are checked at the time the spin is for, in the user's time zone.
+// Vetoed options are left out.
+func (h *Handler) selectRandomOption(ctx context.Context, userID, wheelID int64, strategy selection.Strategy, src selection.Source, timeConstraintMinutes *int64, tagFilter selection.TagFilter, at time.Time, count int, vetoed map[int64]bool) (spinResult, bool, error) {
+	candidates, noOptionsAvailable, err := h.spinCandidates(ctx, userID, wheelID, strategy, timeConstraintMinutes, tagFilter, at, vetoed)
+	if err != nil || noOptionsAvailable || len(candidates.Options) == 0 {
+		return spinResult{CoolingDown: candidates.CoolingDown}, noOptionsAvailable, err
 	}
 
-	//nolint:gosec
-	r := rand.Int64N(totalWeight)
-	var currentWeight int64
-	for _, opt := range eligibleOptions {
-		weight := int64(1)
-		if opt.Weight.Valid {
-			weight = opt.Weight.Int64
-		}
-		currentWeight += weight
-		if r < currentWeight {
-			return h.dbOptionToAppOption(ctx, opt, userID), false, nil
-		}
+	history, err := h.recentPicks(ctx, userID, wheelID, strategy)
+	if err != nil {
+		return spinResult{}, false, err
+	}
+
+	eligible := strategy.Eligible(candidates.Options, history)
+	picked := selection.PickN(src, eligible, count)
+	if len(picked) == 0 {
+		return spinResult{}, true, nil
 	}
 
-	return h.dbOptionToAppOption(ctx, eligibleOptions[0], userID), false, nil
+	selected := make([]home.Option, len(picked))
+	for i, opt := range picked {
+		selected[i] = optionToAppOption(candidates.ByID[opt.ID].Option, candidates.ByID[opt.ID].Tags)
+	}
+	probabilities := make(map[int64]float64, len(eligible))
+	for i, p := range selection.InclusionProbabilities(eligible, count) {
+		probabilities[eligible[i].ID] = p
+	}
+
+	return spinResult{
+		Picked:        selected,
+		Eligible:      eligible,
+		Names:         candidates.Names,
+		Probabilities: probabilities,
+		CoolingDown:   candidates.CoolingDown,
+	}, false, nil
+}
+
+// cooldowns returns when each option on the wheel that is cooling down can be picked again
+func (h *Handler) cooldowns(ctx context.Context, userID, wheelID int64, now time.Time) (map[int64]time.Time, error) {
+	rows, err := h.Database.Queries().GetCooldowns(ctx, queries.GetCooldownsParams{
+		WheelID: wheelID,
+		UserID:  userID,
+		Now:     now.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cooldowns: %w", err)
+	}
+
+	cooldowns := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		cooldowns[row.ID] = time.Unix(row.AvailableAt, 0)
+	}
+	return cooldowns, nil
 }
 
 // AddOption handles adding a new option
@@ -269,50 +638,40 @@ func (h *Handler) AddOption(w http.ResponseWriter, r *http.Request) {
 		durationParam = *duration
 	}
 
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to create option", http.StatusInternalServerError)
+		return
+	}
+
 	createParams := queries.CreateOptionParams{
 		Name:            text,
 		DurationMinutes: durationParam,
 		Weight:          sql.NullInt64{Int64: 1, Valid: true}, // Default weight
 		UserID:          userID,
+		WheelID:         wheel.ID,
 	}
 
-	createdOption, err := h.Database.Queries().CreateOption(ctx, createParams)
-	if err != nil {
+	// Parse tags and create the option with them
+	tagsStr := r.FormValue("tags")
+	tags := parseTagsFromForm(tagsStr)
+	if _, err := h.createOptionWithTags(ctx, createParams, tags); err != nil {
 		h.Logger.Error("Failed to create option", "error", err)
 		http.Error(w, "Failed to create option", http.StatusInternalServerError)
 		return
 	}
 
-	// Parse and set tags
-	tagsStr := r.FormValue("tags")
-	tags := parseTagsFromForm(tagsStr)
-	if len(tags) > 0 {
-		if err := h.setTagsForOption(ctx, createdOption.ID, userID, tags); err != nil {
-			h.Logger.Error("Failed to set tags", "error", err)
-			// Continue - tags are optional
-		}
-	}
-
 	h.Logger.Info("Option created", "text", text, "duration", duration, "tags", tags)
 
 	// Return updated list to refresh display
-	options, err := h.Database.Queries().GetOptions(ctx, userID)
+	appOptions, totalWeight, err := h.activeOptions(ctx, userID)
 	if err != nil {
 		h.Logger.Error("Failed to get options", "error", err)
 		http.Error(w, "Failed to get options", http.StatusInternalServerError)
 		return
 	}
 
-	appOptions := make([]home.Option, len(options))
-	for i, opt := range options {
-		appOptions[i] = h.dbOptionToAppOption(ctx, opt, userID)
-	}
-
-	var totalWeight int64
-	for _, opt := range appOptions {
-		totalWeight += opt.Weight
-	}
-
 	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
 }
 
@@ -323,22 +682,55 @@ func (h *Handler) Home(w http.ResponseWriter, r *http.Request) {
 	// Get user email from context (set by UserContextMiddleware)
 	userEmail := utils.GetUserEmail(r)
 
-	// Fetch all available tags for the filter (only for authenticated users)
-	var allTags []queries.Tag
+	// Fetch the wheels and the available tags for the filter (only for authenticated users)
+	allTags := []queries.Tag{}
+	var wheels []home.Wheel
+	var activeWheel home.Wheel
+	var timeZone string
+	var round *home.Round
+	var commitment string
 	userID, ok := utils.GetUserID(r)
 	if ok {
-		tags, err := h.Database.Queries().GetAllTags(ctx, userID)
+		wheel, err := h.activeWheel(ctx, userID)
+		if err != nil {
+			h.Logger.Error("Failed to get active wheel", "error", err)
+			http.Error(w, "Failed to load wheel", http.StatusInternalServerError)
+			return
+		}
+		activeWheel = dbWheelToAppWheel(wheel)
+
+		wheels, err = h.wheelList(ctx, userID)
+		if err != nil {
+			h.Logger.Warn("Failed to fetch wheels", "error", err)
+		}
+
+		tags, err := h.Database.Queries().GetAllTags(ctx, queries.GetAllTagsParams{
+			WheelID: wheel.ID,
+			UserID:  userID,
+		})
 		if err != nil {
 			h.Logger.Warn("Failed to fetch tags for filter", "error", err)
-			allTags = []queries.Tag{} // Empty slice on error
 		} else {
 			allTags = tags
 		}
-	} else {
-		allTags = []queries.Tag{} // No tags for anonymous users
+
+		timeZone = h.userLocation(ctx, userID).String()
+
+		state, err := h.currentRound(ctx, userID, wheel.ID)
+		if err != nil {
+			h.Logger.Warn("Failed to fetch elimination round", "error", err)
+		} else if state != nil {
+			appRound := state.toAppRound()
+			round = &appRound
+		}
+
+		commitment, err = h.seedCommitment(ctx, userID)
+		if err != nil {
+			h.Logger.Warn("Failed to fetch server seed commitment", "error", err)
+		}
 	}
 
-	h.html(ctx, w, http.StatusOK, core.HTML("Example Site", home.Page(allTags, userEmail), userEmail))
+	h.html(ctx, w, http.StatusOK, core.HTML("Example Site", home.Page(allTags, userEmail, wheels, activeWheel, timeZone, round, commitment), userEmail))
 }
 
 // RandomPicker handles the random activity picker request
@@ -354,20 +746,7 @@ func (h *Handler) RandomPicker(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse time constraint from form
-	hoursStr := r.FormValue("hours")
-	minutesStr := r.FormValue("minutes")
-
-	var timeConstraintMinutes *int64
-	if hoursStr != "" || minutesStr != "" {
-		hours, _ := strconv.ParseInt(hoursStr, 10, 64)
-		minutes, _ := strconv.ParseInt(minutesStr, 10, 64)
-		totalMinutes := (hours * 60) + minutes
-
-		// Only apply constraint if total > 0
-		if totalMinutes > 0 {
-			timeConstraintMinutes = &totalMinutes
-		}
-	}
+	timeConstraintMinutes := timeConstraintFromForm(r)
 
 	// Parse tag filter from form
 	if err := r.ParseForm(); err != nil {
@@ -375,12 +754,111 @@ func (h *Handler) RandomPicker(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
-	selectedTags := r.Form["tags[]"] // Get array of selected tags
+	tagFilter, err := tagFilterFromForm(r)
+	if err != nil {
+		h.Logger.Error("Invalid tag filter mode", "mode", r.FormValue("tag_mode"), "error", err)
+		http.Error(w, "Invalid tag filter mode", http.StatusBadRequest)
+		return
+	}
+	if err := parseTagExpression(&tagFilter, r.FormValue("tag_expression")); err != nil {
+		h.html(r.Context(), w, http.StatusOK, home.InvalidTagExpression(r.FormValue("tag_expression"), err.Error()))
+		return
+	}
+
+	// Parse selection strategy from form
+	noRepeat, _ := strconv.Atoi(r.FormValue("no_repeat"))
+	strategy, err := selection.New(r.FormValue("strategy"), noRepeat)
+	if err != nil {
+		h.Logger.Error("Invalid selection strategy", "strategy", r.FormValue("strategy"), "error", err)
+		http.Error(w, "Invalid selection strategy", http.StatusBadRequest)
+		return
+	}
+
+	// Parse how many options to pick, defaulting to one
+	count, _ := strconv.Atoi(r.FormValue("count"))
+	count = min(max(count, 1), selection.MaxPicks)
+
+	// Parse the time the spin is for, defaulting to now
+	at, target, err := parseSpinTime(r.FormValue("spin_at"), h.userLocation(r.Context(), userID), h.now())
+	if err != nil {
+		h.Logger.Error("Invalid spin time", "spin_at", r.FormValue("spin_at"), "error", err)
+		http.Error(w, "Invalid spin time", http.StatusBadRequest)
+		return
+	}
+
+	wheel, err := h.activeWheel(r.Context(), userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to select option", http.StatusInternalServerError)
+		return
+	}
+
+	// Provably fair spins draw with the committed server seed instead of the handler's random source
+	src := h.random()
+	var seeds *fairSeeds
+	if r.FormValue("fair") != "" {
+		fairSeeds, err := h.fairSpinSeeds(r.Context(), userID, r.FormValue("client_seed"))
+		if errors.Is(err, fair.ErrClientSeedTooLong) {
+			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, "Client seed can be at most 64 characters"))
+			http.Error(w, "Invalid client seed", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			h.Logger.Error("Failed to get server seed", "error", err)
+			http.Error(w, "Failed to select option", http.StatusInternalServerError)
+			return
+		}
+		seeds, src = &fairSeeds, fair.NewSource(fairSeeds.Server, fairSeeds.Client)
+	}
+
+	// Elimination spins draw one option from what is left of the round
+	var round *roundState
+	if strategy.Name() == selection.StrategyElimination {
+		state, elimination, err := h.roundForSpin(r.Context(), userID, wheel.ID, r.FormValue("elimination_variant"))
+		if errors.Is(err, selection.ErrUnknownEliminationVariant) {
+			h.Logger.Error("Invalid elimination variant", "variant", r.FormValue("elimination_variant"), "error", err)
+			http.Error(w, "Invalid elimination variant", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			h.Logger.Error("Failed to get elimination round", "error", err)
+			http.Error(w, "Failed to select option", http.StatusInternalServerError)
+			return
+		}
+		round, strategy, count = &state, elimination, 1
+	}
+
+	// A reroll vetoes the decision it replaces and picks one option, leaving out every option vetoed in the round
+	var vetoed *veto
+	if r.FormValue("veto") != "" {
+		spinID, err := stringToInt64(r.FormValue("veto"))
+		if err != nil {
+			http.Error(w, "Invalid spin ID", http.StatusBadRequest)
+			return
+		}
+		v, err := h.vetoFor(r.Context(), userID, wheel, spinID)
+		if err == nil && round != nil {
+			err = errVetoElimination
+		}
+		if err != nil {
+			h.writeOutcomeError(w, err, "Failed to reroll")
+			return
+		}
+		vetoed, count = &v, 1
+	} else if round == nil {
+		// Spinning again without accepting or skipping the last decision rerolls it
+		v, err := h.pendingVeto(r.Context(), userID, wheel, count)
+		if err != nil {
+			h.writeOutcomeError(w, err, "Failed to select option")
+			return
+		}
+		vetoed = v
+	}
 
 	// Add delay to let spinner show
-	time.Sleep(800 * time.Millisecond)
+	time.Sleep(h.SpinDelay)
 
-	selected, noOptionsAvailable, err := h.selectRandomOption(r.Context(), userID, timeConstraintMinutes, selectedTags)
+	spin, noOptionsAvailable, err := h.selectRandomOption(r.Context(), userID, wheel.ID, strategy, src, timeConstraintMinutes, tagFilter, at, count, vetoed.options())
 	if err != nil {
 		h.Logger.Error("Failed to select random option", "error", err)
 		http.Error(w, "Failed to select option", http.StatusInternalServerError)
@@ -394,35 +872,63 @@ func (h *Handler) RandomPicker(w http.ResponseWriter, r *http.Request) {
 		if timeConstraintMinutes != nil {
 			constraintMinutes = *timeConstraintMinutes
 		}
-		h.html(r.Context(), w, http.StatusOK, home.NoOptionsAvailable(constraintMinutes))
+		h.html(r.Context(), w, http.StatusOK, home.NoOptionsAvailable(constraintMinutes, spin.coolingDown(at)))
 		return
 	}
 
-	// Calculate probability
-	options, err := h.Database.Queries().GetOptions(r.Context(), userID)
-	if err != nil {
-		h.Logger.Error("Failed to get options for probability", "error", err)
-		http.Error(w, "Failed to calculate probability", http.StatusInternalServerError)
+	if len(spin.Picked) == 0 {
+		// No options have been added yet
+		h.html(r.Context(), w, http.StatusOK, home.Result([]home.Pick{{Text: "No options available"}}, 1, nil, nil, nil, nil))
 		return
 	}
 
-	var totalWeight int64
-	var optionWeight int64
-	selectedID, _ := stringToInt64(selected.ID)
+	// A provably fair spin reveals its seed once its proof is stored with the picks
+	spin.Seeds = seeds
+	events := map[string]bool{}
+	if seeds != nil {
+		events["seedRotated"] = true
+	}
 
-	for _, opt := range options {
-		weight := int64(1)
-		if opt.Weight.Valid {
-			weight = opt.Weight.Int64
+	if round != nil {
+		state, recorded, err := h.recordRoundSpin(r.Context(), userID, wheel.ID, *round, spin, timeConstraintMinutes, tagFilter, target)
+		if err != nil {
+			h.Logger.Error("Failed to record elimination spin", "error", err)
+			http.Error(w, "Failed to record spin", http.StatusInternalServerError)
+			return
 		}
-		totalWeight += weight
-		if opt.ID == selectedID {
-			optionWeight = weight
+		events["eliminationChanged"] = true
+		setHXTriggerEvents(w, events)
+		h.html(r.Context(), w, http.StatusOK, home.EliminationResult(spin.picks()[0], state.toAppRound(), remainingNames(spin), spin.distribution(), target, recorded.appProof()))
+		return
+	}
+
+	spin.Veto = vetoed
+	recorded, err := h.recordSpin(r.Context(), userID, wheel.ID, spin, strategy.Name(), timeConstraintMinutes, tagFilter, target)
+	if isConflict(err) {
+		h.writeOutcomeError(w, err, "Failed to reroll")
+		return
+	}
+	if err != nil {
+		// A provably fair spin is checked against the picks it recorded and a reroll has to log its veto, so they
+		// fail without being saved. Otherwise log the error and continue - the decision is still valid.
+		h.Logger.Error("Failed to record spin", "error", err)
+		if seeds != nil || vetoed != nil {
+			http.Error(w, "Failed to record spin", http.StatusInternalServerError)
+			return
 		}
 	}
 
-	probability := float64(optionWeight) / float64(totalWeight)
-	result := home.Result(selected.Text, probability, selected.Duration)
+	// Saved picks can be accepted, skipped or vetoed while the round has vetoes left
+	picks := spin.picks()
+	for i, id := range recorded.IDs {
+		picks[i].SpinID = strconv.FormatInt(id, 10)
+	}
+	if len(picks) == 1 {
+		picks[0].VetoesLeft = vetoesLeft(wheel, vetoed.used())
+	}
+
+	setHXTriggerEvents(w, events)
+	result := home.Result(picks, count, spin.distribution(), spin.coolingDown(at), target, recorded.appProof())
 	h.html(r.Context(), w, http.StatusOK, result)
 }
 
@@ -439,24 +945,28 @@ func (h *Handler) GetOptions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	options, err := h.Database.Queries().GetOptions(ctx, userID)
+	wheel, err := h.activeWheel(ctx, userID)
 	if err != nil {
-		h.Logger.Error("Failed to get options", "error", err)
+		h.Logger.Error("Failed to get active wheel", "error", err)
 		http.Error(w, "Failed to get options", http.StatusInternalServerError)
 		return
 	}
 
-	appOptions := make([]home.Option, len(options))
-	for i, opt := range options {
-		appOptions[i] = h.dbOptionToAppOption(ctx, opt, userID)
+	wheels, err := h.wheelList(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get wheels", "error", err)
+		http.Error(w, "Failed to get options", http.StatusInternalServerError)
+		return
 	}
 
-	var totalWeight int64
-	for _, opt := range appOptions {
-		totalWeight += opt.Weight
+	appOptions, totalWeight, err := h.wheelOptions(ctx, wheel.ID, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get options", "error", err)
+		http.Error(w, "Failed to get options", http.StatusInternalServerError)
+		return
 	}
 
-	h.html(ctx, w, http.StatusOK, home.ManageModal(appOptions, totalWeight))
+	h.html(ctx, w, http.StatusOK, home.ManageModal(wheels, dbWheelToAppWheel(wheel), appOptions, totalWeight))
 }
 
 // UpdateOption handles updating an existing option
@@ -496,6 +1006,9 @@ func (h *Handler) UpdateOption(w http.ResponseWriter, r *http.Request) {
 		Bio:             dbOpt.Bio,
 		DurationMinutes: dbOpt.DurationMinutes,
 		Weight:          dbOpt.Weight,
+		CooldownMinutes: dbOpt.CooldownMinutes,
+		Availability:    dbOpt.Availability,
+		Links:           dbOpt.Links,
 		ID:              id,
 		UserID:          userID,
 	}
@@ -508,23 +1021,13 @@ func (h *Handler) UpdateOption(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return updated options list to refresh all probabilities
-	updatedOptions, err := h.Database.Queries().GetOptions(ctx, userID)
+	appOptions, totalWeight, err := h.activeOptions(ctx, userID)
 	if err != nil {
 		h.Logger.Error("Failed to get updated options", "error", err)
 		http.Error(w, "Failed to refresh options", http.StatusInternalServerError)
 		return
 	}
 
-	appOptions := make([]home.Option, len(updatedOptions))
-	for i, opt := range updatedOptions {
-		appOptions[i] = h.dbOptionToAppOption(ctx, opt, userID)
-	}
-
-	var totalWeight int64
-	for _, opt := range appOptions {
-		totalWeight += opt.Weight
-	}
-
 	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
 }
 
@@ -584,23 +1087,13 @@ func (h *Handler) UpdateDuration(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("HX-Trigger", `{"success": "Duration updated successfully"}`)
 
 	// Return updated options list to refresh all probabilities
-	updatedOptions, err := h.Database.Queries().GetOptions(ctx, userID)
+	appOptions, totalWeight, err := h.activeOptions(ctx, userID)
 	if err != nil {
 		h.Logger.Error("Failed to get updated options", "error", err)
 		http.Error(w, "Failed to refresh options", http.StatusInternalServerError)
 		return
 	}
 
-	appOptions := make([]home.Option, len(updatedOptions))
-	for i, opt := range updatedOptions {
-		appOptions[i] = h.dbOptionToAppOption(ctx, opt, userID)
-	}
-
-	var totalWeight int64
-	for _, opt := range appOptions {
-		totalWeight += opt.Weight
-	}
-
 	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
 }
 
@@ -644,7 +1137,7 @@ func (h *Handler) IncreaseWeight(w http.ResponseWriter, r *http.Request) {
 	if dbOpt.Weight.Valid {
 		currentWeight = dbOpt.Weight.Int64
 	}
-	newWeight := min(currentWeight+1, 10)
+	newWeight := min(currentWeight+1, selection.MaxWeight)
 
 	updateParams := queries.UpdateWeightParams{
 		Weight: sql.NullInt64{Int64: newWeight, Valid: true},
@@ -660,23 +1153,13 @@ func (h *Handler) IncreaseWeight(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return updated options list to refresh all probabilities
-	updatedOptions, err := h.Database.Queries().GetOptions(ctx, userID)
+	appOptions, totalWeight, err := h.activeOptions(ctx, userID)
 	if err != nil {
 		h.Logger.Error("Failed to get updated options", "error", err)
 		http.Error(w, "Failed to refresh options", http.StatusInternalServerError)
 		return
 	}
 
-	appOptions := make([]home.Option, len(updatedOptions))
-	for i, opt := range updatedOptions {
-		appOptions[i] = h.dbOptionToAppOption(ctx, opt, userID)
-	}
-
-	var totalWeight int64
-	for _, opt := range appOptions {
-		totalWeight += opt.Weight
-	}
-
 	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
 }
 
@@ -720,7 +1203,7 @@ func (h *Handler) DecreaseWeight(w http.ResponseWriter, r *http.Request) {
 	if dbOpt.Weight.Valid {
 		currentWeight = dbOpt.Weight.Int64
 	}
-	newWeight := max(currentWeight-1, 1)
+	newWeight := max(currentWeight-1, 0)
 
 	updateParams := queries.UpdateWeightParams{
 		Weight: sql.NullInt64{Int64: newWeight, Valid: true},
@@ -736,23 +1219,13 @@ func (h *Handler) DecreaseWeight(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return updated options list to refresh all probabilities
-	updatedOptions, err := h.Database.Queries().GetOptions(ctx, userID)
+	appOptions, totalWeight, err := h.activeOptions(ctx, userID)
 	if err != nil {
 		h.Logger.Error("Failed to get updated options", "error", err)
 		http.Error(w, "Failed to refresh options", http.StatusInternalServerError)
 		return
 	}
 
-	appOptions := make([]home.Option, len(updatedOptions))
-	for i, opt := range updatedOptions {
-		appOptions[i] = h.dbOptionToAppOption(ctx, opt, userID)
-	}
-
-	var totalWeight int64
-	for _, opt := range appOptions {
-		totalWeight += opt.Weight
-	}
-
 	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
 }
 
@@ -781,34 +1254,36 @@ func (h *Handler) DeleteOption(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.Database.Queries().DeleteOption(ctx, queries.DeleteOptionParams{
+	// Look up the option first so its tags and image can be removed along with it
+	dbOpt, err := h.Database.Queries().GetOption(ctx, queries.GetOptionParams{
 		ID:     intID,
 		UserID: userID,
 	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		h.Logger.Error("Failed to get option", "error", err)
+		http.Error(w, "Failed to delete option", http.StatusInternalServerError)
+		return
+	}
+
+	// Take the option's tags off along with it. An option that was not found has no ID and so nothing to delete.
+	err = h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		return deleteOption(ctx, q, dbOpt.ID, userID)
+	})
 	if err != nil {
 		h.Logger.Error("Failed to delete option", "error", err)
 		http.Error(w, "Failed to delete option", http.StatusInternalServerError)
 		return
 	}
+	h.releaseImages(ctx, dbOpt.ImageKey)
 
 	// Return updated options list to refresh all probabilities
-	updatedOptions, err := h.Database.Queries().GetOptions(ctx, userID)
+	appOptions, totalWeight, err := h.activeOptions(ctx, userID)
 	if err != nil {
 		h.Logger.Error("Failed to get updated options", "error", err)
 		http.Error(w, "Failed to refresh options", http.StatusInternalServerError)
 		return
 	}
 
-	appOptions := make([]home.Option, len(updatedOptions))
-	for i, opt := range updatedOptions {
-		appOptions[i] = h.dbOptionToAppOption(ctx, opt, userID)
-	}
-
-	var totalWeight int64
-	for _, opt := range appOptions {
-		totalWeight += opt.Weight
-	}
-
 	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
 }
 
@@ -850,22 +1325,13 @@ func (h *Handler) ExpandOption(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get all options to calculate total weight
-	options, err := h.Database.Queries().GetOptions(ctx, userID)
+	_, totalWeight, err := h.activeOptions(ctx, userID)
 	if err != nil {
 		h.Logger.Error("Failed to get options", "error", err)
 		http.Error(w, "Failed to get options", http.StatusInternalServerError)
 		return
 	}
 
-	var totalWeight int64
-	for _, opt := range options {
-		weight := int64(1)
-		if opt.Weight.Valid {
-			weight = opt.Weight.Int64
-		}
-		totalWeight += weight
-	}
-
 	appOption := h.dbOptionToAppOption(ctx, dbOpt, userID)
 	h.html(ctx, w, http.StatusOK, home.ExpandedOptionRow(appOption, totalWeight))
 }
@@ -908,27 +1374,18 @@ func (h *Handler) CollapseOption(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get all options to calculate total weight
-	options, err := h.Database.Queries().GetOptions(ctx, userID)
+	_, totalWeight, err := h.activeOptions(ctx, userID)
 	if err != nil {
 		h.Logger.Error("Failed to get options", "error", err)
 		http.Error(w, "Failed to get options", http.StatusInternalServerError)
 		return
 	}
 
-	var totalWeight int64
-	for _, opt := range options {
-		weight := int64(1)
-		if opt.Weight.Valid {
-			weight = opt.Weight.Int64
-		}
-		totalWeight += weight
-	}
-
 	appOption := h.dbOptionToAppOption(ctx, dbOpt, userID)
 	h.html(ctx, w, http.StatusOK, home.OptionRow(appOption, totalWeight))
 }
 
-// UpdateOptionDetails handles updating both duration and weight
+// UpdateOptionDetails handles updating the details of an option from its edit form
 func (h *Handler) UpdateOptionDetails(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -985,16 +1442,16 @@ func (h *Handler) UpdateOptionDetails(w http.ResponseWriter, r *http.Request) {
 		minutes = 59
 	}
 
-	// Parse and clamp weight (1-10)
+	// Parse and clamp weight (0-100)
 	weight, err := strconv.ParseInt(weightStr, 10, 64)
 	if err != nil {
 		weight = 1
 	}
-	if weight < 1 {
-		weight = 1
+	if weight < 0 {
+		weight = 0
 	}
-	if weight > 10 {
-		weight = 10
+	if weight > selection.MaxWeight {
+		weight = selection.MaxWeight
 	}
 
 	// Calculate total minutes
@@ -1006,6 +1463,41 @@ func (h *Handler) UpdateOptionDetails(w http.ResponseWriter, r *http.Request) {
 		duration = nil
 	}
 
+	description, err := markdown.Normalize(r.FormValue("description"))
+	if err != nil {
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, "Invalid description: "+err.Error()))
+		http.Error(w, "Invalid description: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	links, err := normalizeLinks(linksFromForm(r.FormValue("links")))
+	if err != nil {
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, "Invalid links: "+err.Error()))
+		http.Error(w, "Invalid links: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	storedLinks, err := encodeLinks(links)
+	if err != nil {
+		h.Logger.Error("Failed to encode links", "error", err)
+		http.Error(w, "Failed to update option", http.StatusInternalServerError)
+		return
+	}
+
+	cooldown := cooldownFromForm(r)
+
+	rules, err := availability.Parse(r.FormValue("availability"))
+	if err != nil {
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, "Invalid availability: "+err.Error()))
+		http.Error(w, "Invalid availability: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	storedAvailability, err := encodeAvailability(rules)
+	if err != nil {
+		h.Logger.Error("Failed to encode availability", "error", err)
+		http.Error(w, "Failed to update option", http.StatusInternalServerError)
+		return
+	}
+
 	// Get current option to preserve other fields
 	dbOpt, err := h.Database.Queries().GetOption(ctx, queries.GetOptionParams{
 		ID:     id,
@@ -1016,53 +1508,92 @@ func (h *Handler) UpdateOptionDetails(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update option with name, duration, and weight
+	// Update option with name, description, duration, weight, and cooldown
 	updateParams := queries.UpdateOptionParams{
 		Name:            textStr,
-		Bio:             dbOpt.Bio,
+		Bio:             sql.NullString{String: description, Valid: description != ""},
 		DurationMinutes: duration,
 		Weight:          sql.NullInt64{Int64: weight, Valid: true},
+		CooldownMinutes: cooldown,
+		Availability:    storedAvailability,
+		Links:           storedLinks,
 		ID:              id,
 		UserID:          userID,
 	}
 
-	err = h.Database.Queries().UpdateOption(ctx, updateParams)
-	if err != nil {
-		h.Logger.Error("Failed to update option", "error", err)
-		http.Error(w, "Failed to update option", http.StatusInternalServerError)
-		return
-	}
-
-	// Parse and set tags
+	// Parse tags and update the option along with them
 	tagsStr := r.FormValue("tags")
 	tags := parseTagsFromForm(tagsStr)
-	if err := h.setTagsForOption(ctx, id, userID, tags); err != nil {
-		h.Logger.Error("Failed to update tags", "error", err)
-		http.Error(w, "Failed to update tags", http.StatusInternalServerError)
+	if err := h.updateOptionWithTags(ctx, updateParams, dbOpt.WheelID, tags); err != nil {
+		h.Logger.Error("Failed to update option", "error", err)
+		http.Error(w, "Failed to update option", http.StatusInternalServerError)
 		return
 	}
 
-	h.Logger.Info("Option updated", "id", id, "name", textStr, "duration", totalMinutes, "weight", weight, "tags", tags)
+	h.Logger.Info("Option updated", "id", id, "name", textStr, "duration", totalMinutes, "weight", weight, "cooldown", cooldown.Int64, "availability", rules.String(), "tags", tags, "links", len(links))
 
 	// Return full options list to refresh all probabilities
-	options, err := h.Database.Queries().GetOptions(ctx, userID)
+	appOptions, totalWeight, err := h.activeOptions(ctx, userID)
 	if err != nil {
 		h.Logger.Error("Failed to get options", "error", err)
 		http.Error(w, "Failed to refresh options", http.StatusInternalServerError)
 		return
 	}
 
-	appOptions := make([]home.Option, len(options))
-	for i, opt := range options {
-		appOptions[i] = h.dbOptionToAppOption(ctx, opt, userID)
+	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
+}
+
+// PreviewOptionDescription handles rendering the description typed into an option's edit form
+func (h *Handler) PreviewOptionDescription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	var totalWeight int64
-	for _, opt := range appOptions {
-		totalWeight += opt.Weight
+	if _, ok := utils.RequireAuth(w, r); !ok {
+		return
 	}
 
-	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
+	id, err := stringToInt64(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid option ID", http.StatusBadRequest)
+		return
+	}
+
+	description, err := markdown.Normalize(r.FormValue("description"))
+	if err != nil {
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, "Invalid description: "+err.Error()))
+		http.Error(w, "Invalid description: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.html(r.Context(), w, http.StatusOK, home.DescriptionPreview(strconv.FormatInt(id, 10), description))
+}
+
+// cooldownFromForm reads the cooldown amount and unit from the edit form, clamped to at most a year.
+// A blank or zero amount means the option has no cooldown.
+func cooldownFromForm(r *http.Request) sql.NullInt64 {
+	return minutesFromForm(r, "cooldown", maxCooldownMinutes)
+}
+
+// minutesFromForm reads a length of time from the form as an amount in the field and a unit in the field with
+// a _unit suffix, capped at maxMinutes. No amount, or zero, is no time at all.
+func minutesFromForm(r *http.Request, field string, maxMinutes int64) sql.NullInt64 {
+	amount, err := strconv.ParseInt(r.FormValue(field), 10, 64)
+	if err != nil || amount <= 0 {
+		return sql.NullInt64{}
+	}
+
+	var minutes int64
+	switch r.FormValue(field + "_unit") {
+	case "minutes":
+		minutes = amount
+	case "hours":
+		minutes = min(amount, maxMinutes/60) * 60
+	default:
+		minutes = min(amount, maxMinutes/(24*60)) * 24 * 60
+	}
+	return sql.NullInt64{Int64: min(minutes, maxMinutes), Valid: true}
 }
 
 // CloseModal handles closing the management modal
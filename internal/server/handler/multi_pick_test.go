@@ -0,0 +1,104 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpinPicksDistinctOptions(t *testing.T) {
+	e := newTestEnv(t)
+	for _, body := range []string{
+		`{"name": "Hike", "weight": 1}`,
+		`{"name": "Museum", "weight": 2}`,
+		`{"name": "Picnic", "weight": 5}`,
+		`{"name": "Cinema", "weight": 10}`,
+	} {
+		require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", body))
+	}
+
+	status, result := e.spin(t, `{"count": 3}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 3, result.Count)
+	require.Len(t, result.Picks, 3)
+
+	probabilities := make(map[string]float64, len(result.Eligible))
+	var total float64
+	for _, chance := range result.Eligible {
+		probabilities[chance.Name] = chance.Probability
+		total += chance.Probability
+	}
+	require.InDelta(t, 3, total, 1e-6, "inclusion probabilities add up to the number of picks")
+	require.Greater(t, probabilities["Cinema"], probabilities["Picnic"])
+	require.Greater(t, probabilities["Museum"], probabilities["Hike"])
+
+	seen := make(map[int64]bool, len(result.Picks))
+	for i, pick := range result.Picks {
+		require.Equal(t, int64(i+1), pick.Position)
+		require.False(t, seen[pick.Option.ID], "%s picked twice", pick.Option.Name)
+		seen[pick.Option.ID] = true
+		require.Equal(t, probabilities[pick.Option.Name], pick.Probability)
+	}
+	require.Equal(t, result.Picks[0].SpinID, result.SpinID)
+	require.Equal(t, result.Picks[0].Option.ID, result.Option.ID)
+
+	// Every pick is recorded in the order it was drawn
+	spins, err := e.db.Queries().GetSpins(context.Background(), queries.GetSpinsParams{WheelID: sql.NullInt64{Int64: e.wheelID(t), Valid: true}, UserID: e.userID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, spins, 3)
+	for i, spin := range spins {
+		pick := result.Picks[len(result.Picks)-1-i]
+		require.Equal(t, pick.SpinID, spin.ID)
+		require.Equal(t, pick.Option.ID, spin.OptionID.Int64)
+		require.Equal(t, pick.Position, spin.PickPosition)
+		require.Equal(t, int64(3), spin.PickCount)
+		require.Equal(t, pick.Probability, spin.Probability)
+	}
+}
+
+func TestSpinPicksFewerWhenNotEnoughEligible(t *testing.T) {
+	e := newTestEnv(t)
+	for _, body := range []string{`{"name": "Hike"}`, `{"name": "Cinema", "weight": 10}`} {
+		require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", body))
+	}
+
+	status, result := e.spin(t, `{"count": 5}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 5, result.Count)
+	require.Len(t, result.Picks, 2)
+	for _, pick := range result.Picks {
+		require.InDelta(t, 1, pick.Probability, 1e-9)
+	}
+}
+
+func TestSpinSinglePickByDefault(t *testing.T) {
+	e := newTestEnv(t)
+	for _, body := range []string{`{"name": "Hike", "weight": 1}`, `{"name": "Cinema", "weight": 3}`} {
+		require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", body))
+	}
+
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, result.Count)
+	require.Len(t, result.Picks, 1)
+
+	expected := 0.25
+	if result.Option.Name == "Cinema" {
+		expected = 0.75
+	}
+	require.InDelta(t, expected, result.Probability, 1e-9)
+}
+
+func TestSpinInvalidCount(t *testing.T) {
+	e := newTestEnv(t)
+	require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Hike"}`))
+
+	for _, body := range []string{`{"count": -1}`, `{"count": 11}`} {
+		status, _ := e.spin(t, body)
+		require.Equal(t, http.StatusUnprocessableEntity, status, body)
+	}
+}
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/Piszmog/make-a-decision/internal/components/odds"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/Piszmog/make-a-decision/internal/stats"
+)
+
+// defaultSimulationSpins is how many spins a simulation makes unless asked for another number
+const defaultSimulationSpins = 10000
+
+// errNoOptionsMatch is returned when no option passes the filters of a simulation
+var errNoOptionsMatch = errors.New("no options match the filters")
+
+// oddsRow is how often an option came up against the chance it had of coming up
+type oddsRow struct {
+	ID          int64
+	Name        string
+	Probability float64
+	Observed    int64
+}
+
+// oddsReport compares how often options came up with the chances they had
+type oddsReport struct {
+	Total int64
+	Rows  []oddsRow
+	stats.ChiSquareResult
+}
+
+// newOddsReport tests the rows with a chi-square goodness of fit test
+func newOddsReport(rows []oddsRow) oddsReport {
+	categories := make([]stats.Category, len(rows))
+	var total int64
+	for i, row := range rows {
+		categories[i] = stats.Category{Observed: row.Observed, Probability: row.Probability}
+		total += row.Observed
+	}
+	return oddsReport{Total: total, Rows: rows, ChiSquareResult: stats.ChiSquare(categories)}
+}
+
+// toAppReport converts the report for display
+func (r oddsReport) toAppReport() odds.Report {
+	rows := make([]odds.Odds, len(r.Rows))
+	for i, row := range r.Rows {
+		rows[i] = odds.Odds{Name: row.Name, Probability: row.Probability, Observed: row.Observed}
+	}
+	return odds.Report{
+		Total:            r.Total,
+		Odds:             rows,
+		ChiSquare:        r.Statistic,
+		DegreesOfFreedom: r.DegreesOfFreedom,
+		PValue:           r.PValue,
+	}
+}
+
+// simulateSpins spins the wheel the given number of times with the filters without recording anything, counting how
+// often each option comes up. Every simulated spin picks a single option and is added to the history the next one
+// sees, so shuffle bag and no-repeat spins follow on from each other the way real ones do. Decay and cooldowns are
+// taken as they stand at the time of the spin, and each elimination spin is the first draw of a new round.
+// An option's probability is the chance it had on the average simulated spin.
+func (h *Handler) simulateSpins(ctx context.Context, userID, wheelID int64, filters spinFilters, spins int) (oddsReport, error) {
+	candidates, noOptionsAvailable, err := h.spinCandidates(ctx, userID, wheelID, filters.Strategy, filters.TimeConstraintMinutes, filters.TagFilter, filters.At, nil)
+	if err != nil {
+		return oddsReport{}, err
+	}
+	if noOptionsAvailable || len(candidates.Options) == 0 {
+		return oddsReport{}, errNoOptionsMatch
+	}
+	history, err := h.recentPicks(ctx, userID, wheelID, filters.Strategy)
+	if err != nil {
+		return oddsReport{}, err
+	}
+
+	src := h.random()
+	counts := make(map[int64]int64, len(candidates.Options))
+	chances := make(map[int64]float64, len(candidates.Options))
+	for range spins {
+		eligible := filters.Strategy.Eligible(candidates.Options, history)
+		opt, ok := selection.Pick(src, eligible)
+		if !ok {
+			break
+		}
+		total := float64(selection.TotalWeight(eligible))
+		for _, e := range eligible {
+			chances[e.ID] += float64(e.Weight) / total
+		}
+		counts[opt.ID]++
+		history = slices.Insert(history, 0, opt.ID)
+		if _, ok := filters.Strategy.(selection.ShuffleBag); ok && len(eligible) == 1 {
+			// The pick emptied the bag, so the picks before it no longer count
+			history = history[:0]
+		} else if limit := pickHistoryLimit(filters.Strategy); limit >= 0 {
+			history = history[:min(int64(len(history)), limit)]
+		}
+	}
+
+	rows := make([]oddsRow, len(candidates.Options))
+	for i, opt := range candidates.Options {
+		rows[i] = oddsRow{ID: opt.ID, Name: candidates.Names[opt.ID], Probability: chances[opt.ID] / float64(spins), Observed: counts[opt.ID]}
+	}
+	return newOddsReport(rows), nil
+}
+
+// optionWeight returns the weight of the option, which is 1 when it has none
+func optionWeight(opt queries.Option) int64 {
+	if !opt.Weight.Valid {
+		return 1
+	}
+	return opt.Weight.Int64
+}
+
+// historyTolerance is how far the chance recorded for a pick can be from the chance its option has now and still
+// count as the same
+const historyTolerance = 1e-9
+
+// spinHistoryOdds compares the weighted spins on the wheel that drew by the options' current weights with those
+// weights. Only spins that picked one option without filters and were not rerolls are counted, and only when the
+// chance they recorded for their pick is the chance the option has now. Spins made before a weight changed, or
+// while decay or cooldowns moved the odds, drew by other odds and are left out.
+func (h *Handler) spinHistoryOdds(ctx context.Context, userID, wheelID int64) (oddsReport, error) {
+	options, err := h.Database.Queries().GetOptions(ctx, queries.GetOptionsParams{WheelID: wheelID, UserID: userID})
+	if err != nil {
+		return oddsReport{}, fmt.Errorf("failed to get options: %w", err)
+	}
+	picks, err := h.Database.Queries().GetUnfilteredPicks(ctx, queries.GetUnfilteredPicksParams{
+		WheelID:  nullWheelID(wheelID),
+		UserID:   userID,
+		Strategy: selection.StrategyWeighted,
+	})
+	if err != nil {
+		return oddsReport{}, fmt.Errorf("failed to get picks: %w", err)
+	}
+
+	var total int64
+	for _, opt := range options {
+		total += optionWeight(opt)
+	}
+	probabilities := make(map[int64]float64, len(options))
+	for _, opt := range options {
+		if total > 0 {
+			probabilities[opt.ID] = float64(optionWeight(opt)) / float64(total)
+		}
+	}
+
+	counts := make(map[int64]int64, len(options))
+	for _, pick := range picks {
+		probability, ok := probabilities[pick.OptionID.Int64]
+		if ok && probability > 0 && math.Abs(pick.Probability-probability) < historyTolerance {
+			counts[pick.OptionID.Int64]++
+		}
+	}
+
+	rows := make([]oddsRow, len(options))
+	for i, opt := range options {
+		rows[i] = oddsRow{ID: opt.ID, Name: opt.Name, Probability: probabilities[opt.ID], Observed: counts[opt.ID]}
+	}
+	return newOddsReport(rows), nil
+}
+
+// spinFiltersFromForm reads the strategy and filters of a spin from the spin form
+func (h *Handler) spinFiltersFromForm(r *http.Request, userID int64) (spinFilters, error) {
+	if err := r.ParseForm(); err != nil {
+		return spinFilters{}, errors.New("the filters could not be read")
+	}
+
+	noRepeat, _ := strconv.Atoi(r.FormValue("no_repeat"))
+	strategy, err := selection.New(r.FormValue("strategy"), noRepeat)
+	if err != nil {
+		return spinFilters{}, fmt.Errorf("unknown strategy %q", r.FormValue("strategy"))
+	}
+	tagFilter, err := tagFilterFromForm(r)
+	if err != nil {
+		return spinFilters{}, fmt.Errorf("unknown tag mode %q", r.FormValue("tag_mode"))
+	}
+	if err := parseTagExpression(&tagFilter, r.FormValue("tag_expression")); err != nil {
+		return spinFilters{}, fmt.Errorf("invalid tag expression: %w", err)
+	}
+	at, target, err := parseSpinTime(r.FormValue("spin_at"), h.userLocation(r.Context(), userID), h.now())
+	if err != nil {
+		return spinFilters{}, fmt.Errorf("invalid spin time: %w", err)
+	}
+
+	return spinFilters{
+		Strategy:              strategy,
+		TimeConstraintMinutes: timeConstraintFromForm(r),
+		TagFilter:             tagFilter,
+		At:                    at,
+		Target:                target,
+	}, nil
+}
+
+// simulationFields returns the filters in the query to carry over when a simulation is run again
+func simulationFields(query url.Values) []odds.Field {
+	var fields []odds.Field
+	for name, values := range query {
+		if name == "spins" {
+			continue
+		}
+		for _, value := range values {
+			fields = append(fields, odds.Field{Name: name, Value: value})
+		}
+	}
+	return fields
+}
+
+// simulationSpins reads how many spins to simulate, keeping it within what a request can afford
+func simulationSpins(value string) int {
+	spins, err := strconv.Atoi(value)
+	if err != nil || spins < 1 {
+		return defaultSimulationSpins
+	}
+	return min(spins, odds.MaxSpins)
+}
+
+// OddsPage handles the page that checks picks follow the odds. It simulates spins with the filters from the spin
+// form and compares the wheel's spin history with the options' weights.
+func (h *Handler) OddsPage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/signin", http.StatusSeeOther)
+		return
+	}
+
+	ctx := r.Context()
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to check the odds", http.StatusInternalServerError)
+		return
+	}
+
+	simulation := odds.Simulation{
+		Spins:  simulationSpins(r.URL.Query().Get("spins")),
+		Fields: simulationFields(r.URL.Query()),
+	}
+	filters, err := h.spinFiltersFromForm(r, userID)
+	if err != nil {
+		simulation.Error = "Could not simulate spins: " + err.Error()
+	} else {
+		report, err := h.simulateSpins(ctx, userID, wheel.ID, filters, simulation.Spins)
+		switch {
+		case errors.Is(err, errNoOptionsMatch):
+			simulation.Error = "No options match the filters"
+		case err != nil:
+			h.Logger.Error("Failed to simulate spins", "error", err)
+			http.Error(w, "Failed to check the odds", http.StatusInternalServerError)
+			return
+		default:
+			simulation.Report = report.toAppReport()
+		}
+	}
+
+	history, err := h.spinHistoryOdds(ctx, userID, wheel.ID)
+	if err != nil {
+		h.Logger.Error("Failed to compare spin history", "error", err)
+		http.Error(w, "Failed to check the odds", http.StatusInternalServerError)
+		return
+	}
+
+	h.html(ctx, w, http.StatusOK, odds.Page(utils.GetUserEmail(r), wheel.Name, simulation, history.toAppReport()))
+}
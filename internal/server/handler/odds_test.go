@@ -0,0 +1,225 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// cycleSource counts up through every number below the bound, so a whole number of cycles picks every option
+// exactly in proportion to its weight
+type cycleSource struct {
+	next int64
+}
+
+func (s *cycleSource) Int64N(n int64) int64 {
+	v := s.next % n
+	s.next++
+	return v
+}
+
+// simulate simulates spins on the active wheel through the API and decodes the result when it succeeds
+func (e testEnv) simulate(t *testing.T, body string) (int, handler.APISimulation) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/simulations", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	e.handler.APISimulateSpins(w, r)
+
+	var result handler.APISimulation
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	}
+	return w.Code, result
+}
+
+// observed returns how often each option came up in the report
+func observed(report handler.APIOddsReport) map[string]int64 {
+	counts := make(map[string]int64, len(report.Options))
+	for _, opt := range report.Options {
+		counts[opt.Name] = opt.Observed
+	}
+	return counts
+}
+
+func TestSimulateSpins(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		observed map[string]int64
+	}{
+		{
+			name:     "picks follow the weights",
+			body:     `{"spins": 1100}`,
+			observed: map[string]int64{"Hike": 100, "Cinema": 1000},
+		},
+		{
+			name:     "filters narrow the options",
+			body:     `{"spins": 500, "tags": ["indoor"], "include_untagged": false}`,
+			observed: map[string]int64{"Cinema": 500},
+		},
+		{
+			name:     "uniform ignores weights",
+			body:     `{"spins": 1000, "strategy": "uniform"}`,
+			observed: map[string]int64{"Hike": 500, "Cinema": 500},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.createOptions(t, `{"name": "Hike", "weight": 1, "tags": ["outdoor"]}`, `{"name": "Cinema", "weight": 10, "tags": ["indoor"]}`)
+			e.handler.Random = &cycleSource{}
+
+			status, result := e.simulate(t, test.body)
+			require.Equal(t, http.StatusOK, status)
+			require.Equal(t, test.observed, observed(result.Simulation))
+			require.InDelta(t, 0, result.Simulation.ChiSquare, 1e-9)
+			require.InDelta(t, 1, result.Simulation.PValue, 1e-9)
+			require.Equal(t, len(test.observed)-1, result.Simulation.DegreesOfFreedom)
+		})
+	}
+}
+
+func TestSimulateSpinsStepsStrategies(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "shuffle bag", body: `{"spins": 1100, "strategy": "shuffle"}`},
+		{name: "no-repeat", body: `{"spins": 1100, "strategy": "no-repeat", "no_repeat": 1}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.createOptions(t, `{"name": "Hike", "weight": 1}`, `{"name": "Cinema", "weight": 10}`)
+			e.handler.Random = &cycleSource{}
+
+			// Each simulated spin sees the ones before it, so the heavier option cannot come up twice in a row
+			status, result := e.simulate(t, test.body)
+			require.Equal(t, http.StatusOK, status)
+			require.Equal(t, map[string]int64{"Hike": 550, "Cinema": 550}, observed(result.Simulation))
+
+			var chances float64
+			for _, opt := range result.Simulation.Options {
+				chances += opt.Probability
+			}
+			require.InDelta(t, 1, chances, 1e-9)
+			require.Greater(t, result.Simulation.PValue, 0.01)
+		})
+	}
+}
+
+func TestSimulateSpinsFlagsABrokenSource(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 1}`, `{"name": "Cinema", "weight": 10}`)
+
+	// A source that always draws 0 picks the lightest option every time
+	e.handler.Random = &fixedSource{}
+	status, result := e.simulate(t, `{"spins": 1000}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string]int64{"Hike": 1000, "Cinema": 0}, observed(result.Simulation))
+	require.Less(t, result.Simulation.PValue, 1e-6)
+}
+
+func TestSimulateSpinsDefaultsSpins(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`)
+
+	status, result := e.simulate(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 10000, result.Spins)
+	require.Equal(t, int64(10000), result.Simulation.Total)
+	require.Zero(t, result.Simulation.DegreesOfFreedom)
+}
+
+func TestSimulateSpinsComparesHistory(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 1}`, `{"name": "Cinema", "weight": 3}`)
+
+	// Spin Hike once and Cinema three times with the weighted strategy, plus a uniform spin that is left out
+	e.handler.Random = &cycleSource{}
+	for range 4 {
+		status, _ := e.spin(t, `{}`)
+		require.Equal(t, http.StatusOK, status)
+	}
+	status, _ := e.spin(t, `{"strategy": "uniform"}`)
+	require.Equal(t, http.StatusOK, status)
+
+	status, result := e.simulate(t, `{"spins": 1}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(4), result.History.Total)
+	require.Equal(t, map[string]int64{"Hike": 1, "Cinema": 3}, observed(result.History))
+	require.InDelta(t, 0.25, result.History.Options[0].Probability, 1e-9)
+	require.InDelta(t, 1, result.History.PValue, 1e-9)
+
+	// Spins with filters drew from other odds, as did every spin before the weights changed
+	status, _ = e.spin(t, `{"time_constraint_minutes": 30}`)
+	require.Equal(t, http.StatusOK, status)
+	id := e.optionID(t, "Hike")
+	status = e.serve(t, e.handler.APIUpdateOption, http.MethodPut, "/api/v1/options/"+id, "application/json", `{"name": "Hike", "weight": 0}`, "id", id)
+	require.Equal(t, http.StatusOK, status)
+	status, result = e.simulate(t, `{"spins": 1}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Zero(t, result.History.Total)
+
+	for range 2 {
+		status, _ := e.spin(t, `{}`)
+		require.Equal(t, http.StatusOK, status)
+	}
+	status, result = e.simulate(t, `{"spins": 1}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string]int64{"Hike": 0, "Cinema": 2}, observed(result.History))
+}
+
+func TestSimulateSpinsInvalidInput(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "tags": ["outdoor"]}`)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "too many spins", body: `{"spins": 100001}`, status: http.StatusUnprocessableEntity},
+		{name: "negative spins", body: `{"spins": -1}`, status: http.StatusUnprocessableEntity},
+		{name: "unknown strategy", body: `{"strategy": "loaded"}`, status: http.StatusUnprocessableEntity},
+		{name: "no options match", body: `{"tags": ["indoor"], "include_untagged": false}`, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, _ := e.simulate(t, test.body)
+			require.Equal(t, test.status, status)
+		})
+	}
+}
+
+func TestOddsPage(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 1, "tags": ["outdoor"]}`, `{"name": "Cinema", "weight": 10, "tags": ["indoor"]}`)
+	e.handler.Random = &cycleSource{}
+
+	status, body := e.page(t, e.handler.OddsPage, httptest.NewRequest(http.MethodGet, "/odds?spins=1100&strategy=weighted", nil))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "These picks are in line with the odds")
+	require.Contains(t, body, "No weighted spins on this wheel drew by the current weights yet")
+	require.Contains(t, body, `name="strategy" value="weighted"`)
+
+	status, body = e.page(t, e.handler.OddsPage, httptest.NewRequest(http.MethodGet, "/odds?tags[]=museum&include_untagged=false", nil))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "No options match the filters")
+
+	r := httptest.NewRequest(http.MethodGet, "/odds", nil)
+	w := httptest.NewRecorder()
+	e.handler.OddsPage(w, r)
+	require.Equal(t, http.StatusSeeOther, w.Code)
+}
@@ -0,0 +1,246 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// failOnTag makes linking the tag named boom to an option fail, after the option and any earlier tags were written
+const failOnTag = `
+CREATE TRIGGER inject_tag_failure BEFORE INSERT ON option_tags
+WHEN (SELECT name FROM tags WHERE id = NEW.tag_id) = 'boom'
+BEGIN
+  SELECT RAISE(ABORT, 'injected failure');
+END;`
+
+type testEnv struct {
+	handler *handler.Handler
+	db      db.Database
+	userID  int64
+}
+
+func newTestEnv(t *testing.T) testEnv {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	database, err := db.New(logger, filepath.Join(t.TempDir(), "test.sqlite3"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = database.Close() })
+	require.NoError(t, db.Migrate(database))
+
+	user, err := database.Queries().CreateUser(context.Background(), queries.CreateUserParams{Email: "a@example.com", PasswordHash: "x"})
+	require.NoError(t, err)
+
+	return testEnv{
+		handler: &handler.Handler{Logger: logger, Database: database},
+		db:      database,
+		userID:  user.ID,
+	}
+}
+
+// injectFailure installs the trigger that fails tag writes for the tag named boom
+func (e testEnv) injectFailure(t *testing.T) {
+	t.Helper()
+	_, err := e.db.DB().Exec(failOnTag)
+	require.NoError(t, err)
+}
+
+func (e testEnv) serve(t *testing.T, h http.HandlerFunc, method, target, contentType, body string, pathValues ...string) int {
+	t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(pathValues); i += 2 {
+		r.SetPathValue(pathValues[i], pathValues[i+1])
+	}
+	r = utils.SetUserID(r, e.userID)
+
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w.Code
+}
+
+func (e testEnv) postForm(t *testing.T, h http.HandlerFunc, target string, values url.Values) int {
+	t.Helper()
+	return e.serve(t, h, http.MethodPost, target, "application/x-www-form-urlencoded", values.Encode())
+}
+
+// options returns the options on the user's active wheel with their tags
+func (e testEnv) options(t *testing.T) map[string][]string {
+	t.Helper()
+	ctx := context.Background()
+	wheel, err := e.db.Queries().GetActiveWheel(ctx, e.userID)
+	require.NoError(t, err)
+
+	opts, err := e.db.Queries().GetOptions(ctx, queries.GetOptionsParams{WheelID: wheel.ID, UserID: e.userID})
+	require.NoError(t, err)
+
+	result := make(map[string][]string, len(opts))
+	for _, opt := range opts {
+		tags, err := e.db.Queries().GetTagsForOption(ctx, queries.GetTagsForOptionParams{OptionID: opt.ID, UserID: e.userID})
+		require.NoError(t, err)
+		names := []string{}
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		result[opt.Name] = names
+	}
+	return result
+}
+
+// tagNames returns the names of every tag on the user's active wheel
+func (e testEnv) tagNames(t *testing.T) []string {
+	t.Helper()
+	ctx := context.Background()
+	wheel, err := e.db.Queries().GetActiveWheel(ctx, e.userID)
+	require.NoError(t, err)
+
+	tags, err := e.db.Queries().GetTagsForWheel(ctx, queries.GetTagsForWheelParams{WheelID: wheel.ID, UserID: e.userID})
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// optionID returns the ID of the option with the name on the user's active wheel
+func (e testEnv) optionID(t *testing.T, name string) string {
+	t.Helper()
+	ctx := context.Background()
+	wheel, err := e.db.Queries().GetActiveWheel(ctx, e.userID)
+	require.NoError(t, err)
+
+	opt, err := e.db.Queries().GetOptionByName(ctx, queries.GetOptionByNameParams{WheelID: wheel.ID, UserID: e.userID, Name: name})
+	require.NoError(t, err)
+	return strconv.FormatInt(opt.ID, 10)
+}
+
+func TestCreateOptionIsAllOrNothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func(e testEnv, t *testing.T) int
+	}{
+		{
+			name: "form",
+			create: func(e testEnv, t *testing.T) int {
+				return e.postForm(t, e.handler.AddOption, "/api/options", url.Values{"text": {"Hiking"}, "tags": {"good, boom"}})
+			},
+		},
+		{
+			name: "api",
+			create: func(e testEnv, t *testing.T) int {
+				return e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", `{"name": "Hiking", "tags": ["good", "boom"]}`)
+			},
+		},
+		{
+			name: "import",
+			create: func(e testEnv, t *testing.T) int {
+				csv := "name,tags\nReading,good\nHiking,\"good,boom\"\n"
+				return e.serve(t, e.handler.APIImport, http.MethodPost, "/api/v1/import?format=csv", "text/csv", csv)
+			},
+		},
+		{
+			name: "sync",
+			create: func(e testEnv, t *testing.T) int {
+				body := `{"options": [{"id": "local-1", "text": "Hiking", "weight": 1, "tags": ["good", "boom"]}]}`
+				return e.serve(t, e.handler.SyncLocalOptions, http.MethodPost, "/api/sync-local-options", "application/json", body)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.injectFailure(t)
+
+			// Sync reports failures per option rather than failing the request
+			status := test.create(e, t)
+			if test.name != "sync" {
+				require.Equal(t, http.StatusInternalServerError, status)
+			}
+
+			require.Empty(t, e.options(t), "no option should be left behind")
+			require.Empty(t, e.tagNames(t), "no tag should be left behind")
+		})
+	}
+}
+
+func TestSyncFailureCanBeRetried(t *testing.T) {
+	e := newTestEnv(t)
+	e.injectFailure(t)
+
+	body := `{"options": [{"id": "local-1", "text": "Hiking", "weight": 1, "tags": ["good", "boom"]}]}`
+	require.Equal(t, http.StatusOK, e.serve(t, e.handler.SyncLocalOptions, http.MethodPost, "/api/sync-local-options", "application/json", body))
+	require.Empty(t, e.options(t))
+
+	// The failed import must not mark the local option as synced
+	_, err := e.db.DB().Exec("DROP TRIGGER inject_tag_failure")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, e.serve(t, e.handler.SyncLocalOptions, http.MethodPost, "/api/sync-local-options", "application/json", body))
+	require.Equal(t, map[string][]string{"Hiking": {"good", "boom"}}, e.options(t))
+}
+
+func TestSyncWeight(t *testing.T) {
+	e := newTestEnv(t)
+
+	body := `{"options": [
+		{"id": "local-1", "text": "Unweighted"},
+		{"id": "local-2", "text": "Never", "weight": 0},
+		{"id": "local-3", "text": "Heavy", "weight": 1000}
+	]}`
+	require.Equal(t, http.StatusOK, e.serve(t, e.handler.SyncLocalOptions, http.MethodPost, "/api/sync-local-options", "application/json", body))
+
+	require.Equal(t, int64(1), e.apiOption(t, e.optionID(t, "Unweighted")).Weight)
+	require.Equal(t, int64(0), e.apiOption(t, e.optionID(t, "Never")).Weight)
+	require.Equal(t, int64(selection.MaxWeight), e.apiOption(t, e.optionID(t, "Heavy")).Weight)
+}
+
+func TestUpdateOptionIsAllOrNothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(e testEnv, t *testing.T, id string) int
+	}{
+		{
+			name: "form",
+			update: func(e testEnv, t *testing.T, id string) int {
+				return e.postForm(t, e.handler.UpdateOptionDetails, "/api/options/update", url.Values{
+					"id": {id}, "text": {"Renamed"}, "hours": {"0"}, "minutes": {"30"}, "weight": {"5"}, "tags": {"boom"},
+				})
+			},
+		},
+		{
+			name: "api",
+			update: func(e testEnv, t *testing.T, id string) int {
+				return e.serve(t, e.handler.APIUpdateOption, http.MethodPut, "/api/v1/options/"+id, "application/json", `{"name": "Renamed", "weight": 5, "tags": ["boom"]}`, "id", id)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			require.Equal(t, http.StatusOK, e.postForm(t, e.handler.AddOption, "/api/options", url.Values{"text": {"Hiking"}, "tags": {"good"}}))
+			e.injectFailure(t)
+
+			require.Equal(t, http.StatusInternalServerError, test.update(e, t, e.optionID(t, "Hiking")))
+			require.Equal(t, map[string][]string{"Hiking": {"good"}}, e.options(t), "the option and its tags should be unchanged")
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+// What a user did with a decision
+const (
+	outcomeAccepted = "accepted"
+	outcomeSkipped  = "skipped"
+	outcomeRerolled = "rerolled"
+)
+
+const (
+	minRating = 1
+	maxRating = 5
+	// neutralRating leaves the weight of the option as it is when learning from ratings.
+	// Every point above or below it moves the weight by one.
+	neutralRating = 3
+	// maxNoteLength is the longest note a completed decision can have
+	maxNoteLength = 500
+)
+
+var (
+	errSpinOutcome   = errors.New("outcome must be accepted or skipped")
+	errSpinRating    = fmt.Errorf("rating must be between %d and %d", minRating, maxRating)
+	errSpinNote      = fmt.Errorf("note must be at most %d characters", maxNoteLength)
+	errSpinCompleted = errors.New("a completed decision can only be accepted")
+	errSpinPassed    = errors.New("a skipped or rerolled decision cannot be completed")
+)
+
+// isConflict reports whether the error is an outcome that conflicts with the one already recorded
+func isConflict(err error) bool {
+	return errors.Is(err, errSpinCompleted) || errors.Is(err, errSpinPassed) || errors.Is(err, errVetoed) ||
+		errors.Is(err, errVetoLimit) || errors.Is(err, errVetoPending)
+}
+
+// isInvalidOutcome reports whether the error is an outcome that can never be recorded for the spin
+func isInvalidOutcome(err error) bool {
+	return errors.Is(err, errSpinOutcome) || errors.Is(err, errSpinRating) || errors.Is(err, errSpinNote) ||
+		errors.Is(err, errVetoMultiPick) || errors.Is(err, errVetoElimination) || errors.Is(err, errVetoWheel)
+}
+
+// setSpinOutcome records whether the user accepted or skipped the decision made by a spin. Decisions are only
+// rerolled by vetoing them with another spin, after which the outcome cannot change.
+// Returns sql.ErrNoRows when the user has no such spin.
+func (h *Handler) setSpinOutcome(ctx context.Context, userID, spinID int64, outcome string) (queries.Spin, error) {
+	if outcome != outcomeAccepted && outcome != outcomeSkipped {
+		return queries.Spin{}, errSpinOutcome
+	}
+
+	var updated queries.Spin
+	err := h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		spin, err := q.GetSpin(ctx, queries.GetSpinParams{ID: spinID, UserID: userID})
+		if err != nil {
+			return fmt.Errorf("failed to get spin: %w", err)
+		}
+		if spin.CompletedAt.Valid && outcome != outcomeAccepted {
+			return errSpinCompleted
+		}
+		if spin.Outcome.String == outcomeRerolled {
+			return errVetoed
+		}
+
+		updated, err = q.SetSpinOutcome(ctx, queries.SetSpinOutcomeParams{
+			Outcome: sql.NullString{String: outcome, Valid: true},
+			ID:      spinID,
+			UserID:  userID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set spin outcome: %w", err)
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// completeSpin marks the decision made by a spin as done with a rating and an optional note. Completing a decision
+// accepts it. The first time a decision is completed, the option it picked is nudged toward its rating when the user
+// learns from ratings. Rating it again only changes the rating and note.
+// Returns sql.ErrNoRows when the user has no such spin.
+func (h *Handler) completeSpin(ctx context.Context, userID, spinID, rating int64, note string) (queries.Spin, error) {
+	if rating < minRating || rating > maxRating {
+		return queries.Spin{}, errSpinRating
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > maxNoteLength {
+		return queries.Spin{}, errSpinNote
+	}
+
+	var updated queries.Spin
+	err := h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		spin, err := q.GetSpin(ctx, queries.GetSpinParams{ID: spinID, UserID: userID})
+		if err != nil {
+			return fmt.Errorf("failed to get spin: %w", err)
+		}
+		if spin.Outcome.String == outcomeSkipped || spin.Outcome.String == outcomeRerolled {
+			return errSpinPassed
+		}
+
+		updated, err = q.CompleteSpin(ctx, queries.CompleteSpinParams{
+			Rating: sql.NullInt64{Int64: rating, Valid: true},
+			Note:   sql.NullString{String: note, Valid: note != ""},
+			ID:     spinID,
+			UserID: userID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to complete spin: %w", err)
+		}
+
+		if spin.CompletedAt.Valid || !spin.OptionID.Valid {
+			return nil
+		}
+		learn, err := q.GetUserLearnFromRatings(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get rating setting: %w", err)
+		}
+		if learn == 0 {
+			return nil
+		}
+		return nudgeWeight(ctx, q, userID, spin.OptionID.Int64, rating)
+	})
+	return updated, err
+}
+
+// nudgeWeight moves the weight of an option up for a good rating and down for a poor one. The weight stays between
+// 1 and selection.MaxWeight, so a rating never stops an option from coming up, and an option weighted 0 is left alone.
+// Options that have since been deleted are skipped.
+func nudgeWeight(ctx context.Context, q *queries.Queries, userID, optionID, rating int64) error {
+	opt, err := q.GetOption(ctx, queries.GetOptionParams{ID: optionID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get option: %w", err)
+	}
+
+	weight := optionWeight(opt)
+	if weight == 0 {
+		return nil
+	}
+	nudged := max(1, min(weight+rating-neutralRating, selection.MaxWeight))
+	if nudged == weight {
+		return nil
+	}
+	if err := q.UpdateWeight(ctx, queries.UpdateWeightParams{
+		Weight: sql.NullInt64{Int64: nudged, Valid: true},
+		ID:     optionID,
+		UserID: userID,
+	}); err != nil {
+		return fmt.Errorf("failed to update weight: %w", err)
+	}
+	return nil
+}
+
+// spinIDFromPath parses the {id} path value of a spin, sending an error if it is invalid
+func spinIDFromPath(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	spinID, err := stringToInt64(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid spin ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return spinID, true
+}
+
+// writeOutcomeError sends the error from recording what became of a decision
+func (h *Handler) writeOutcomeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Decision not found", http.StatusNotFound)
+	case isInvalidOutcome(err):
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, "Could not save: "+err.Error()))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case isConflict(err):
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, "Could not save: "+err.Error()))
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.Logger.Error(message, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// SetSpinOutcome handles accepting or skipping the decision shown after a spin
+func (h *Handler) SetSpinOutcome(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	spinID, ok := spinIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	spin, err := h.setSpinOutcome(ctx, userID, spinID, r.FormValue("outcome"))
+	if err != nil {
+		h.writeOutcomeError(w, err, "Failed to save decision")
+		return
+	}
+
+	h.Logger.Info("Spin outcome recorded", "spin_id", spin.ID, "outcome", spin.Outcome.String)
+	h.html(ctx, w, http.StatusOK, home.ResultActions(strconv.FormatInt(spin.ID, 10), spin.Outcome.String, nil))
+}
+
+// CompleteSpin handles marking a decision from the history as done and rating it
+func (h *Handler) CompleteSpin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	spinID, ok := spinIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// A missing or malformed rating parses to a value completeSpin rejects
+	rating, _ := strconv.ParseInt(r.FormValue("rating"), 10, 64)
+
+	ctx := r.Context()
+	spin, err := h.completeSpin(ctx, userID, spinID, rating, r.FormValue("note"))
+	if err != nil {
+		h.writeOutcomeError(w, err, "Failed to save rating")
+		return
+	}
+
+	h.Logger.Info("Spin completed", "spin_id", spin.ID, "rating", spin.Rating.Int64)
+	h.html(ctx, w, http.StatusOK, home.SpinRow(h.dbSpinToAppSpin(spin, h.userLocation(ctx, userID))))
+}
@@ -0,0 +1,164 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// spinID spins the user's active wheel and returns the ID of the recorded spin
+func (e testEnv) spinID(t *testing.T) string {
+	t.Helper()
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	return strconv.FormatInt(result.SpinID, 10)
+}
+
+// savedSpin returns the recorded spin
+func (e testEnv) savedSpin(t *testing.T, id string) queries.Spin {
+	t.Helper()
+	spinID, err := strconv.ParseInt(id, 10, 64)
+	require.NoError(t, err)
+	spin, err := e.db.Queries().GetSpin(context.Background(), queries.GetSpinParams{ID: spinID, UserID: e.userID})
+	require.NoError(t, err)
+	return spin
+}
+
+func TestSetSpinOutcome(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`)
+	id := e.spinID(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		expected string
+	}{
+		{name: "accepted", body: `{"outcome": "accepted"}`, status: http.StatusOK, expected: "accepted"},
+		{name: "changed to skipped", body: `{"outcome": "skipped"}`, status: http.StatusOK, expected: "skipped"},
+		{name: "rerolled only by vetoing", body: `{"outcome": "rerolled"}`, status: http.StatusUnprocessableEntity, expected: "skipped"},
+		{name: "unknown", body: `{"outcome": "maybe"}`, status: http.StatusUnprocessableEntity, expected: "skipped"},
+		{name: "missing", body: `{}`, status: http.StatusUnprocessableEntity, expected: "skipped"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := e.serve(t, e.handler.APISetSpinOutcome, http.MethodPost, "/api/v1/spins/"+id+"/outcome", "application/json", test.body, "id", id)
+			require.Equal(t, test.status, status)
+			require.Equal(t, test.expected, e.savedSpin(t, id).Outcome.String)
+		})
+	}
+
+	status := e.serve(t, e.handler.APISetSpinOutcome, http.MethodPost, "/api/v1/spins/999/outcome", "application/json", `{"outcome": "accepted"}`, "id", "999")
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestCompleteSpin(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "rated", body: `{"rating": 4}`, status: http.StatusOK},
+		{name: "with a note", body: `{"rating": 1, "note": "Rained the whole time"}`, status: http.StatusOK},
+		{name: "missing rating", body: `{"note": "Fun"}`, status: http.StatusUnprocessableEntity},
+		{name: "rating too high", body: `{"rating": 6}`, status: http.StatusUnprocessableEntity},
+		{name: "note too long", body: `{"rating": 3, "note": "` + strings.Repeat("a", 501) + `"}`, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id := e.spinID(t)
+			status := e.serve(t, e.handler.APICompleteSpin, http.MethodPost, "/api/v1/spins/"+id+"/completion", "application/json", test.body, "id", id)
+			require.Equal(t, test.status, status)
+
+			spin := e.savedSpin(t, id)
+			require.Equal(t, test.status == http.StatusOK, spin.CompletedAt.Valid)
+			if test.status == http.StatusOK {
+				require.Equal(t, "accepted", spin.Outcome.String)
+			}
+		})
+	}
+
+	t.Run("skipped decisions cannot be completed", func(t *testing.T) {
+		id := e.spinID(t)
+		require.Equal(t, http.StatusOK, e.serve(t, e.handler.APISetSpinOutcome, http.MethodPost, "/", "application/json", `{"outcome": "skipped"}`, "id", id))
+		status := e.serve(t, e.handler.APICompleteSpin, http.MethodPost, "/", "application/json", `{"rating": 5}`, "id", id)
+		require.Equal(t, http.StatusConflict, status)
+	})
+
+	t.Run("completed decisions cannot be rerolled", func(t *testing.T) {
+		id := e.spinID(t)
+		require.Equal(t, http.StatusOK, e.serve(t, e.handler.APICompleteSpin, http.MethodPost, "/", "application/json", `{"rating": 5}`, "id", id))
+		status, _ := e.spin(t, `{"veto_spin_id": `+id+`}`)
+		require.Equal(t, http.StatusConflict, status)
+		require.Equal(t, "accepted", e.savedSpin(t, id).Outcome.String)
+	})
+}
+
+func TestCompleteSpinFromHistory(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`)
+	id := e.spinID(t)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/spins/"+id+"/completion", nil)
+	r.PostForm = url.Values{"rating": {"4"}, "note": {"  Great views  "}}
+	r.SetPathValue("id", id)
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	e.handler.CompleteSpin(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "★★★★☆")
+	require.Contains(t, w.Body.String(), "Great views")
+	require.NotContains(t, w.Body.String(), "Mark done")
+	require.Equal(t, "Great views", e.savedSpin(t, id).Note.String)
+}
+
+func TestCompleteSpinLearnsFromRatings(t *testing.T) {
+	tests := []struct {
+		name     string
+		learn    bool
+		weight   int64
+		ratings  []string
+		expected int64
+	}{
+		{name: "off leaves the weight", weight: 5, ratings: []string{"5"}, expected: 5},
+		{name: "loved", learn: true, weight: 5, ratings: []string{"5"}, expected: 7},
+		{name: "disliked", learn: true, weight: 5, ratings: []string{"2"}, expected: 4},
+		{name: "neutral", learn: true, weight: 5, ratings: []string{"3"}, expected: 5},
+		{name: "rating again does not nudge twice", learn: true, weight: 5, ratings: []string{"5", "1"}, expected: 7},
+		{name: "never drops below 1", learn: true, weight: 1, ratings: []string{"1"}, expected: 1},
+		{name: "never rises above 100", learn: true, weight: 99, ratings: []string{"5"}, expected: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.createOptions(t, `{"name": "Hike", "weight": `+strconv.FormatInt(test.weight, 10)+`}`)
+			if test.learn {
+				status := e.postForm(t, e.handler.UpdateLearnFromRatings, "/api/settings/learn-from-ratings", url.Values{"learn_from_ratings": {"on"}})
+				require.Equal(t, http.StatusOK, status)
+			}
+
+			id := e.spinID(t)
+			for _, rating := range test.ratings {
+				status := e.serve(t, e.handler.APICompleteSpin, http.MethodPost, "/", "application/json", `{"rating": `+rating+`}`, "id", id)
+				require.Equal(t, http.StatusOK, status)
+			}
+			require.Equal(t, test.expected, e.weight(t, "Hike"))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedSource always draws the same number, remembering the bound it was last asked for
+type fixedSource struct {
+	value int64
+	bound int64
+}
+
+func (s *fixedSource) Int64N(n int64) int64 {
+	s.bound = n
+	return s.value
+}
+
+// lastSpin returns the option the most recent spin on the active wheel picked
+func (e testEnv) lastSpin(t *testing.T) string {
+	t.Helper()
+	spins, err := e.db.Queries().GetSpins(context.Background(), queries.GetSpinsParams{
+		WheelID: sql.NullInt64{Int64: e.wheelID(t), Valid: true},
+		UserID:  e.userID,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Len(t, spins, 1)
+	return spins[0].OptionName
+}
+
+func TestRandomPicker(t *testing.T) {
+	saturdayNoon := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	mondayNoon := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	e := newTestEnv(t)
+	e.createOptions(t,
+		`{"name": "Hike", "weight": 1, "duration_minutes": 180, "tags": ["outdoor"], "availability": [{"days": ["sat", "sun"]}]}`,
+		`{"name": "Museum", "weight": 3, "duration_minutes": 90, "tags": ["indoor"]}`,
+		`{"name": "Cinema", "weight": 2, "duration_minutes": 150, "tags": ["indoor"], "availability": [{"days": ["fri", "sat"], "start": "18:00", "end": "23:59"}]}`,
+		`{"name": "Reading", "weight": 4, "duration_minutes": 30}`,
+	)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		form     url.Values
+		value    int64
+		bound    int64
+		expected string
+	}{
+		// Saturday noon leaves Hike (0), Museum (1 to 3) and Reading (4 to 7) as Cinema only runs in the evening
+		{name: "weighted first option", now: saturdayNoon, value: 0, bound: 8, expected: "Hike"},
+		{name: "weighted middle option", now: saturdayNoon, value: 3, bound: 8, expected: "Museum"},
+		{name: "weighted last option", now: saturdayNoon, value: 7, bound: 8, expected: "Reading"},
+		{name: "uniform", now: saturdayNoon, form: url.Values{"strategy": {"uniform"}}, value: 1, bound: 3, expected: "Museum"},
+		{name: "time constraint", now: saturdayNoon, form: url.Values{"hours": {"1"}, "minutes": {"30"}}, value: 3, bound: 7, expected: "Reading"},
+		{name: "include tags", now: saturdayNoon, form: url.Values{"tags[]": {"indoor"}, "include_untagged": {"false"}}, value: 2, bound: 3, expected: "Museum"},
+		{name: "exclude tags", now: saturdayNoon, form: url.Values{"exclude_tags[]": {"outdoor"}}, value: 0, bound: 7, expected: "Museum"},
+		{name: "tag expression", now: saturdayNoon, form: url.Values{"tag_expression": {"not indoor"}}, value: 1, bound: 5, expected: "Reading"},
+		{name: "clock decides availability", now: mondayNoon, value: 0, bound: 7, expected: "Museum"},
+		{name: "spin for later", now: saturdayNoon, form: url.Values{"spin_at": {"2026-10-17T20:00"}}, value: 4, bound: 10, expected: "Cinema"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := &fixedSource{value: test.value}
+			e.handler.Random = src
+			e.handler.Now = func() time.Time { return test.now }
+
+			form := url.Values{"strategy": {"weighted"}}
+			for key, values := range test.form {
+				form[key] = values
+			}
+			require.Equal(t, http.StatusOK, e.postForm(t, e.handler.RandomPicker, "/api/random", form))
+			require.Equal(t, test.expected, e.lastSpin(t))
+			require.Equal(t, test.bound, src.bound)
+		})
+	}
+}
+
+func TestRandomPickerSpinDelay(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`)
+	e.handler.SpinDelay = 50 * time.Millisecond
+
+	start := time.Now()
+	require.Equal(t, http.StatusOK, e.postForm(t, e.handler.RandomPicker, "/api/random", url.Values{}))
+	require.GreaterOrEqual(t, time.Since(start), e.handler.SpinDelay)
+}
+
+func TestShuffleBagReplaysEveryPick(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Read"}`, `{"name": "Cook"}`)
+	e.handler.Random = &fixedSource{}
+
+	// 101 picks leave two options drawn from the current bag, which a replay of only the last 100 would get wrong
+	picks := make([]string, 0, 101)
+	for range 101 {
+		status, result := e.spin(t, `{"strategy": "shuffle"}`)
+		require.Equal(t, http.StatusOK, status)
+		picks = append(picks, result.Option.Name)
+	}
+	require.ElementsMatch(t, []string{"Hike", "Read", "Cook"}, picks[:3])
+
+	status, result := e.spin(t, `{"strategy": "shuffle"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.NotContains(t, picks[99:], result.Option.Name)
+}
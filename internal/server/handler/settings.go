@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Piszmog/make-a-decision/internal/components/settings"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+// maxTokenNameLength is the longest name an API token can be given
+const maxTokenNameLength = 50
+
+// dbTokenToAppToken converts SQLC queries.ApiToken to app settings.Token
+func dbTokenToAppToken(dbToken queries.ApiToken) settings.Token {
+	token := settings.Token{
+		ID:        strconv.FormatInt(dbToken.ID, 10),
+		Name:      dbToken.Name,
+		Prefix:    dbToken.TokenPrefix,
+		Scope:     dbToken.Scope,
+		CreatedAt: dbToken.CreatedAt,
+	}
+	if dbToken.LastUsedAt.Valid {
+		token.LastUsedAt = &dbToken.LastUsedAt.Time
+	}
+	return token
+}
+
+// apiTokens returns the user's tokens that have not been revoked, newest first
+func (h *Handler) apiTokens(ctx context.Context, userID int64) ([]settings.Token, error) {
+	dbTokens, err := h.Database.Queries().GetAPITokens(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api tokens: %w", err)
+	}
+
+	tokens := make([]settings.Token, len(dbTokens))
+	for i, dbToken := range dbTokens {
+		tokens[i] = dbTokenToAppToken(dbToken)
+	}
+	return tokens, nil
+}
+
+// requireSessionAuth checks for a user signed in through the browser.
+// Tokens cannot be used to manage tokens, otherwise a leaked token could mint new ones.
+func requireSessionAuth(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	if _, ok := utils.GetTokenScope(r); ok {
+		http.Error(w, "API tokens cannot manage API tokens", http.StatusForbidden)
+		return 0, false
+	}
+	return utils.RequireAuth(w, r)
+}
+
+// SettingsPage handles rendering the account settings page
+func (h *Handler) SettingsPage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := utils.GetUserID(r); !ok {
+		http.Redirect(w, r, "/signin", http.StatusSeeOther)
+		return
+	}
+
+	userID, ok := requireSessionAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	tokens, err := h.apiTokens(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get api tokens", "error", err)
+		http.Error(w, "Failed to load settings", http.StatusInternalServerError)
+		return
+	}
+
+	learn, err := h.Database.Queries().GetUserLearnFromRatings(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get rating setting", "error", err)
+		http.Error(w, "Failed to load settings", http.StatusInternalServerError)
+		return
+	}
+
+	h.html(ctx, w, http.StatusOK, settings.Page(utils.GetUserEmail(r), tokens, h.userLocation(ctx, userID).String(), learn != 0))
+}
+
+// UpdateTimeZone handles changing the time zone availability windows and spin times are evaluated in
+func (h *Handler) UpdateTimeZone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := requireSessionAuth(w, r)
+	if !ok {
+		return
+	}
+
+	loc, err := loadTimeZone(r.FormValue("time_zone"))
+	if err != nil {
+		w.Header().Set("HX-Trigger", `{"error": "Unknown time zone, use a name such as America/Chicago"}`)
+		http.Error(w, "Unknown time zone", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if err := h.Database.Queries().UpdateUserTimeZone(ctx, queries.UpdateUserTimeZoneParams{
+		TimeZone: loc.String(),
+		ID:       userID,
+	}); err != nil {
+		h.Logger.Error("Failed to update time zone", "error", err)
+		http.Error(w, "Failed to update time zone", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Time zone updated", "user_id", userID, "time_zone", loc.String())
+	localTime := h.now().In(loc)
+	h.html(ctx, w, http.StatusOK, settings.TimeZoneForm(loc.String(), &localTime))
+}
+
+// UpdateLearnFromRatings handles turning on or off whether rating a decision nudges the weight of the option it picked
+func (h *Handler) UpdateLearnFromRatings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := requireSessionAuth(w, r)
+	if !ok {
+		return
+	}
+
+	// An unchecked checkbox is left out of the form
+	learn := r.FormValue("learn_from_ratings") == "on"
+	var value int64
+	if learn {
+		value = 1
+	}
+
+	ctx := r.Context()
+	if err := h.Database.Queries().UpdateUserLearnFromRatings(ctx, queries.UpdateUserLearnFromRatingsParams{
+		LearnFromRatings: value,
+		ID:               userID,
+	}); err != nil {
+		h.Logger.Error("Failed to update rating setting", "error", err)
+		http.Error(w, "Failed to update rating setting", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Rating setting updated", "user_id", userID, "learn_from_ratings", learn)
+	h.html(ctx, w, http.StatusOK, settings.LearnFromRatingsForm(learn, true))
+}
+
+// CreateAPIToken handles creating a new API token. The token is only returned in this response.
+func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := requireSessionAuth(w, r)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" || len(name) > maxTokenNameLength {
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": "Token name must be 1-%d characters"}`, maxTokenNameLength))
+		http.Error(w, fmt.Sprintf("Token name must be 1-%d characters", maxTokenNameLength), http.StatusBadRequest)
+		return
+	}
+
+	scope := r.FormValue("scope")
+	if scope != utils.TokenScopeRead && scope != utils.TokenScopeWrite {
+		w.Header().Set("HX-Trigger", `{"error": "Scope must be read or write"}`)
+		http.Error(w, "Scope must be read or write", http.StatusBadRequest)
+		return
+	}
+
+	token, prefix, err := utils.GenerateAPIToken()
+	if err != nil {
+		h.Logger.Error("Failed to generate api token", "error", err)
+		http.Error(w, "Failed to create token", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+	dbToken, err := h.Database.Queries().CreateAPIToken(ctx, queries.CreateAPITokenParams{
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   utils.HashAPIToken(token),
+		TokenPrefix: prefix,
+		Scope:       scope,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to create api token", "error", err)
+		http.Error(w, "Failed to create token", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := h.apiTokens(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get api tokens", "error", err)
+		http.Error(w, "Failed to get tokens", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("API token created", "id", dbToken.ID, "scope", scope)
+	h.html(ctx, w, http.StatusOK, settings.Tokens(tokens, &settings.NewToken{Name: name, Token: token}))
+}
+
+// RevokeAPIToken handles revoking an API token so it can no longer be used
+func (h *Handler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := requireSessionAuth(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := stringToInt64(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rows, err := h.Database.Queries().RevokeAPIToken(ctx, queries.RevokeAPITokenParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to revoke api token", "error", err)
+		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	tokens, err := h.apiTokens(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get api tokens", "error", err)
+		http.Error(w, "Failed to get tokens", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("API token revoked", "id", id)
+	h.html(ctx, w, http.StatusOK, settings.Tokens(tokens, nil))
+}
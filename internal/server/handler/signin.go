@@ -100,7 +100,7 @@ func (h *Handler) newSession(ctx context.Context, userID int64, userAgent string
 	}
 
 	token := uuid.New().String()
-	expiresAt := time.Now().Add(middleware.SessionDuration)
+	expiresAt := h.now().Add(middleware.SessionDuration)
 
 	session := queries.InsertSessionParams{
 		UserID:    userID,
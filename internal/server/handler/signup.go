@@ -111,6 +111,13 @@ func (h *Handler) SignupSubmit(w http.ResponseWriter, r *http.Request) {
 
 	h.Logger.Info("User created successfully", "user_id", user.ID, "email", user.Email)
 
+	// Start from the browser's time zone so availability windows match the user's clock
+	if loc, err := loadTimeZone(r.FormValue("time_zone")); err == nil {
+		if err := h.Database.Queries().UpdateUserTimeZone(ctx, queries.UpdateUserTimeZoneParams{TimeZone: loc.String(), ID: user.ID}); err != nil {
+			h.Logger.Warn("Failed to set time zone", "user_id", user.ID, "error", err)
+		}
+	}
+
 	// Create session automatically to sign the user in
 	token, expiresAt, err := h.newSession(ctx, user.ID, r.UserAgent(), "", utils.GetClientIP(r))
 	if err != nil {
@@ -1,20 +1,29 @@
 package handler
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/markdown"
+	"github.com/Piszmog/make-a-decision/internal/selection"
 	"github.com/Piszmog/make-a-decision/internal/server/utils"
 )
 
 type LocalOption struct {
-	Text     string   `json:"text"`
-	Weight   int64    `json:"weight"`
-	Duration *int64   `json:"duration"`
-	Tags     []string `json:"tags"`
+	ID          string   `json:"id"`
+	Text        string   `json:"text"`
+	Description string   `json:"description"`
+	Weight      *int64   `json:"weight"`
+	Duration    *int64   `json:"duration"`
+	Tags        []string `json:"tags"`
 }
 
 type SyncRequest struct {
@@ -22,11 +31,38 @@ type SyncRequest struct {
 }
 
 type SyncResponse struct {
-	Success bool   `json:"success"`
+	Success bool `json:"success"`
+	// Synced is the number of options created or merged
 	Synced  int    `json:"synced"`
+	Created int    `json:"created"`
+	Merged  int    `json:"merged"`
+	Skipped int    `json:"skipped"`
 	Message string `json:"message,omitempty"`
 }
 
+// syncOutcome is what happened to a single local option during a sync
+type syncOutcome int
+
+const (
+	syncCreated syncOutcome = iota
+	syncMerged
+	syncSkipped
+)
+
+// decodeSyncRequest reads the sync body. Older clients sent a bare array of options.
+func decodeSyncRequest(r *http.Request) (SyncRequest, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		return SyncRequest{}, err
+	}
+
+	var req SyncRequest
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		return req, json.Unmarshal(trimmed, &req.Options)
+	}
+	return req, json.Unmarshal(raw, &req)
+}
+
 func (h *Handler) SyncLocalOptions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -38,75 +74,186 @@ func (h *Handler) SyncLocalOptions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req SyncRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	req, err := decodeSyncRequest(r)
+	if err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	ctx := r.Context()
-	syncedCount := 0
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to sync options", http.StatusInternalServerError)
+		return
+	}
 
-	// Create each option
+	resp := SyncResponse{Success: true}
 	for _, opt := range req.Options {
-		// Validate
-		if strings.TrimSpace(opt.Text) == "" {
-			h.Logger.Warn("Skipping option with empty text during sync")
-			continue
+		outcome, err := h.syncLocalOption(ctx, userID, wheel.ID, opt)
+		if err != nil {
+			h.Logger.Error("Failed to sync option", "error", err, "option", opt.Text)
+			outcome = syncSkipped
 		}
 
-		// Clamp weight to valid range (1-10)
-		weight := max(1, min(opt.Weight, 10))
+		switch outcome {
+		case syncCreated:
+			resp.Created++
+		case syncMerged:
+			resp.Merged++
+		case syncSkipped:
+			resp.Skipped++
+		}
+	}
+	resp.Synced = resp.Created + resp.Merged
+	resp.Message = fmt.Sprintf("Synced options: %d created, %d merged, %d skipped", resp.Created, resp.Merged, resp.Skipped)
+
+	h.Logger.Info("Local options synced", "user_id", userID, "created", resp.Created, "merged", resp.Merged, "skipped", resp.Skipped)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.Logger.Error("Failed to encode sync response", "error", err)
+	}
+}
+
+// syncLocalOption imports a single local option. Local IDs that were already imported are skipped
+// and options with the same name as an existing option, ignoring case, are merged into it.
+func (h *Handler) syncLocalOption(ctx context.Context, userID, wheelID int64, opt LocalOption) (syncOutcome, error) {
+	name := strings.TrimSpace(opt.Text)
+	if name == "" {
+		h.Logger.Warn("Skipping option with empty text during sync")
+		return syncSkipped, nil
+	}
+
+	if opt.Duration != nil && (*opt.Duration < 0 || *opt.Duration > 1440) {
+		h.Logger.Warn("Invalid duration during sync, skipping", "duration", *opt.Duration)
+		return syncSkipped, nil
+	}
+
+	description, err := markdown.Normalize(opt.Description)
+	if err != nil {
+		h.Logger.Warn("Invalid description during sync, skipping", "error", err)
+		return syncSkipped, nil
+	}
+	opt.Description = description
 
-		// Validate duration
-		var durationParam any
-		if opt.Duration != nil {
-			dur := *opt.Duration
-			if dur < 0 || dur > 1440 {
-				h.Logger.Warn("Invalid duration during sync, skipping", "duration", dur)
-				continue
+	outcome := syncSkipped
+	err = h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		// Claim the local ID so concurrent syncs cannot both import it. A failed import rolls back the claim.
+		if opt.ID != "" {
+			claimed, err := q.ClaimLocalOption(ctx, queries.ClaimLocalOptionParams{
+				UserID:  userID,
+				LocalID: opt.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to claim local option: %w", err)
+			}
+			if claimed == 0 {
+				return nil
 			}
-			durationParam = dur
 		}
 
-		// Create option
-		createParams := queries.CreateOptionParams{
-			Name:            opt.Text,
-			Weight:          sql.NullInt64{Int64: weight, Valid: true},
-			DurationMinutes: durationParam,
-			UserID:          userID,
+		optionID, importOutcome, err := importLocalOption(ctx, q, userID, wheelID, name, opt)
+		if err != nil {
+			return err
 		}
 
-		createdOption, err := h.Database.Queries().CreateOption(ctx, createParams)
-		if err != nil {
-			h.Logger.Error("Failed to sync option", "error", err, "option", opt.Text)
-			continue
+		if opt.ID != "" {
+			if err := q.SetSyncedLocalOption(ctx, queries.SetSyncedLocalOptionParams{
+				OptionID: sql.NullInt64{Int64: optionID, Valid: true},
+				UserID:   userID,
+				LocalID:  opt.ID,
+			}); err != nil {
+				return fmt.Errorf("failed to record local option: %w", err)
+			}
 		}
+		outcome = importOutcome
+		return nil
+	})
+	if err != nil {
+		return syncSkipped, err
+	}
+	return outcome, nil
+}
 
-		// Add tags (limit to 5)
-		tags := opt.Tags
-		if len(tags) > 5 {
-			tags = tags[:5]
+// importLocalOption merges the local option into an option with the same name or creates a new one
+func importLocalOption(ctx context.Context, q *queries.Queries, userID, wheelID int64, name string, opt LocalOption) (int64, syncOutcome, error) {
+	existing, err := q.GetOptionByName(ctx, queries.GetOptionByNameParams{
+		WheelID: wheelID,
+		UserID:  userID,
+		Name:    name,
+	})
+	if err == nil {
+		if err := mergeLocalOption(ctx, q, userID, wheelID, existing, opt); err != nil {
+			return 0, syncSkipped, err
 		}
+		return existing.ID, syncMerged, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, syncSkipped, fmt.Errorf("failed to find option by name: %w", err)
+	}
 
-		if len(tags) > 0 {
-			if err := h.setTagsForOption(ctx, createdOption.ID, userID, tags); err != nil {
-				h.Logger.Warn("Failed to sync tags for option", "error", err, "option_id", createdOption.ID)
-			}
+	var durationParam any
+	if opt.Duration != nil {
+		durationParam = *opt.Duration
+	}
+
+	// Options saved before the browser stored weights have none and count as weight 1
+	weight := int64(1)
+	if opt.Weight != nil {
+		weight = max(0, min(*opt.Weight, selection.MaxWeight))
+	}
+
+	created, err := q.CreateOption(ctx, queries.CreateOptionParams{
+		Name:            name,
+		Bio:             sql.NullString{String: opt.Description, Valid: opt.Description != ""},
+		Weight:          sql.NullInt64{Int64: weight, Valid: true},
+		DurationMinutes: durationParam,
+		UserID:          userID,
+		WheelID:         wheelID,
+	})
+	if err != nil {
+		return 0, syncSkipped, fmt.Errorf("failed to create option: %w", err)
+	}
+
+	if err := setTagsForOption(ctx, q, created.ID, wheelID, userID, normalizeTags(opt.Tags)); err != nil {
+		return 0, syncSkipped, err
+	}
+	return created.ID, syncCreated, nil
+}
+
+// mergeLocalOption folds a local option into an existing one. The existing weight is kept,
+// a missing description or duration is filled in and the tags are combined, up to the tag limit.
+func mergeLocalOption(ctx context.Context, q *queries.Queries, userID, wheelID int64, existing queries.Option, opt LocalOption) error {
+	if !existing.Bio.Valid && opt.Description != "" {
+		if err := q.UpdateBio(ctx, queries.UpdateBioParams{
+			Bio:    sql.NullString{String: opt.Description, Valid: true},
+			ID:     existing.ID,
+			UserID: userID,
+		}); err != nil {
+			return fmt.Errorf("failed to update description: %w", err)
 		}
+	}
 
-		syncedCount++
+	if existing.DurationMinutes == nil && opt.Duration != nil {
+		if err := q.UpdateDuration(ctx, queries.UpdateDurationParams{
+			DurationMinutes: *opt.Duration,
+			ID:              existing.ID,
+			UserID:          userID,
+		}); err != nil {
+			return fmt.Errorf("failed to update duration: %w", err)
+		}
 	}
 
-	h.Logger.Info("Local options synced", "user_id", userID, "count", syncedCount)
+	existingTags, err := tagsForOption(ctx, q, existing.ID, userID)
+	if err != nil {
+		return err
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(SyncResponse{
-		Success: true,
-		Synced:  syncedCount,
-		Message: "Options synced successfully",
-	}); err != nil {
-		h.Logger.Error("Failed to encode sync response", "error", err)
+	tags := normalizeTags(append(slices.Clone(existingTags), opt.Tags...))
+	if len(tags) == len(existingTags) {
+		return nil
 	}
+	return setTagsForOption(ctx, q, existing.ID, wheelID, userID, tags)
 }
@@ -0,0 +1,204 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/selection"
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/Piszmog/make-a-decision/internal/tagexpr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpinTagFilter(t *testing.T) {
+	e := newTestEnv(t)
+
+	options := map[string][]string{
+		"Hiking":  {"outdoor", "active"},
+		"Running": {"outdoor", "active", "solo"},
+		"Reading": {"indoor", "solo"},
+		"Chess":   {"indoor"},
+		"Nap":     {},
+	}
+	for name, tags := range options {
+		body, err := json.Marshal(handler.APIOptionInput{Name: name, Tags: tags})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", string(body)))
+	}
+
+	untagged := true
+	noUntagged := false
+	tests := []struct {
+		name     string
+		input    handler.APISpinInput
+		expected []string
+	}{
+		{
+			name:     "no filter",
+			expected: []string{"Chess", "Hiking", "Nap", "Reading", "Running"},
+		},
+		{
+			name:     "any",
+			input:    handler.APISpinInput{Tags: []string{"active", "indoor"}},
+			expected: []string{"Chess", "Hiking", "Nap", "Reading", "Running"},
+		},
+		{
+			name:     "any without untagged",
+			input:    handler.APISpinInput{Tags: []string{"solo"}, IncludeUntagged: &noUntagged},
+			expected: []string{"Reading", "Running"},
+		},
+		{
+			name:     "all",
+			input:    handler.APISpinInput{Tags: []string{"outdoor", "solo"}, TagMode: selection.TagModeAll, IncludeUntagged: &untagged},
+			expected: []string{"Nap", "Running"},
+		},
+		{
+			name:     "all without untagged",
+			input:    handler.APISpinInput{Tags: []string{"Outdoor", "active"}, TagMode: selection.TagModeAll, IncludeUntagged: &noUntagged},
+			expected: []string{"Hiking", "Running"},
+		},
+		{
+			name:     "exclude",
+			input:    handler.APISpinInput{ExcludeTags: []string{"solo"}},
+			expected: []string{"Chess", "Hiking", "Nap"},
+		},
+		{
+			name:     "any with exclude",
+			input:    handler.APISpinInput{Tags: []string{"outdoor"}, ExcludeTags: []string{"solo"}, IncludeUntagged: &noUntagged},
+			expected: []string{"Hiking"},
+		},
+		{
+			name:     "expression",
+			input:    handler.APISpinInput{TagExpression: "(outdoor or indoor) and not solo"},
+			expected: []string{"Chess", "Hiking"},
+		},
+		{
+			name:     "expression with exclude",
+			input:    handler.APISpinInput{TagExpression: "not active", ExcludeTags: []string{"indoor"}},
+			expected: []string{"Nap"},
+		},
+		{
+			name:     "only untagged excluded",
+			input:    handler.APISpinInput{IncludeUntagged: &noUntagged},
+			expected: []string{"Chess", "Hiking", "Reading", "Running"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := json.Marshal(test.input)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/spins", strings.NewReader(string(body)))
+			r.Header.Set("Content-Type", "application/json")
+			r = utils.SetUserID(r, e.userID)
+			w := httptest.NewRecorder()
+			e.handler.APISpinWheel(w, r)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var result handler.APISpinResult
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+
+			eligible := make([]string, len(result.Eligible))
+			for i, chance := range result.Eligible {
+				eligible[i] = chance.Name
+			}
+			slices.Sort(eligible)
+			require.Equal(t, test.expected, eligible)
+
+			// The query must agree with the filter the local storage path mirrors
+			filter, err := selection.NewTagFilter(test.input.TagMode, lower(test.input.Tags), lower(test.input.ExcludeTags), test.input.IncludeUntagged == nil || *test.input.IncludeUntagged)
+			require.NoError(t, err)
+			if test.input.TagExpression != "" {
+				filter.Expression, err = tagexpr.Parse(test.input.TagExpression)
+				require.NoError(t, err)
+			}
+			for name, tags := range options {
+				require.Equal(t, slices.Contains(test.expected, name), filter.Matches(tags), name)
+			}
+		})
+	}
+}
+
+func TestSpinUnknownTagMode(t *testing.T) {
+	e := newTestEnv(t)
+	status := e.serve(t, e.handler.APISpinWheel, http.MethodPost, "/api/v1/spins", "application/json", `{"tag_mode": "some"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+}
+
+func TestSpinInvalidTagExpression(t *testing.T) {
+	e := newTestEnv(t)
+
+	status := e.serve(t, e.handler.APISpinWheel, http.MethodPost, "/api/v1/spins", "application/json", `{"tag_expression": "outdoor and"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/random", strings.NewReader(url.Values{"tag_expression": {"(outdoor or cheap"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	e.handler.RandomPicker(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "Invalid Tag Expression")
+	require.Contains(t, w.Body.String(), "missing closing parenthesis at position 1")
+}
+
+func TestSpinRecordsTagFilter(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "tags": ["outdoor", "active"]}`, `{"name": "Read", "tags": ["indoor", "solo"]}`)
+
+	status, result := e.spin(t, `{"tags": ["Outdoor", "active"], "tag_mode": "all", "exclude_tags": ["solo"], "include_untagged": false, "tag_expression": "  not rainy "}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "Hike", result.Option.Name)
+
+	spin := e.savedSpin(t, strconv.FormatInt(result.SpinID, 10))
+	require.JSONEq(t, `["outdoor", "active"]`, spin.Tags)
+	require.Equal(t, selection.TagModeAll, spin.TagMode)
+	require.JSONEq(t, `["solo"]`, spin.ExcludeTags)
+	require.Zero(t, spin.IncludeUntagged)
+	require.Equal(t, "not rainy", spin.TagExpression)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/spins", nil)
+	r.Header.Set("Accept", "application/json")
+	status, body := e.page(t, e.handler.APIListSpins, r)
+	require.Equal(t, http.StatusOK, status)
+	var page handler.APISpinPage
+	require.NoError(t, json.Unmarshal([]byte(body), &page))
+	require.Len(t, page.Spins, 1)
+	require.Equal(t, []string{"outdoor", "active"}, page.Spins[0].Tags)
+	require.Equal(t, selection.TagModeAll, page.Spins[0].TagMode)
+	require.Equal(t, []string{"solo"}, page.Spins[0].ExcludeTags)
+	require.False(t, page.Spins[0].IncludeUntagged)
+	require.Equal(t, "not rainy", page.Spins[0].TagExpression)
+
+	status, body = e.page(t, e.handler.History, httptest.NewRequest(http.MethodGet, "/api/history", nil))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "All of")
+	require.Contains(t, body, `title="Excluded solo"`)
+	require.Contains(t, body, "No untagged options")
+	require.Contains(t, body, "not rainy")
+
+	// A spin without a filter records that every option was let through
+	status, result = e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	spin = e.savedSpin(t, strconv.FormatInt(result.SpinID, 10))
+	require.Equal(t, "[]", spin.Tags)
+	require.Equal(t, selection.TagModeAny, spin.TagMode)
+	require.Equal(t, "[]", spin.ExcludeTags)
+	require.Equal(t, int64(1), spin.IncludeUntagged)
+	require.Empty(t, spin.TagExpression)
+}
+
+func lower(tags []string) []string {
+	result := make([]string, len(tags))
+	for i, tag := range tags {
+		result[i] = strings.ToLower(tag)
+	}
+	return result
+}
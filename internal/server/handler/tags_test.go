@@ -0,0 +1,65 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnusedTagsAreDeleted(t *testing.T) {
+	e := newTestEnv(t)
+	status := e.serve(t, e.handler.APICreateTag, http.MethodPost, "/api/v1/tags", "application/json", `{"name": "someday"}`)
+	require.Equal(t, http.StatusCreated, status)
+	e.createOptions(t, `{"name": "Hike", "tags": ["outdoor", "cheap"]}`, `{"name": "Cook", "tags": ["cheap"]}`, `{"name": "Read", "tags": ["indoor"]}`)
+	require.ElementsMatch(t, []string{"someday", "outdoor", "cheap", "indoor"}, e.tagNames(t))
+
+	// A tag taken off one option is kept while another option has it
+	hike := e.optionID(t, "Hike")
+	status = e.serve(t, e.handler.APIUpdateOption, http.MethodPut, "/api/v1/options/"+hike, "application/json", `{"name": "Hike", "tags": ["outdoor"]}`, "id", hike)
+	require.Equal(t, http.StatusOK, status)
+	require.ElementsMatch(t, []string{"someday", "outdoor", "cheap", "indoor"}, e.tagNames(t))
+
+	cook := e.optionID(t, "Cook")
+	status = e.serve(t, e.handler.APIUpdateOption, http.MethodPut, "/api/v1/options/"+cook, "application/json", `{"name": "Cook"}`, "id", cook)
+	require.Equal(t, http.StatusOK, status)
+	require.ElementsMatch(t, []string{"someday", "outdoor", "indoor"}, e.tagNames(t))
+
+	// Deleting an option deletes the tags only it had
+	status = e.serve(t, e.handler.APIDeleteOption, http.MethodDelete, "/api/v1/options/"+hike, "", "", "id", hike)
+	require.Equal(t, http.StatusNoContent, status)
+	require.ElementsMatch(t, []string{"someday", "indoor"}, e.tagNames(t))
+
+	read := e.optionID(t, "Read")
+	status = e.serve(t, e.handler.DeleteOption, http.MethodDelete, "/api/options/"+read, "", "")
+	require.Equal(t, http.StatusOK, status)
+
+	// A tag created on its own is kept until an option uses it
+	require.Equal(t, []string{"someday"}, e.tagNames(t))
+}
+
+func TestImportCountsTagsAfterMergingDuplicates(t *testing.T) {
+	e := newTestEnv(t)
+
+	body := `[
+		{"name": "Hike", "tags": ["Outdoor", "outdoor ", "OUTDOOR", "cheap", "Cheap", "free"]},
+		{"name": "Cook", "tags": ["a", "b", "c", "d", "e", "f"]}
+	]`
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/import", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	status, resp := e.page(t, e.handler.APIImport, r)
+	require.Equal(t, http.StatusOK, status)
+
+	var report handler.APIImportReport
+	require.NoError(t, json.Unmarshal([]byte(resp), &report))
+	require.Len(t, report.Valid, 1)
+	require.Equal(t, []string{"outdoor", "cheap", "free"}, report.Valid[0].Tags)
+	require.Len(t, report.Rejected, 1)
+	require.Equal(t, "Cook", report.Rejected[0].Name)
+
+	require.Equal(t, map[string][]string{"Hike": {"outdoor", "cheap", "free"}}, e.options(t))
+}
@@ -0,0 +1,439 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Piszmog/make-a-decision/internal/availability"
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/Piszmog/make-a-decision/internal/transfer"
+)
+
+// APIImportOption is a row of an import that passed validation
+type APIImportOption struct {
+	Row             int      `json:"row"`
+	Name            string   `json:"name"`
+	Description     *string  `json:"description"`
+	DurationMinutes *int64   `json:"duration_minutes"`
+	Weight          int64    `json:"weight"`
+	Tags            []string `json:"tags"`
+	Links           []string `json:"links"`
+	CooldownMinutes *int64   `json:"cooldown_minutes"`
+	// Availability holds the windows the option can come up in, empty when it is available any time
+	Availability availability.Rules `json:"availability"`
+}
+
+// APIImportRejection is a row of an import that was rejected and why
+type APIImportRejection struct {
+	Row   int    `json:"row"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// APIImportReport describes what an import did, or would do for a preview
+type APIImportReport struct {
+	Format   string               `json:"format"`
+	Total    int                  `json:"total"`
+	Imported int                  `json:"imported"`
+	Valid    []APIImportOption    `json:"valid"`
+	Rejected []APIImportRejection `json:"rejected"`
+}
+
+// importPlan is a validated import ready to be stored
+type importPlan struct {
+	report APIImportReport
+	inputs []optionInput
+}
+
+// planImport validates every row with the same rules as creating a single option
+func planImport(format transfer.Format, rows []transfer.Row) importPlan {
+	plan := importPlan{
+		report: APIImportReport{
+			Format:   string(format),
+			Total:    len(rows),
+			Valid:    []APIImportOption{},
+			Rejected: []APIImportRejection{},
+		},
+	}
+
+	for _, row := range rows {
+		if row.Err != nil {
+			plan.report.Rejected = append(plan.report.Rejected, APIImportRejection{Row: row.Number, Name: row.Option.Name, Error: row.Err.Error()})
+			continue
+		}
+
+		apiInput := APIOptionInput{
+			Name:            row.Option.Name,
+			Description:     &row.Option.Description,
+			DurationMinutes: row.Option.DurationMinutes,
+			Weight:          row.Option.Weight,
+			CooldownMinutes: row.Option.CooldownMinutes,
+			Tags:            row.Option.Tags,
+			Links:           row.Option.Links,
+		}
+		// Windows are written the same way as in the API, so they are validated as API input
+		if len(row.Option.Availability) > 0 {
+			var err error
+			if apiInput.Availability, err = json.Marshal(row.Option.Availability); err != nil {
+				plan.report.Rejected = append(plan.report.Rejected, APIImportRejection{Row: row.Number, Name: row.Option.Name, Error: err.Error()})
+				continue
+			}
+		}
+
+		input, err := apiInput.validate()
+		if err != nil {
+			plan.report.Rejected = append(plan.report.Rejected, APIImportRejection{Row: row.Number, Name: row.Option.Name, Error: err.Error()})
+			continue
+		}
+
+		var duration *int64
+		if d, ok := input.Duration.(int64); ok {
+			duration = &d
+		}
+		rules := optionAvailability(input.Availability)
+		if rules == nil {
+			rules = availability.Rules{}
+		}
+		plan.report.Valid = append(plan.report.Valid, APIImportOption{
+			Row:             row.Number,
+			Name:            input.Name,
+			Description:     nullStringPtr(input.Description),
+			DurationMinutes: duration,
+			Weight:          input.Weight,
+			Tags:            input.Tags,
+			Links:           optionLinks(input.Links),
+			CooldownMinutes: nullInt64Ptr(input.Cooldown),
+			Availability:    rules,
+		})
+		plan.inputs = append(plan.inputs, input)
+	}
+	return plan
+}
+
+// importOptions creates the planned options on the wheel in a single transaction and returns how many were created
+func (h *Handler) importOptions(ctx context.Context, userID, wheelID int64, inputs []optionInput) (int, error) {
+	err := h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		for _, input := range inputs {
+			created, err := q.CreateOption(ctx, queries.CreateOptionParams{
+				Name:            input.Name,
+				Bio:             input.Description,
+				DurationMinutes: input.Duration,
+				Weight:          sql.NullInt64{Int64: input.Weight, Valid: true},
+				CooldownMinutes: input.Cooldown,
+				Availability:    input.Availability,
+				Links:           input.Links,
+				UserID:          userID,
+				WheelID:         wheelID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create option: %w", err)
+			}
+
+			if err := setTagsForOption(ctx, q, created.ID, wheelID, userID, input.Tags); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(inputs), nil
+}
+
+// exportDocument returns the options on a wheel as they are written to a file
+func (h *Handler) exportDocument(ctx context.Context, userID int64, wheel queries.Wheel) (transfer.Document, error) {
+	options, _, err := h.wheelOptions(ctx, wheel.ID, userID)
+	if err != nil {
+		return transfer.Document{}, err
+	}
+
+	doc := transfer.Document{
+		Wheel:   wheel.Name,
+		Options: make([]transfer.Option, len(options)),
+	}
+	for i, opt := range options {
+		weight := opt.Weight
+		doc.Options[i] = transfer.Option{
+			Name:            opt.Text,
+			DurationMinutes: opt.Duration,
+			Weight:          &weight,
+			Tags:            opt.Tags,
+			Description:     opt.Description,
+			Links:           opt.Links,
+			CooldownMinutes: opt.Cooldown,
+			Availability:    availabilityWindows(opt.Availability),
+		}
+	}
+	return doc, nil
+}
+
+// availabilityWindows returns the availability rules as they are written to a file
+func availabilityWindows(rules availability.Rules) []transfer.Window {
+	if len(rules) == 0 {
+		return nil
+	}
+	windows := make([]transfer.Window, len(rules))
+	for i, rule := range rules {
+		windows[i] = transfer.Window{Days: rule.Days.Names()}
+		if !rule.AllDay {
+			windows[i].Start, windows[i].End = rule.Start.String(), rule.End.String()
+		}
+	}
+	return windows
+}
+
+// exportFilename returns the name a wheel's export is downloaded as
+func exportFilename(wheelName string, format transfer.Format) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, wheelName)
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = "wheel"
+	}
+	return name + "-options." + string(format)
+}
+
+// apiImportFormat returns the format of an import body from ?format or the Content-Type header
+func apiImportFormat(r *http.Request) (transfer.Format, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return transfer.ParseFormat(format)
+	}
+	return transfer.FormatFromContentType(r.Header.Get("Content-Type"))
+}
+
+// APIExport handles downloading the options on a wheel as JSON, CSV or YAML
+func (h *Handler) APIExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	format := transfer.FormatJSON
+	if f := r.URL.Query().Get("format"); f != "" {
+		var err error
+		if format, err = transfer.ParseFormat(f); err != nil {
+			h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+			return
+		}
+	}
+
+	wheel, ok := h.apiWheelFromQuery(w, r, userID)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	doc, err := h.exportDocument(ctx, userID, wheel)
+	if err != nil {
+		h.Logger.Error("Failed to get options", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to export options")
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(wheel.Name, format)))
+	w.WriteHeader(http.StatusOK)
+	if err := transfer.Encode(w, format, doc); err != nil {
+		h.Logger.Error("Failed to encode export", "error", err)
+	}
+}
+
+// apiImportPlan reads and validates an import body, sending a JSON error if it cannot be read
+func (h *Handler) apiImportPlan(w http.ResponseWriter, r *http.Request) (importPlan, bool) {
+	format, err := apiImportFormat(r)
+	if err != nil {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return importPlan{}, false
+	}
+
+	rows, err := transfer.Decode(http.MaxBytesReader(w, r.Body, maxAPIRequestBodyBytes), format)
+	if err != nil {
+		h.writeJSONError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return importPlan{}, false
+	}
+	return planImport(format, rows), true
+}
+
+// APIImportPreview handles validating an import without saving anything
+func (h *Handler) APIImportPreview(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireAPIAuth(w, r); !ok {
+		return
+	}
+
+	plan, ok := h.apiImportPlan(w, r)
+	if !ok {
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, plan.report)
+}
+
+// APIImport handles importing the valid rows of a file. Rejected rows are reported and skipped.
+func (h *Handler) APIImport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.requireAPIAuth(w, r)
+	if !ok {
+		return
+	}
+
+	wheel, ok := h.apiWheelFromQuery(w, r, userID)
+	if !ok {
+		return
+	}
+
+	plan, ok := h.apiImportPlan(w, r)
+	if !ok {
+		return
+	}
+
+	imported, err := h.importOptions(r.Context(), userID, wheel.ID, plan.inputs)
+	if err != nil {
+		h.Logger.Error("Failed to import options", "error", err)
+		h.writeJSONError(w, http.StatusInternalServerError, apiErrInternal, "Failed to import options")
+		return
+	}
+
+	h.Logger.Info("Options imported", "wheel_id", wheel.ID, "imported", imported, "rejected", len(plan.report.Rejected))
+	plan.report.Imported = imported
+	h.writeJSON(w, http.StatusOK, plan.report)
+}
+
+// importPlanToAppPlan converts an import plan to the preview shown in the manage modal
+func importPlanToAppPlan(plan importPlan, content string) home.ImportPlan {
+	appPlan := home.ImportPlan{
+		Format:   plan.report.Format,
+		Content:  content,
+		Valid:    make([]home.ImportRow, len(plan.report.Valid)),
+		Rejected: make([]home.ImportRejection, len(plan.report.Rejected)),
+	}
+	for i, row := range plan.report.Valid {
+		appPlan.Valid[i] = home.ImportRow{
+			Row:      row.Row,
+			Name:     row.Name,
+			Duration: row.DurationMinutes,
+			Weight:   row.Weight,
+			Tags:     row.Tags,
+		}
+	}
+	for i, row := range plan.report.Rejected {
+		appPlan.Rejected[i] = home.ImportRejection(row)
+	}
+	return appPlan
+}
+
+// importError sends an error shown as a toast in the manage modal
+func importError(w http.ResponseWriter, message string) {
+	w.Header().Set("HX-Trigger", fmt.Sprintf(`{"error": %q}`, message))
+	http.Error(w, message, http.StatusBadRequest)
+}
+
+// PreviewImport handles an uploaded file from the manage modal, showing what would be imported
+func (h *Handler) PreviewImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := utils.RequireAuth(w, r); !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAPIRequestBodyBytes)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		importError(w, "Choose a file to import")
+		return
+	}
+	defer file.Close()
+
+	format, err := transfer.ParseFormat(filepath.Ext(header.Filename))
+	if err != nil {
+		importError(w, "Files must end in .json, .csv or .yaml")
+		return
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		importError(w, "Failed to read file")
+		return
+	}
+
+	rows, err := transfer.Decode(bytes.NewReader(content), format)
+	if err != nil {
+		importError(w, err.Error())
+		return
+	}
+
+	plan := planImport(format, rows)
+	h.html(r.Context(), w, http.StatusOK, home.ImportPreview(importPlanToAppPlan(plan, string(content))))
+}
+
+// ImportOptions handles confirming an import previewed in the manage modal
+func (h *Handler) ImportOptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*maxAPIRequestBodyBytes)
+	format, err := transfer.ParseFormat(r.FormValue("format"))
+	if err != nil {
+		importError(w, err.Error())
+		return
+	}
+
+	rows, err := transfer.Decode(strings.NewReader(r.FormValue("content")), format)
+	if err != nil {
+		importError(w, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to import options", http.StatusInternalServerError)
+		return
+	}
+
+	plan := planImport(format, rows)
+	imported, err := h.importOptions(ctx, userID, wheel.ID, plan.inputs)
+	if err != nil {
+		h.Logger.Error("Failed to import options", "error", err)
+		http.Error(w, "Failed to import options", http.StatusInternalServerError)
+		return
+	}
+	h.Logger.Info("Options imported", "wheel_id", wheel.ID, "imported", imported, "rejected", len(plan.report.Rejected))
+
+	appOptions, totalWeight, err := h.wheelOptions(ctx, wheel.ID, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get options", "error", err)
+		http.Error(w, "Failed to get options", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", fmt.Sprintf(`{"success": "Imported %d options"}`, imported))
+	h.html(ctx, w, http.StatusOK, home.OptionsListWithWeight(appOptions, totalWeight))
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+// maxVetoLimit is the most vetoes a wheel can allow per round
+const maxVetoLimit = 100
+
+var (
+	errVetoLimit       = errors.New("no vetoes are left for this decision")
+	errVetoed          = errors.New("the decision has already been skipped or vetoed")
+	errVetoMultiPick   = errors.New("only a spin that picked one option can be vetoed")
+	errVetoElimination = errors.New("elimination spins cannot be vetoed")
+	errVetoWheel       = errors.New("the spin is on another wheel")
+	errVetoPending     = errors.New("accept or skip the last decision before picking more than one option")
+)
+
+// veto is a spin being vetoed by a reroll. A round is a decision along with every reroll of it.
+type veto struct {
+	SpinID int64
+	// Number is how many vetoes the round has used up once this one is made
+	Number int64
+	// Options holds every option vetoed so far in the round, none of which the reroll can pick
+	Options map[int64]bool
+}
+
+// options returns the options a reroll cannot pick, or nil when the spin is not a reroll
+func (v *veto) options() map[int64]bool {
+	if v == nil {
+		return nil
+	}
+	return v.Options
+}
+
+// used returns how many vetoes the round has used up, which is none when the spin is not a reroll
+func (v *veto) used() int64 {
+	if v == nil {
+		return 0
+	}
+	return v.Number
+}
+
+// vetoesLeft returns how many more times a decision on the wheel can be vetoed after used vetoes,
+// or nil when the wheel allows any number of them
+func vetoesLeft(wheel queries.Wheel, used int64) *int64 {
+	if !wheel.VetoLimit.Valid {
+		return nil
+	}
+	left := max(wheel.VetoLimit.Int64-used, 0)
+	return &left
+}
+
+// vetoFor checks that the decision made by a spin on the wheel can be vetoed and returns the veto.
+// Returns sql.ErrNoRows when the user has no such spin.
+func (h *Handler) vetoFor(ctx context.Context, userID int64, wheel queries.Wheel, spinID int64) (veto, error) {
+	q := h.Database.Queries()
+	spin, err := q.GetSpin(ctx, queries.GetSpinParams{ID: spinID, UserID: userID})
+	if err != nil {
+		return veto{}, fmt.Errorf("failed to get spin: %w", err)
+	}
+
+	switch {
+	case spin.WheelID.Int64 != wheel.ID:
+		return veto{}, errVetoWheel
+	case spin.PickCount > 1:
+		return veto{}, errVetoMultiPick
+	case spin.RoundID.Valid:
+		return veto{}, errVetoElimination
+	case spin.CompletedAt.Valid:
+		return veto{}, errSpinCompleted
+	case spin.Outcome.String == outcomeSkipped || spin.Outcome.String == outcomeRerolled:
+		return veto{}, errVetoed
+	case wheel.VetoLimit.Valid && spin.VetoNumber >= wheel.VetoLimit.Int64:
+		return veto{}, errVetoLimit
+	}
+
+	// Walk back through the round to every option vetoed before this one
+	number := spin.VetoNumber + 1
+	vetoed := map[int64]bool{}
+	for {
+		if spin.OptionID.Valid {
+			vetoed[spin.OptionID.Int64] = true
+		}
+		if !spin.VetoedSpinID.Valid {
+			break
+		}
+		spin, err = q.GetSpin(ctx, queries.GetSpinParams{ID: spin.VetoedSpinID.Int64, UserID: userID})
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return veto{}, fmt.Errorf("failed to get vetoed spin: %w", err)
+		}
+	}
+
+	return veto{SpinID: spinID, Number: number, Options: vetoed}, nil
+}
+
+// pendingVeto returns the veto a plain spin makes on a wheel with a veto limit, or nil when there is none.
+// Spinning again while the last decision is still undecided rerolls it, so it uses up a veto like any other reroll
+// and is refused once the round has none left. Spins that pick several options cannot replace a single decision.
+func (h *Handler) pendingVeto(ctx context.Context, userID int64, wheel queries.Wheel, count int) (*veto, error) {
+	if !wheel.VetoLimit.Valid {
+		return nil, nil //nolint:nilnil
+	}
+	spin, err := h.Database.Queries().GetLastDecision(ctx, queries.GetLastDecisionParams{
+		WheelID: sql.NullInt64{Int64: wheel.ID, Valid: true},
+		UserID:  userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil //nolint:nilnil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last decision: %w", err)
+	}
+	if spin.Outcome.Valid || spin.CompletedAt.Valid {
+		return nil, nil //nolint:nilnil
+	}
+	if count > 1 {
+		return nil, errVetoPending
+	}
+
+	v, err := h.vetoFor(ctx, userID, wheel, spin.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// UpdateWheelVetoLimit handles setting how many times a decision on the active wheel can be vetoed.
+// A blank limit allows any number of vetoes.
+func (h *Handler) UpdateWheelVetoLimit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var limit sql.NullInt64
+	if value := r.FormValue("veto_limit"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			w.Header().Set("HX-Trigger", `{"error": "Vetoes per round must be a whole number"}`)
+			http.Error(w, "Invalid veto limit", http.StatusBadRequest)
+			return
+		}
+		limit = sql.NullInt64{Int64: max(0, min(parsed, maxVetoLimit)), Valid: true}
+	}
+
+	ctx := r.Context()
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to update vetoes", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Database.Queries().SetWheelVetoLimit(ctx, queries.SetWheelVetoLimitParams{
+		VetoLimit: limit,
+		ID:        wheel.ID,
+		UserID:    userID,
+	}); err != nil {
+		h.Logger.Error("Failed to update wheel veto limit", "error", err)
+		http.Error(w, "Failed to update vetoes", http.StatusInternalServerError)
+		return
+	}
+
+	wheel.VetoLimit = limit
+	h.html(ctx, w, http.StatusOK, home.VetoSettings(dbWheelToAppWheel(wheel), true))
+}
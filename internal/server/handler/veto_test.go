@@ -0,0 +1,184 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/server/handler"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// reroll vetoes the spin and spins the user's active wheel again
+func (e testEnv) reroll(t *testing.T, spinID int64) (int, handler.APISpinResult) {
+	t.Helper()
+	return e.spin(t, `{"veto_spin_id": `+strconv.FormatInt(spinID, 10)+`}`)
+}
+
+// setVetoLimit sets how many vetoes each decision on the active wheel gets through the manage modal form
+func (e testEnv) setVetoLimit(t *testing.T, limit string) {
+	t.Helper()
+	status := e.postForm(t, e.handler.UpdateWheelVetoLimit, "/api/wheels/veto-limit", url.Values{"veto_limit": {limit}})
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestRerollLeavesOutVetoedOptions(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`, `{"name": "Picnic"}`)
+
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, result.VetoesLeft)
+
+	vetoed := map[string]bool{}
+	for number := int64(1); number <= 2; number++ {
+		vetoed[result.Option.Name] = true
+		previous := result.SpinID
+
+		status, result = e.reroll(t, previous)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, result.Eligible, 3-len(vetoed))
+		require.False(t, vetoed[result.Option.Name], "picked %s which was vetoed", result.Option.Name)
+
+		// The veto is logged against the decision it replaced
+		require.Equal(t, "rerolled", e.savedSpin(t, strconv.FormatInt(previous, 10)).Outcome.String)
+		spin := e.savedSpin(t, strconv.FormatInt(result.SpinID, 10))
+		require.Equal(t, previous, spin.VetoedSpinID.Int64)
+		require.Equal(t, number, spin.VetoNumber)
+	}
+
+	// Every option has been vetoed this round
+	status, _ = e.reroll(t, result.SpinID)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+}
+
+func TestVetoLimit(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`, `{"name": "Picnic"}`)
+	e.setVetoLimit(t, "1")
+
+	status, first := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(1), *first.VetoesLeft)
+
+	status, second := e.reroll(t, first.SpinID)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(0), *second.VetoesLeft)
+
+	// The round is out of vetoes, so the reroll sticks
+	status, _ = e.reroll(t, second.SpinID)
+	require.Equal(t, http.StatusConflict, status)
+	require.Empty(t, e.savedSpin(t, strconv.FormatInt(second.SpinID, 10)).Outcome.String)
+
+	// A new decision starts a new round once the last one is settled
+	status = e.serve(t, e.handler.APISetSpinOutcome, http.MethodPost, "/", "application/json", `{"outcome": "skipped"}`, "id", strconv.FormatInt(second.SpinID, 10))
+	require.Equal(t, http.StatusOK, status)
+	status, third := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(1), *third.VetoesLeft)
+
+	// No vetoes at all makes every decision binding, and a blank limit allows any number again
+	e.setVetoLimit(t, "0")
+	status, _ = e.reroll(t, third.SpinID)
+	require.Equal(t, http.StatusConflict, status)
+	e.setVetoLimit(t, "")
+	status, _ = e.reroll(t, third.SpinID)
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestSpinAgainUsesVeto(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`, `{"name": "Picnic"}`)
+	e.setVetoLimit(t, "1")
+
+	status, first := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+
+	// Spinning again without settling the decision rerolls it
+	status, second := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(0), *second.VetoesLeft)
+	require.Len(t, second.Eligible, 2)
+	require.NotEqual(t, first.Option.Name, second.Option.Name)
+	require.Equal(t, "rerolled", e.savedSpin(t, strconv.FormatInt(first.SpinID, 10)).Outcome.String)
+	spin := e.savedSpin(t, strconv.FormatInt(second.SpinID, 10))
+	require.Equal(t, first.SpinID, spin.VetoedSpinID.Int64)
+	require.Equal(t, int64(1), spin.VetoNumber)
+
+	// The round is out of vetoes, so neither a plain spin nor a spin for several options gets past it
+	before := e.spinCount(t, first.WheelID)
+	status, _ = e.spin(t, `{}`)
+	require.Equal(t, http.StatusConflict, status)
+	status, _ = e.spin(t, `{"count": 2}`)
+	require.Equal(t, http.StatusConflict, status)
+	status = e.postForm(t, e.handler.RandomPicker, "/api/random", url.Values{})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, before, e.spinCount(t, first.WheelID))
+	require.Empty(t, e.savedSpin(t, strconv.FormatInt(second.SpinID, 10)).Outcome.String)
+
+	// Accepting the decision settles it, so the next spin is a new decision
+	status = e.serve(t, e.handler.APISetSpinOutcome, http.MethodPost, "/", "application/json", `{"outcome": "accepted"}`, "id", strconv.FormatInt(second.SpinID, 10))
+	require.Equal(t, http.StatusOK, status)
+	status, third := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(1), *third.VetoesLeft)
+	require.Zero(t, e.savedSpin(t, strconv.FormatInt(third.SpinID, 10)).VetoNumber)
+
+	// Without a limit a plain spin leaves the last decision alone
+	e.setVetoLimit(t, "")
+	status, _ = e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, e.savedSpin(t, strconv.FormatInt(third.SpinID, 10)).Outcome.String)
+}
+
+func TestVetoConflicts(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`, `{"name": "Picnic"}`)
+
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = e.reroll(t, result.SpinID)
+	require.Equal(t, http.StatusOK, status)
+
+	// A decision can only be vetoed once, and a vetoed decision cannot be accepted
+	status, _ = e.reroll(t, result.SpinID)
+	require.Equal(t, http.StatusConflict, status)
+	id := strconv.FormatInt(result.SpinID, 10)
+	status = e.serve(t, e.handler.APISetSpinOutcome, http.MethodPost, "/", "application/json", `{"outcome": "accepted"}`, "id", id)
+	require.Equal(t, http.StatusConflict, status)
+
+	status, multi := e.spin(t, `{"count": 2}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(0), *multi.VetoesLeft)
+	status, _ = e.reroll(t, multi.SpinID)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+
+	status, _ = e.reroll(t, 999)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestRerollFromResultCard(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike"}`, `{"name": "Museum"}`)
+	e.setVetoLimit(t, "1")
+
+	status, result := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+
+	values := url.Values{"veto": {strconv.FormatInt(result.SpinID, 10)}}
+	r := httptest.NewRequest(http.MethodPost, "/api/random", nil)
+	r.PostForm = values
+	r = utils.SetUserID(r, e.userID)
+	w := httptest.NewRecorder()
+	e.handler.RandomPicker(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "No vetoes left this round")
+	require.NotContains(t, w.Body.String(), result.Option.Name)
+
+	status = e.postForm(t, e.handler.RandomPicker, "/api/random", values)
+	require.Equal(t, http.StatusConflict, status)
+}
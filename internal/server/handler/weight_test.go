@@ -0,0 +1,107 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/stretchr/testify/require"
+)
+
+// weight returns the weight of the option with the name on the user's active wheel
+func (e testEnv) weight(t *testing.T, name string) int64 {
+	t.Helper()
+	id, err := strconv.ParseInt(e.optionID(t, name), 10, 64)
+	require.NoError(t, err)
+	opt, err := e.db.Queries().GetOption(context.Background(), queries.GetOptionParams{ID: id, UserID: e.userID})
+	require.NoError(t, err)
+	return opt.Weight.Int64
+}
+
+func TestSpinNeverPicksOptionsWeightedZero(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 0}`, `{"name": "Museum", "weight": 100}`, `{"name": "Picnic", "weight": 1}`)
+
+	for _, body := range []string{`{}`, `{"strategy": "uniform"}`, `{"strategy": "elimination"}`, `{"count": 3}`} {
+		status, result := e.spin(t, body)
+		require.Equal(t, http.StatusOK, status, body)
+		require.Len(t, result.Eligible, 2, body)
+		for _, chance := range result.Eligible {
+			require.NotEqual(t, "Hike", chance.Name, body)
+		}
+		require.Empty(t, result.CoolingDown, body)
+	}
+
+	// Once every option is weighted 0 there is nothing to pick
+	e = newTestEnv(t)
+	e.createOptions(t, `{"name": "Hike", "weight": 0}`)
+	status, _ := e.spin(t, `{}`)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+}
+
+func TestCreateOptionWeight(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "never", body: `{"name": "A", "weight": 0}`, status: http.StatusCreated},
+		{name: "heaviest", body: `{"name": "A", "weight": 100}`, status: http.StatusCreated},
+		{name: "negative", body: `{"name": "A", "weight": -1}`, status: http.StatusUnprocessableEntity},
+		{name: "too heavy", body: `{"name": "A", "weight": 101}`, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			status := e.serve(t, e.handler.APICreateOption, http.MethodPost, "/api/v1/options", "application/json", test.body)
+			require.Equal(t, test.status, status)
+		})
+	}
+}
+
+func TestUpdateOptionWeightFromForm(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Favorite"}`)
+	id := e.optionID(t, "Favorite")
+
+	tests := []struct {
+		name     string
+		weight   string
+		expected int64
+	}{
+		{name: "fine grained", weight: "37", expected: 37},
+		{name: "never", weight: "0", expected: 0},
+		{name: "clamped to the heaviest", weight: "250", expected: 100},
+		{name: "clamped to never", weight: "-5", expected: 0},
+		{name: "blank defaults", weight: "", expected: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := e.postForm(t, e.handler.UpdateOptionDetails, "/api/options/update", url.Values{
+				"id":     {id},
+				"text":   {"Favorite"},
+				"weight": {test.weight},
+			})
+			require.Equal(t, http.StatusOK, status)
+			require.Equal(t, test.expected, e.weight(t, "Favorite"))
+		})
+	}
+}
+
+func TestStepWeight(t *testing.T) {
+	e := newTestEnv(t)
+	e.createOptions(t, `{"name": "Light", "weight": 1}`, `{"name": "Heavy", "weight": 99}`)
+	light, heavy := e.optionID(t, "Light"), e.optionID(t, "Heavy")
+
+	for range 2 {
+		require.Equal(t, http.StatusOK, e.serve(t, e.handler.DecreaseWeight, http.MethodPost, "/api/weight/decrease/"+light, "", ""))
+		require.Equal(t, http.StatusOK, e.serve(t, e.handler.IncreaseWeight, http.MethodPost, "/api/weight/increase/"+heavy, "", ""))
+	}
+	require.Equal(t, int64(0), e.weight(t, "Light"))
+	require.Equal(t, int64(100), e.weight(t, "Heavy"))
+}
@@ -0,0 +1,461 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Piszmog/make-a-decision/internal/components/home"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+)
+
+const (
+	// defaultWheelName is the name of the wheel created for users that have none
+	defaultWheelName = "My Wheel"
+	// maxWheelNameLength is the longest name a wheel can be given
+	maxWheelNameLength = 50
+)
+
+// activeWheel returns the wheel the user is working with.
+// Falls back to the user's first wheel, creating one if the user has none.
+func (h *Handler) activeWheel(ctx context.Context, userID int64) (queries.Wheel, error) {
+	wheel, err := h.Database.Queries().GetActiveWheel(ctx, userID)
+	if err == nil {
+		return wheel, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return queries.Wheel{}, fmt.Errorf("failed to get active wheel: %w", err)
+	}
+
+	wheels, err := h.Database.Queries().GetWheels(ctx, userID)
+	if err != nil {
+		return queries.Wheel{}, fmt.Errorf("failed to get wheels: %w", err)
+	}
+
+	if len(wheels) > 0 {
+		wheel = wheels[0]
+	} else {
+		wheel, err = h.Database.Queries().CreateWheel(ctx, queries.CreateWheelParams{
+			UserID: userID,
+			Name:   defaultWheelName,
+		})
+		if err != nil {
+			return queries.Wheel{}, fmt.Errorf("failed to create wheel: %w", err)
+		}
+	}
+
+	if err := h.setActiveWheel(ctx, userID, wheel.ID); err != nil {
+		return queries.Wheel{}, err
+	}
+	return wheel, nil
+}
+
+// setActiveWheel switches the wheel the user is working with
+func (h *Handler) setActiveWheel(ctx context.Context, userID, wheelID int64) error {
+	if err := h.Database.Queries().SetActiveWheel(ctx, queries.SetActiveWheelParams{
+		ActiveWheelID: sql.NullInt64{Int64: wheelID, Valid: true},
+		ID:            userID,
+	}); err != nil {
+		return fmt.Errorf("failed to set active wheel: %w", err)
+	}
+	return nil
+}
+
+// nullWheelID wraps a wheel ID for the nullable spins.wheel_id column
+func nullWheelID(wheelID int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: wheelID, Valid: true}
+}
+
+// wheelOptions returns the options on a wheel along with their total weight
+func (h *Handler) wheelOptions(ctx context.Context, wheelID, userID int64) ([]home.Option, int64, error) {
+	options, err := h.taggedOptions(ctx, wheelID, userID, noTagFilter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	appOptions := make([]home.Option, len(options))
+	for i, opt := range options {
+		appOptions[i] = optionToAppOption(opt.Option, opt.Tags)
+	}
+
+	var totalWeight int64
+	for _, opt := range appOptions {
+		totalWeight += opt.Weight
+	}
+
+	if err := h.setEffectiveChances(ctx, userID, wheelID, appOptions); err != nil {
+		return nil, 0, err
+	}
+
+	return appOptions, totalWeight, nil
+}
+
+// activeOptions returns the options on the user's active wheel along with their total weight
+func (h *Handler) activeOptions(ctx context.Context, userID int64) ([]home.Option, int64, error) {
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return h.wheelOptions(ctx, wheel.ID, userID)
+}
+
+// wheelList returns the user's wheels for display
+func (h *Handler) wheelList(ctx context.Context, userID int64) ([]home.Wheel, error) {
+	wheels, err := h.Database.Queries().GetWheels(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wheels: %w", err)
+	}
+
+	appWheels := make([]home.Wheel, len(wheels))
+	for i, wheel := range wheels {
+		appWheels[i] = dbWheelToAppWheel(wheel)
+	}
+	return appWheels, nil
+}
+
+// dbWheelToAppWheel converts SQLC queries.Wheel to app home.Wheel
+func dbWheelToAppWheel(wheel queries.Wheel) home.Wheel {
+	appWheel := home.Wheel{
+		ID:   strconv.FormatInt(wheel.ID, 10),
+		Name: wheel.Name,
+	}
+	if wheel.DecayHalfLifeMinutes.Valid {
+		appWheel.DecayHalfLife = &wheel.DecayHalfLifeMinutes.Int64
+	}
+	if wheel.VetoLimit.Valid {
+		appWheel.VetoLimit = &wheel.VetoLimit.Int64
+	}
+	return appWheel
+}
+
+// parseWheelName reads and validates a wheel name. The name is taken from the form,
+// falling back to the htmx prompt.
+func parseWheelName(r *http.Request) (string, bool) {
+	name := r.FormValue("name")
+	if name == "" {
+		name = r.Header.Get("HX-Prompt")
+	}
+	name = strings.TrimSpace(name)
+	return name, name != "" && len([]rune(name)) <= maxWheelNameLength
+}
+
+// CreateWheel handles creating a new wheel and switching to it
+func (h *Handler) CreateWheel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	name, ok := parseWheelName(r)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Wheel name must be 1-%d characters", maxWheelNameLength), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	wheel, err := h.Database.Queries().CreateWheel(ctx, queries.CreateWheelParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		h.Logger.Error("Failed to create wheel", "error", err)
+		http.Error(w, "Failed to create wheel", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.setActiveWheel(ctx, userID, wheel.ID); err != nil {
+		h.Logger.Error("Failed to switch wheel", "error", err)
+		http.Error(w, "Failed to switch wheel", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Wheel created", "id", wheel.ID, "name", name)
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// SwitchWheel handles changing the active wheel
+func (h *Handler) SwitchWheel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	wheelID, err := stringToInt64(r.FormValue("wheel_id"))
+	if err != nil {
+		http.Error(w, "Invalid wheel ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	wheel, err := h.Database.Queries().GetWheel(ctx, queries.GetWheelParams{
+		ID:     wheelID,
+		UserID: userID,
+	})
+	if err != nil {
+		http.Error(w, "Wheel not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.setActiveWheel(ctx, userID, wheel.ID); err != nil {
+		h.Logger.Error("Failed to switch wheel", "error", err)
+		http.Error(w, "Failed to switch wheel", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// RenameWheel handles renaming the active wheel
+func (h *Handler) RenameWheel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	name, ok := parseWheelName(r)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Wheel name must be 1-%d characters", maxWheelNameLength), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	wheel, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to rename wheel", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Database.Queries().RenameWheel(ctx, queries.RenameWheelParams{
+		Name:   name,
+		ID:     wheel.ID,
+		UserID: userID,
+	}); err != nil {
+		h.Logger.Error("Failed to rename wheel", "error", err)
+		http.Error(w, "Failed to rename wheel", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Wheel renamed", "id", wheel.ID, "name", name)
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// DuplicateWheel handles copying the active wheel, along with its options, tags and adaptive weights, and switching to the copy
+func (h *Handler) DuplicateWheel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	source, err := h.activeWheel(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get active wheel", "error", err)
+		http.Error(w, "Failed to duplicate wheel", http.StatusInternalServerError)
+		return
+	}
+
+	name := []rune("Copy of " + source.Name)
+	if len(name) > maxWheelNameLength {
+		name = name[:maxWheelNameLength]
+	}
+
+	// Copy the wheel and every option on it, or nothing at all. The options are read in the transaction too, so an
+	// image is never shared with a copy after the last option using it has let it go.
+	var wheel queries.Wheel
+	var options []taggedOption
+	err = h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		var err error
+		options, err = optionsWithTags(ctx, q, source.ID, userID, noTagFilter)
+		if err != nil {
+			return err
+		}
+		wheel, err = q.CreateWheel(ctx, queries.CreateWheelParams{
+			UserID: userID,
+			Name:   string(name),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create wheel: %w", err)
+		}
+		if source.DecayHalfLifeMinutes.Valid {
+			if err := q.SetWheelDecay(ctx, queries.SetWheelDecayParams{
+				DecayHalfLifeMinutes: source.DecayHalfLifeMinutes,
+				ID:                   wheel.ID,
+				UserID:               userID,
+			}); err != nil {
+				return fmt.Errorf("failed to copy adaptive weights: %w", err)
+			}
+		}
+		if source.VetoLimit.Valid {
+			if err := q.SetWheelVetoLimit(ctx, queries.SetWheelVetoLimitParams{
+				VetoLimit: source.VetoLimit,
+				ID:        wheel.ID,
+				UserID:    userID,
+			}); err != nil {
+				return fmt.Errorf("failed to copy veto limit: %w", err)
+			}
+		}
+
+		for _, opt := range options {
+			created, err := q.CreateOption(ctx, queries.CreateOptionParams{
+				Name:            opt.Name,
+				Bio:             opt.Bio,
+				DurationMinutes: opt.DurationMinutes,
+				Weight:          opt.Weight,
+				CooldownMinutes: opt.CooldownMinutes,
+				Availability:    opt.Availability,
+				Links:           opt.Links,
+				ImageKey:        opt.ImageKey,
+				UserID:          userID,
+				WheelID:         wheel.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to copy option %d: %w", opt.ID, err)
+			}
+			if err := setTagsForOption(ctx, q, created.ID, wheel.ID, userID, opt.Tags); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		h.Logger.Error("Failed to duplicate wheel", "error", err)
+		http.Error(w, "Failed to duplicate wheel", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.setActiveWheel(ctx, userID, wheel.ID); err != nil {
+		h.Logger.Error("Failed to switch wheel", "error", err)
+		http.Error(w, "Failed to switch wheel", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Wheel duplicated", "source_id", source.ID, "id", wheel.ID, "options", len(options))
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteWheel handles deleting a wheel along with its options, tags and history
+func (h *Handler) DeleteWheel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := utils.RequireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	wheelID, err := stringToInt64(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid wheel ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	wheels, err := h.Database.Queries().GetWheels(ctx, userID)
+	if err != nil {
+		h.Logger.Error("Failed to get wheels", "error", err)
+		http.Error(w, "Failed to delete wheel", http.StatusInternalServerError)
+		return
+	}
+
+	var remaining []queries.Wheel
+	found := false
+	for _, wheel := range wheels {
+		if wheel.ID == wheelID {
+			found = true
+			continue
+		}
+		remaining = append(remaining, wheel)
+	}
+	if !found {
+		http.Error(w, "Wheel not found", http.StatusNotFound)
+		return
+	}
+	if len(remaining) == 0 {
+		http.Error(w, "Cannot delete your only wheel", http.StatusBadRequest)
+		return
+	}
+
+	// Remove everything on the wheel before the wheel itself, all or nothing
+	var imageKeys []sql.NullString
+	err = h.Database.WithTx(ctx, func(q *queries.Queries) error {
+		var err error
+		if imageKeys, err = q.GetImageKeysForWheel(ctx, queries.GetImageKeysForWheelParams{WheelID: wheelID, UserID: userID}); err != nil {
+			return fmt.Errorf("failed to get images for wheel: %w", err)
+		}
+		if err := q.ClearOptionTagsForWheel(ctx, queries.ClearOptionTagsForWheelParams{WheelID: wheelID, UserID: userID}); err != nil {
+			return fmt.Errorf("failed to clear option tags for wheel: %w", err)
+		}
+		if err := q.DeleteOptionsForWheel(ctx, queries.DeleteOptionsForWheelParams{WheelID: wheelID, UserID: userID}); err != nil {
+			return fmt.Errorf("failed to delete options for wheel: %w", err)
+		}
+		if err := q.DeleteTagsForWheel(ctx, queries.DeleteTagsForWheelParams{WheelID: wheelID, UserID: userID}); err != nil {
+			return fmt.Errorf("failed to delete tags for wheel: %w", err)
+		}
+		if err := q.DeleteSpinsForWheel(ctx, queries.DeleteSpinsForWheelParams{WheelID: nullWheelID(wheelID), UserID: userID}); err != nil {
+			return fmt.Errorf("failed to delete spins for wheel: %w", err)
+		}
+		if err := q.DeleteEliminationRound(ctx, queries.DeleteEliminationRoundParams{UserID: userID, WheelID: wheelID}); err != nil {
+			return fmt.Errorf("failed to delete elimination round for wheel: %w", err)
+		}
+		if err := q.DeleteSpinProofsForWheel(ctx, queries.DeleteSpinProofsForWheelParams{WheelID: wheelID, UserID: userID}); err != nil {
+			return fmt.Errorf("failed to delete spin proofs for wheel: %w", err)
+		}
+		if err := q.DeleteWheel(ctx, queries.DeleteWheelParams{ID: wheelID, UserID: userID}); err != nil {
+			return fmt.Errorf("failed to delete wheel: %w", err)
+		}
+
+		// Switch to another wheel if the active one was deleted
+		active, err := q.GetActiveWheel(ctx, userID)
+		if err != nil || active.ID == wheelID {
+			if err := q.SetActiveWheel(ctx, queries.SetActiveWheelParams{
+				ActiveWheelID: nullWheelID(remaining[0].ID),
+				ID:            userID,
+			}); err != nil {
+				return fmt.Errorf("failed to switch wheel: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		h.Logger.Error("Failed to delete wheel", "error", err)
+		http.Error(w, "Failed to delete wheel", http.StatusInternalServerError)
+		return
+	}
+	h.releaseImages(ctx, imageKeys...)
+
+	h.Logger.Info("Wheel deleted", "id", wheelID)
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,77 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/stretchr/testify/require"
+)
+
+// failOnWheelDelete makes deleting a wheel fail, after everything on it was deleted
+const failOnWheelDelete = `
+CREATE TRIGGER inject_wheel_failure BEFORE DELETE ON wheels
+BEGIN
+  SELECT RAISE(ABORT, 'injected failure');
+END;`
+
+// wheelToDelete sets up an active wheel with an option and a spin on it, along with a second wheel, and returns
+// the active wheel's ID
+func (e testEnv) wheelToDelete(t *testing.T) int64 {
+	t.Helper()
+	e.createOptions(t, `{"name": "Hike", "tags": ["outdoor"]}`)
+	status, _ := e.spin(t, `{}`)
+	require.Equal(t, http.StatusOK, status)
+	first := e.wheelID(t)
+
+	status = e.postForm(t, e.handler.CreateWheel, "/api/wheels", url.Values{"name": {"Dinner"}})
+	require.Equal(t, http.StatusOK, status)
+	status = e.postForm(t, e.handler.SwitchWheel, "/api/wheels/switch", url.Values{"wheel_id": {strconv.FormatInt(first, 10)}})
+	require.Equal(t, http.StatusOK, status)
+	return first
+}
+
+// spinCount returns how many spins are saved for the wheel
+func (e testEnv) spinCount(t *testing.T, wheelID int64) int64 {
+	t.Helper()
+	count, err := e.db.Queries().CountSpins(context.Background(), queries.CountSpinsParams{
+		WheelID: sql.NullInt64{Int64: wheelID, Valid: true},
+		UserID:  e.userID,
+	})
+	require.NoError(t, err)
+	return count
+}
+
+func TestDeleteWheel(t *testing.T) {
+	e := newTestEnv(t)
+	first := e.wheelToDelete(t)
+
+	id := strconv.FormatInt(first, 10)
+	status := e.serve(t, e.handler.DeleteWheel, http.MethodDelete, "/api/wheels/"+id, "", "", "id", id)
+	require.Equal(t, http.StatusOK, status)
+
+	// The user is switched to the wheel that is left
+	require.NotEqual(t, first, e.wheelID(t))
+	require.Zero(t, e.spinCount(t, first))
+}
+
+func TestDeleteWheelIsAllOrNothing(t *testing.T) {
+	e := newTestEnv(t)
+	first := e.wheelToDelete(t)
+
+	_, err := e.db.DB().Exec(failOnWheelDelete)
+	require.NoError(t, err)
+
+	id := strconv.FormatInt(first, 10)
+	status := e.serve(t, e.handler.DeleteWheel, http.MethodDelete, "/api/wheels/"+id, "", "", "id", id)
+	require.Equal(t, http.StatusInternalServerError, status)
+
+	// Nothing on the wheel was deleted and it is still the active wheel
+	require.Equal(t, first, e.wheelID(t))
+	require.Equal(t, map[string][]string{"Hike": {"outdoor"}}, e.options(t))
+	require.Equal(t, int64(1), e.spinCount(t, first))
+}
@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Piszmog/make-a-decision/internal/db"
@@ -20,12 +22,28 @@ const (
 type UserContextMiddleware struct {
 	Logger   *slog.Logger
 	Database db.Database
+	// Now tells the time sessions expire against. Defaults to time.Now.
+	Now func() time.Time
 }
 
-// Middleware adds user context to requests if they have a valid session
+// now returns the current time from the middleware's clock
+func (m *UserContextMiddleware) now() time.Time {
+	if m.Now == nil {
+		return time.Now()
+	}
+	return m.Now()
+}
+
+// Middleware adds user context to requests if they have a valid session or API token
 // Routes remain public - this just enriches the request with user info
 func (m *UserContextMiddleware) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// API tokens take precedence over the session cookie
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			m.authenticateToken(w, r, next, strings.TrimSpace(token))
+			return
+		}
+
 		cookie, err := r.Cookie("session")
 		if err != nil {
 			// No session cookie - user is not signed in, continue anyway
@@ -45,7 +63,8 @@ func (m *UserContextMiddleware) Middleware(next http.Handler) http.Handler {
 		}
 
 		// Check if session expired
-		if session.ExpiresAt.Before(time.Now()) {
+		now := m.now()
+		if session.ExpiresAt.Before(now) {
 			m.Logger.DebugContext(r.Context(), "session expired", "session_id", session.ID)
 			_ = m.Database.Queries().DeleteSessionByToken(r.Context(), cookie.Value)
 			utils.ClearSessionCookie(w)
@@ -54,10 +73,10 @@ func (m *UserContextMiddleware) Middleware(next http.Handler) http.Handler {
 		}
 
 		// Session is valid - refresh if needed
-		timeUntilExpiry := time.Until(session.ExpiresAt)
+		timeUntilExpiry := session.ExpiresAt.Sub(now)
 		if timeUntilExpiry > 0 && timeUntilExpiry < sessionRefreshWindow {
 			m.Logger.DebugContext(r.Context(), "refreshing session", "user_id", session.UserID)
-			newExpiry := time.Now().Add(SessionDuration)
+			newExpiry := now.Add(SessionDuration)
 			err = m.Database.Queries().UpdateSessionExpiresAt(r.Context(), queries.UpdateSessionExpiresAtParams{
 				Token:     session.Token,
 				ExpiresAt: newExpiry,
@@ -86,3 +105,51 @@ func (m *UserContextMiddleware) Middleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// authenticateToken adds user context to requests made with an API token.
+// Unlike sessions, a bad token is rejected rather than treated as signed out.
+func (m *UserContextMiddleware) authenticateToken(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	apiToken, err := m.Database.Queries().GetAPITokenByHash(r.Context(), utils.HashAPIToken(token))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			m.Logger.ErrorContext(r.Context(), "failed to get api token", "err", err)
+		}
+		writeAuthError(w, http.StatusUnauthorized, "unauthorized", "Invalid API token")
+		return
+	}
+
+	// Read-only tokens can only make safe requests
+	if apiToken.Scope != utils.TokenScopeWrite && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeAuthError(w, http.StatusForbidden, "forbidden", "API token is read-only")
+		return
+	}
+
+	user, err := m.Database.Queries().GetUserByID(r.Context(), apiToken.UserID)
+	if err != nil {
+		m.Logger.ErrorContext(r.Context(), "failed to get user", "err", err)
+		writeAuthError(w, http.StatusUnauthorized, "unauthorized", "Invalid API token")
+		return
+	}
+
+	if err = m.Database.Queries().TouchAPIToken(r.Context(), queries.TouchAPITokenParams{
+		LastUsedAt: sql.NullTime{Time: m.now(), Valid: true},
+		ID:         apiToken.ID,
+	}); err != nil {
+		m.Logger.ErrorContext(r.Context(), "failed to update api token last used", "err", err)
+	}
+
+	r.Header.Set("USER-EMAIL", user.Email)
+	r = utils.SetUserID(r, user.ID)
+	r = utils.SetTokenScope(r, apiToken.Scope)
+
+	next.ServeHTTP(w, r)
+}
+
+// writeAuthError writes an authentication failure in the JSON API error format
+func writeAuthError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]map[string]string{
+		"error": {"code": code, "message": message},
+	})
+}
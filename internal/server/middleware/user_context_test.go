@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Piszmog/make-a-decision/internal/db"
+	"github.com/Piszmog/make-a-decision/internal/db/queries"
+	"github.com/Piszmog/make-a-decision/internal/server/middleware"
+	"github.com/Piszmog/make-a-decision/internal/server/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionExpiry(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		signedIn  bool
+		refreshed bool
+	}{
+		{name: "valid session", expiresAt: now.Add(3 * 24 * time.Hour), signedIn: true},
+		{name: "refreshed near expiry", expiresAt: now.Add(time.Hour), signedIn: true, refreshed: true},
+		{name: "expires right now", expiresAt: now, signedIn: true},
+		{name: "expired session", expiresAt: now.Add(-time.Minute)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			database, err := db.New(logger, filepath.Join(t.TempDir(), "test.sqlite3"))
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = database.Close() })
+			require.NoError(t, db.Migrate(database))
+
+			ctx := context.Background()
+			user, err := database.Queries().CreateUser(ctx, queries.CreateUserParams{Email: "a@example.com", PasswordHash: "x"})
+			require.NoError(t, err)
+			require.NoError(t, database.Queries().InsertSession(ctx, queries.InsertSessionParams{UserID: user.ID, Token: "token", ExpiresAt: test.expiresAt}))
+
+			m := &middleware.UserContextMiddleware{Logger: logger, Database: database, Now: func() time.Time { return now }}
+			var userID int64
+			var signedIn bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, signedIn = utils.GetUserID(r)
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&http.Cookie{Name: "session", Value: "token"})
+			w := httptest.NewRecorder()
+			m.Middleware(next).ServeHTTP(w, r)
+
+			require.Equal(t, test.signedIn, signedIn)
+			if test.signedIn {
+				require.Equal(t, user.ID, userID)
+			}
+
+			cookies := w.Result().Cookies()
+			session, err := database.Queries().GetSessionByToken(ctx, "token")
+			switch {
+			case !test.signedIn:
+				// The session is thrown away and the cookie cleared
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				require.Len(t, cookies, 1)
+				require.Empty(t, cookies[0].Value)
+				require.Negative(t, cookies[0].MaxAge)
+			case test.refreshed:
+				require.NoError(t, err)
+				require.True(t, now.Add(middleware.SessionDuration).Equal(session.ExpiresAt))
+				require.Len(t, cookies, 1)
+				require.Equal(t, "token", cookies[0].Value)
+				require.True(t, session.ExpiresAt.Equal(cookies[0].Expires))
+			default:
+				require.NoError(t, err)
+				require.True(t, test.expiresAt.Equal(session.ExpiresAt))
+				require.Empty(t, cookies)
+			}
+		})
+	}
+}
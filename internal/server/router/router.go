@@ -3,22 +3,81 @@ package router
 import (
 	"github.com/Piszmog/make-a-decision/internal/db"
 	"github.com/Piszmog/make-a-decision/internal/dist"
+	"github.com/Piszmog/make-a-decision/internal/images"
+	"github.com/Piszmog/make-a-decision/internal/selection"
 	"github.com/Piszmog/make-a-decision/internal/server/handler"
 	"github.com/Piszmog/make-a-decision/internal/server/middleware"
 	"log/slog"
 	"net/http"
+	"time"
 )
 
-func New(logger *slog.Logger, database db.Database) http.Handler {
+// DefaultSpinDelay is how long a spin from the page takes unless WithSpinDelay changes it
+const DefaultSpinDelay = 800 * time.Millisecond
+
+// config is what the options configure the routes with.
+type config struct {
+	random    selection.Source
+	now       func() time.Time
+	spinDelay time.Duration
+	images    images.Store
+}
+
+// Option represents a router option.
+type Option func(*config)
+
+// WithRandom sets the source spins get their randomness from.
+func WithRandom(src selection.Source) Option {
+	return func(c *config) {
+		c.random = src
+	}
+}
+
+// WithClock sets the clock used for spins and session expiry.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
+
+// WithSpinDelay sets how long a spin from the page takes.
+func WithSpinDelay(delay time.Duration) Option {
+	return func(c *config) {
+		c.spinDelay = delay
+	}
+}
+
+// WithImageStore sets where uploaded option images are stored. Images are stored in the database unless this is set.
+func WithImageStore(store images.Store) Option {
+	return func(c *config) {
+		c.images = store
+	}
+}
+
+func New(logger *slog.Logger, database db.Database, opts ...Option) http.Handler {
+	cfg := config{
+		random:    selection.Random,
+		now:       time.Now,
+		spinDelay: DefaultSpinDelay,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	h := &handler.Handler{
-		Logger:   logger,
-		Database: database,
+		Logger:    logger,
+		Database:  database,
+		Random:    cfg.random,
+		Now:       cfg.now,
+		SpinDelay: cfg.spinDelay,
+		Images:    cfg.images,
 	}
 
 	// Create user context middleware
 	userContextMiddleware := &middleware.UserContextMiddleware{
 		Logger:   logger,
 		Database: database,
+		Now:      cfg.now,
 	}
 
 	mux := http.NewServeMux()
@@ -34,12 +93,85 @@ func New(logger *slog.Logger, database db.Database) http.Handler {
 	mux.HandleFunc(newPath(http.MethodGet, "/manage/options"), h.GetOptions)
 	mux.HandleFunc(newPath(http.MethodPost, "/api/options"), h.AddOption)
 	mux.HandleFunc(newPath(http.MethodPost, "/api/options/update"), h.UpdateOptionDetails)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/options/description-preview"), h.PreviewOptionDescription)
 	mux.HandleFunc(newPath(http.MethodGet, "/expand-option/"), h.ExpandOption)
 	mux.HandleFunc(newPath(http.MethodGet, "/collapse-option/"), h.CollapseOption)
 	mux.HandleFunc(newPath(http.MethodPost, "/api/weight/increase/"), h.IncreaseWeight)
 	mux.HandleFunc(newPath(http.MethodPost, "/api/weight/decrease/"), h.DecreaseWeight)
 	mux.HandleFunc(newPath(http.MethodDelete, "/api/options/"), h.DeleteOption)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/options/{id}/image"), h.UploadOptionImage)
+	mux.HandleFunc(newPath(http.MethodDelete, "/api/options/{id}/image"), h.RemoveOptionImage)
 	mux.HandleFunc(newPath(http.MethodGet, "/close-modal"), h.CloseModal)
+	mux.HandleFunc(newPath(http.MethodPost, "/options/import/preview"), h.PreviewImport)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/options/import"), h.ImportOptions)
+
+	// Wheels
+	mux.HandleFunc(newPath(http.MethodPost, "/api/wheels"), h.CreateWheel)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/wheels/switch"), h.SwitchWheel)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/wheels/rename"), h.RenameWheel)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/wheels/duplicate"), h.DuplicateWheel)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/wheels/decay"), h.UpdateWheelDecay)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/wheels/veto-limit"), h.UpdateWheelVetoLimit)
+	mux.HandleFunc(newPath(http.MethodDelete, "/api/wheels/{id}"), h.DeleteWheel)
+
+	// Account settings and API tokens
+	mux.HandleFunc(newPath(http.MethodGet, "/settings"), h.SettingsPage)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/tokens"), h.CreateAPIToken)
+	mux.HandleFunc(newPath(http.MethodDelete, "/api/tokens/{id}"), h.RevokeAPIToken)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/settings/time-zone"), h.UpdateTimeZone)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/settings/learn-from-ratings"), h.UpdateLearnFromRatings)
+
+	// Decision history
+	mux.HandleFunc(newPath(http.MethodGet, "/history"), h.History)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/spins/{id}/outcome"), h.SetSpinOutcome)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/spins/{id}/completion"), h.CompleteSpin)
+
+	// Provably fair spins
+	mux.HandleFunc(newPath(http.MethodGet, "/fairness/commitment"), h.SeedCommitment)
+	mux.HandleFunc(newPath(http.MethodPost, "/fairness/commitment"), h.CreateSeedCommitment)
+	mux.HandleFunc(newPath(http.MethodGet, "/verify"), h.VerifyPage)
+	mux.HandleFunc(newPath(http.MethodPost, "/verify"), h.Verify)
+
+	// Odds report
+	mux.HandleFunc(newPath(http.MethodGet, "/odds"), h.OddsPage)
+
+	// Elimination rounds
+	mux.HandleFunc(newPath(http.MethodGet, "/elimination"), h.EliminationRound)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/elimination/reset"), h.ResetEliminationRound)
+
+	// JSON API
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/openapi.yaml"), h.OpenAPI)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/wheels"), h.APIListWheels)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/options"), h.APIListOptions)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/v1/options"), h.APICreateOption)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/options/{id}"), h.APIGetOption)
+	mux.HandleFunc(newPath(http.MethodPut, "/api/v1/options/{id}"), h.APIUpdateOption)
+	mux.HandleFunc(newPath(http.MethodDelete, "/api/v1/options/{id}"), h.APIDeleteOption)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/options/{id}/image"), h.APIGetOptionImage)
+	mux.HandleFunc(newPath(http.MethodPut, "/api/v1/options/{id}/image"), h.APIPutOptionImage)
+	mux.HandleFunc(newPath(http.MethodDelete, "/api/v1/options/{id}/image"), h.APIDeleteOptionImage)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/options/{id}/image/thumbnail"), h.APIGetOptionThumbnail)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/tags"), h.APIListTags)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/v1/tags"), h.APICreateTag)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/tags/{id}"), h.APIGetTag)
+	mux.HandleFunc(newPath(http.MethodPut, "/api/v1/tags/{id}"), h.APIUpdateTag)
+	mux.HandleFunc(newPath(http.MethodDelete, "/api/v1/tags/{id}"), h.APIDeleteTag)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/spins"), h.APIListSpins)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/v1/spins"), h.APISpinWheel)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/v1/spins/{id}/outcome"), h.APISetSpinOutcome)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/v1/spins/{id}/completion"), h.APICompleteSpin)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/v1/simulations"), h.APISimulateSpins)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/elimination"), h.APIGetEliminationRound)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/fairness"), h.APIGetCommitment)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/v1/fairness"), h.APICreateCommitment)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/proofs/{id}"), h.APIGetProof)
+	mux.HandleFunc(newPath(http.MethodDelete, "/api/v1/elimination"), h.APIResetEliminationRound)
+	mux.HandleFunc(newPath(http.MethodGet, "/api/v1/export"), h.APIExport)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/v1/import/preview"), h.APIImportPreview)
+	mux.HandleFunc(newPath(http.MethodPost, "/api/v1/import"), h.APIImport)
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		mux.HandleFunc(newPath(method, "/api/v1/"), h.APINotFound)
+	}
 
 	// Authentication endpoints
 	mux.HandleFunc(newPath(http.MethodGet, "/signin"), h.SigninPage)
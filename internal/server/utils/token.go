@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+)
+
+// API token scopes
+const (
+	TokenScopeRead  = "read"
+	TokenScopeWrite = "write"
+)
+
+const (
+	// apiTokenPrefix marks a string as an API token so it is easy to spot if leaked
+	apiTokenPrefix = "mad_"
+	// apiTokenDisplayLength is how much of a token is kept to tell tokens apart
+	apiTokenDisplayLength = len(apiTokenPrefix) + 8
+)
+
+const tokenScopeContextKey contextKey = "token_scope"
+
+// GenerateAPIToken returns a new random API token along with the prefix shown to identify it
+func GenerateAPIToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, token[:apiTokenDisplayLength], nil
+}
+
+// HashAPIToken returns the hash an API token is stored and looked up by
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetTokenScope marks the request as authenticated by an API token with the given scope
+func SetTokenScope(r *http.Request, scope string) *http.Request {
+	ctx := context.WithValue(r.Context(), tokenScopeContextKey, scope)
+	return r.WithContext(ctx)
+}
+
+// GetTokenScope returns the scope of the API token the request was authenticated with.
+// Returns false if the request was not authenticated with a token.
+func GetTokenScope(r *http.Request) (string, bool) {
+	scope, ok := r.Context().Value(tokenScopeContextKey).(string)
+	return scope, ok
+}
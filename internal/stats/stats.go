@@ -0,0 +1,129 @@
+// Package stats checks whether picks follow the chances they were made with.
+//
+// Picks are compared with their chances using Pearson's chi-square goodness of fit test. The statistic sums
+// (observed - expected)² / expected over every option, and the p-value is the chance of a statistic at least that
+// large if the picks really did follow the chances. A small p-value means the picks are hard to explain by luck.
+package stats
+
+import "math"
+
+// Category is an outcome with how often it came up and the chance it had of coming up each time
+type Category struct {
+	Observed    int64
+	Probability float64
+}
+
+// ChiSquareResult is the outcome of a chi-square goodness of fit test
+type ChiSquareResult struct {
+	Statistic        float64
+	DegreesOfFreedom int
+	PValue           float64
+}
+
+// ChiSquare tests whether the observed counts follow the probabilities. Categories without a chance of coming up
+// are left out of the test. With fewer than two categories left, or nothing observed, there is nothing to test and
+// the p-value is 1.
+func ChiSquare(categories []Category) ChiSquareResult {
+	var total int64
+	var tested []Category
+	for _, category := range categories {
+		if category.Probability > 0 {
+			tested = append(tested, category)
+			total += category.Observed
+		}
+	}
+	if len(tested) < 2 || total == 0 {
+		return ChiSquareResult{PValue: 1}
+	}
+
+	// The probabilities are scaled to the categories being tested so the expected counts add up to the total
+	var probabilities float64
+	for _, category := range tested {
+		probabilities += category.Probability
+	}
+
+	var statistic float64
+	for _, category := range tested {
+		expected := float64(total) * category.Probability / probabilities
+		diff := float64(category.Observed) - expected
+		statistic += diff * diff / expected
+	}
+
+	df := len(tested) - 1
+	return ChiSquareResult{
+		Statistic:        statistic,
+		DegreesOfFreedom: df,
+		PValue:           ChiSquarePValue(statistic, df),
+	}
+}
+
+// ChiSquarePValue returns the chance of a chi-square statistic at least as large as the one given
+func ChiSquarePValue(statistic float64, df int) float64 {
+	if df < 1 {
+		return 1
+	}
+	if statistic <= 0 {
+		return 1
+	}
+	return upperGamma(float64(df)/2, statistic/2)
+}
+
+const (
+	gammaIterations = 500
+	gammaEpsilon    = 1e-15
+	// gammaTiny stands in for zero in the continued fraction to avoid dividing by it
+	gammaTiny = 1e-300
+)
+
+// upperGamma returns the regularized upper incomplete gamma function Q(a, x). The series converges quickly below
+// a + 1 and the continued fraction above it.
+func upperGamma(a, x float64) float64 {
+	if x < a+1 {
+		return 1 - lowerGammaSeries(a, x)
+	}
+	return upperGammaFraction(a, x)
+}
+
+// lowerGammaSeries returns the regularized lower incomplete gamma function P(a, x) from its series
+func lowerGammaSeries(a, x float64) float64 {
+	lgamma, _ := math.Lgamma(a)
+	term := 1 / a
+	sum := term
+	for n := 1; n < gammaIterations; n++ {
+		term *= x / (a + float64(n))
+		sum += term
+		if math.Abs(term) < math.Abs(sum)*gammaEpsilon {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-lgamma)
+}
+
+// upperGammaFraction returns the regularized upper incomplete gamma function Q(a, x) from its continued fraction,
+// evaluated with the modified Lentz method
+func upperGammaFraction(a, x float64) float64 {
+	lgamma, _ := math.Lgamma(a)
+	b := x + 1 - a
+	c := 1 / gammaTiny
+	d := 1 / b
+	h := d
+	for n := 1; n < gammaIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < gammaTiny {
+			d = gammaTiny
+		}
+		c = b + an/c
+		if math.Abs(c) < gammaTiny {
+			c = gammaTiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < gammaEpsilon {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lgamma) * h
+}
@@ -0,0 +1,86 @@
+package stats_test
+
+import (
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/stats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChiSquarePValue(t *testing.T) {
+	// Critical values from chi-square tables
+	tests := []struct {
+		name      string
+		statistic float64
+		df        int
+		expected  float64
+	}{
+		{name: "5% with 1 degree of freedom", statistic: 3.841459, df: 1, expected: 0.05},
+		{name: "1% with 1 degree of freedom", statistic: 6.634897, df: 1, expected: 0.01},
+		{name: "5% with 2 degrees of freedom", statistic: 5.991465, df: 2, expected: 0.05},
+		{name: "5% with 5 degrees of freedom", statistic: 11.070498, df: 5, expected: 0.05},
+		{name: "50% with 10 degrees of freedom", statistic: 9.341818, df: 10, expected: 0.5},
+		{name: "0.1% with 30 degrees of freedom", statistic: 59.702981, df: 30, expected: 0.001},
+		{name: "no difference", statistic: 0, df: 3, expected: 1},
+		{name: "nothing to test", statistic: 4, df: 0, expected: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.InDelta(t, test.expected, stats.ChiSquarePValue(test.statistic, test.df), 1e-6)
+		})
+	}
+}
+
+func TestChiSquare(t *testing.T) {
+	tests := []struct {
+		name       string
+		categories []stats.Category
+		statistic  float64
+		df         int
+	}{
+		{
+			name:       "matches the chances",
+			categories: []stats.Category{{Observed: 10, Probability: 0.1}, {Observed: 90, Probability: 0.9}},
+			statistic:  0,
+			df:         1,
+		},
+		{
+			name:       "even split of uneven chances",
+			categories: []stats.Category{{Observed: 50, Probability: 0.1}, {Observed: 50, Probability: 0.9}},
+			// (50 - 10)² / 10 + (50 - 90)² / 90
+			statistic: 160 + 1600.0/90,
+			df:        1,
+		},
+		{
+			name:       "options without a chance are left out",
+			categories: []stats.Category{{Observed: 30, Probability: 0.25}, {Observed: 70, Probability: 0.75}, {Observed: 0, Probability: 0}},
+			// (30 - 25)² / 25 + (70 - 75)² / 75
+			statistic: 1 + 1.0/3,
+			df:        1,
+		},
+		{
+			name:       "chances are scaled to the options tested",
+			categories: []stats.Category{{Observed: 25, Probability: 0.1}, {Observed: 75, Probability: 0.3}, {Observed: 5, Probability: 0}},
+			statistic:  0,
+			df:         1,
+		},
+		{
+			name:       "single option",
+			categories: []stats.Category{{Observed: 100, Probability: 1}},
+		},
+		{
+			name:       "nothing observed",
+			categories: []stats.Category{{Probability: 0.5}, {Probability: 0.5}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := stats.ChiSquare(test.categories)
+			require.InDelta(t, test.statistic, result.Statistic, 1e-9)
+			require.Equal(t, test.df, result.DegreesOfFreedom)
+			require.InDelta(t, stats.ChiSquarePValue(test.statistic, test.df), result.PValue, 1e-12)
+		})
+	}
+}
@@ -0,0 +1,316 @@
+// Package tagexpr parses and evaluates boolean tag expressions such as `(outdoor or cheap) and not rainy`.
+//
+// Expressions are made of tag names joined with the operators and, or and not, grouped with parentheses.
+// Operators bind in the order not, and, or. Tag names and operators are case insensitive. A tag name that
+// contains spaces or parentheses, or is an operator word, can be written in double quotes.
+package tagexpr
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLength is the longest expression, in characters, that can be parsed
+const MaxLength = 500
+
+// maxDepth is how deeply parentheses and not operators can be nested
+const maxDepth = 32
+
+// ErrEmpty is returned when an expression has no terms
+var ErrEmpty = errors.New("expression is empty")
+
+// SyntaxError describes where an expression could not be parsed
+type SyntaxError struct {
+	// Position is the character, starting at 1, the error was found at
+	Position int
+	Msg      string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Position)
+}
+
+// Expr is a parsed expression
+type Expr interface {
+	// Eval reports whether an option with the tags matches the expression. Tags must be lowercase.
+	Eval(tags []string) bool
+	// String returns the expression with every operation in parentheses
+	String() string
+}
+
+type tagExpr struct {
+	name string
+}
+
+func (e tagExpr) Eval(tags []string) bool {
+	return slices.Contains(tags, e.name)
+}
+
+func (e tagExpr) String() string {
+	if strings.ContainsFunc(e.name, func(r rune) bool { return unicode.IsSpace(r) || r == '(' || r == ')' }) || isOperator(e.name) {
+		return `"` + e.name + `"`
+	}
+	return e.name
+}
+
+type notExpr struct {
+	x Expr
+}
+
+func (e notExpr) Eval(tags []string) bool {
+	return !e.x.Eval(tags)
+}
+
+func (e notExpr) String() string {
+	return "not " + e.x.String()
+}
+
+type andExpr struct {
+	left, right Expr
+}
+
+func (e andExpr) Eval(tags []string) bool {
+	return e.left.Eval(tags) && e.right.Eval(tags)
+}
+
+func (e andExpr) String() string {
+	return "(" + e.left.String() + " and " + e.right.String() + ")"
+}
+
+type orExpr struct {
+	left, right Expr
+}
+
+func (e orExpr) Eval(tags []string) bool {
+	return e.left.Eval(tags) || e.right.Eval(tags)
+}
+
+func (e orExpr) String() string {
+	return "(" + e.left.String() + " or " + e.right.String() + ")"
+}
+
+// Parse parses an expression. Errors in the expression are returned as a *SyntaxError.
+func Parse(input string) (Expr, error) {
+	if utf8.RuneCountInString(input) > MaxLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", MaxLength)
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, ErrEmpty
+	}
+
+	p := parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenTag
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// pos is the character, starting at 1, the token begins at
+	pos int
+}
+
+// isOperator reports whether a word is an operator rather than a tag name
+func isOperator(word string) bool {
+	switch word {
+	case "and", "or", "not":
+		return true
+	default:
+		return false
+	}
+}
+
+// lex splits an expression into tokens, ending with an EOF token
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i + 1})
+			i++
+		case r == '"':
+			end := slices.Index(runes[i+1:], '"')
+			if end < 0 {
+				return nil, &SyntaxError{Position: i + 1, Msg: "missing closing quote"}
+			}
+			name := strings.ToLower(strings.TrimSpace(string(runes[i+1 : i+1+end])))
+			if name == "" {
+				return nil, &SyntaxError{Position: i + 1, Msg: "empty tag name"}
+			}
+			tokens = append(tokens, token{kind: tokenTag, text: name, pos: i + 1})
+			i += end + 2
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			word := strings.ToLower(string(runes[start:i]))
+			tok := token{kind: tokenTag, text: word, pos: start + 1}
+			switch word {
+			case "and":
+				tok.kind = tokenAnd
+			case "or":
+				tok.kind = tokenOr
+			case "not":
+				tok.kind = tokenNot
+			}
+			tokens = append(tokens, tok)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+// parser is a recursive descent parser over the grammar
+//
+//	or   = and { "or" and }
+//	and  = not { "and" not }
+//	not  = "not" not | term
+//	term = tag | "(" or ")"
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// unexpected returns the error for a token that cannot appear where it was found
+func (p *parser) unexpected(tok token) error {
+	switch tok.kind {
+	case tokenEOF:
+		return &SyntaxError{Position: tok.pos, Msg: "unexpected end of expression"}
+	case tokenRParen:
+		return &SyntaxError{Position: tok.pos, Msg: "unexpected )"}
+	default:
+		return &SyntaxError{Position: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+}
+
+// enter tracks nesting so deeply nested input cannot exhaust the stack
+func (p *parser) enter(tok token) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return &SyntaxError{Position: tok.pos, Msg: "expression is nested too deeply"}
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	tok := p.peek()
+	if tok.kind != tokenNot {
+		return p.parseTerm()
+	}
+
+	p.next()
+	if err := p.enter(tok); err != nil {
+		return nil, err
+	}
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	p.depth--
+	return notExpr{x: x}, nil
+}
+
+func (p *parser) parseTerm() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenTag:
+		return tagExpr{name: tok.text}, nil
+	case tokenLParen:
+		if err := p.enter(tok); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			if closing.kind == tokenEOF {
+				return nil, &SyntaxError{Position: tok.pos, Msg: "missing closing parenthesis"}
+			}
+			return nil, p.unexpected(closing)
+		}
+		p.depth--
+		return expr, nil
+	default:
+		return nil, p.unexpected(tok)
+	}
+}
@@ -0,0 +1,95 @@
+package tagexpr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/tagexpr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "tag", input: "outdoor", expected: "outdoor"},
+		{name: "lowercases", input: "OutDoor AND Cheap", expected: "(outdoor and cheap)"},
+		{name: "and binds tighter than or", input: "a or b and c", expected: "(a or (b and c))"},
+		{name: "not binds tightest", input: "not a and b", expected: "(not a and b)"},
+		{name: "left associative", input: "a or b or c", expected: "((a or b) or c)"},
+		{name: "parentheses", input: "(outdoor or cheap) and not rainy", expected: "((outdoor or cheap) and not rainy)"},
+		{name: "parentheses without spaces", input: "(a)and(b)", expected: "(a and b)"},
+		{name: "double not", input: "not not a", expected: "not not a"},
+		{name: "quoted tag", input: `"board games" or "NOT"`, expected: `("board games" or "not")`},
+		{name: "tag with symbols", input: "r&b or sci-fi", expected: "(r&b or sci-fi)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expr, err := tagexpr.Parse(test.input)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, expr.String())
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "missing operand", input: "outdoor and", expected: "unexpected end of expression at position 12"},
+		{name: "leading operator", input: "or outdoor", expected: `unexpected "or" at position 1`},
+		{name: "missing operator", input: "outdoor cheap", expected: `unexpected "cheap" at position 9`},
+		{name: "unclosed parenthesis", input: "(outdoor or cheap", expected: "missing closing parenthesis at position 1"},
+		{name: "extra parenthesis", input: "outdoor)", expected: "unexpected ) at position 8"},
+		{name: "empty parentheses", input: "()", expected: "unexpected ) at position 2"},
+		{name: "unclosed quote", input: `outdoor and "board games`, expected: "missing closing quote at position 13"},
+		{name: "empty quote", input: `""`, expected: "empty tag name at position 1"},
+		{name: "too deep", input: strings.Repeat("(", 40) + "a" + strings.Repeat(")", 40), expected: "expression is nested too deeply at position 33"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := tagexpr.Parse(test.input)
+			var syntaxErr *tagexpr.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	_, err := tagexpr.Parse("  ")
+	require.True(t, errors.Is(err, tagexpr.ErrEmpty))
+
+	_, err = tagexpr.Parse(strings.Repeat("a or ", tagexpr.MaxLength))
+	require.Error(t, err)
+}
+
+func TestEval(t *testing.T) {
+	expr, err := tagexpr.Parse("(outdoor or cheap) and not rainy")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		tags     []string
+		expected bool
+	}{
+		{name: "outdoor", tags: []string{"outdoor"}, expected: true},
+		{name: "cheap", tags: []string{"cheap", "indoor"}, expected: true},
+		{name: "rainy", tags: []string{"outdoor", "rainy"}, expected: false},
+		{name: "neither", tags: []string{"indoor"}, expected: false},
+		{name: "untagged", tags: nil, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, expr.Eval(test.tags))
+		})
+	}
+}
@@ -0,0 +1,418 @@
+// Package transfer reads and writes option lists as JSON, CSV or YAML for backups and imports.
+package transfer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a file format option lists can be written in
+type Format string
+
+// Supported formats
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatYAML Format = "yaml"
+)
+
+// MaxRows is the most options a single file can hold
+const MaxRows = 500
+
+var (
+	ErrUnknownFormat = errors.New("format must be json, csv or yaml")
+	ErrTooManyRows   = fmt.Errorf("a file can have at most %d options", MaxRows)
+	errMissingName   = errors.New("csv header must have a name column")
+	errDuration      = errors.New("duration_minutes must be a whole number")
+	errWeight        = errors.New("weight must be a whole number")
+	errCooldown      = errors.New("cooldown_minutes must be a whole number")
+	errAvailability  = errors.New("availability must be windows like mon,tue 11:00-14:00 separated by ;")
+)
+
+// csvHeader is the column order CSV files are written with
+var csvHeader = []string{"name", "duration_minutes", "weight", "tags", "description", "links", "cooldown_minutes", "availability"}
+
+// Option is an option as it appears in a file. Fields are pointers so missing values can be told apart from zero.
+type Option struct {
+	Name            string   `json:"name" yaml:"name"`
+	DurationMinutes *int64   `json:"duration_minutes" yaml:"duration_minutes,omitempty"`
+	Weight          *int64   `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Tags            []string `json:"tags" yaml:"tags,omitempty"`
+	// Description is Markdown describing the option
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Links are web addresses about the option
+	Links []string `json:"links,omitempty" yaml:"links,omitempty"`
+	// CooldownMinutes is how long after being picked the option sits out of spins
+	CooldownMinutes *int64 `json:"cooldown_minutes,omitempty" yaml:"cooldown_minutes,omitempty"`
+	// Availability holds the windows the option can come up in. No windows means any time.
+	Availability []Window `json:"availability,omitempty" yaml:"availability,omitempty"`
+}
+
+// Window is when an option is available, written the same way as in the API. Days are names such as mon,
+// and start and end are HH:MM times that are both left out for a window lasting all day.
+type Window struct {
+	Days  []string `json:"days" yaml:"days,flow"`
+	Start string   `json:"start,omitempty" yaml:"start,omitempty"`
+	End   string   `json:"end,omitempty" yaml:"end,omitempty"`
+}
+
+// Document is the top level of a JSON or YAML file
+type Document struct {
+	Wheel   string   `json:"wheel,omitempty" yaml:"wheel,omitempty"`
+	Options []Option `json:"options" yaml:"options"`
+}
+
+// Row is one option read from a file. Err is set if the row could not be read.
+type Row struct {
+	Number int
+	Option Option
+	Err    error
+}
+
+// ParseFormat returns the format with the given name or file extension
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), ".")) {
+	case "json":
+		return FormatJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// FormatFromContentType returns the format matching a Content-Type header
+func FormatFromContentType(contentType string) (Format, error) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "application/json":
+		return FormatJSON, nil
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return FormatYAML, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ContentType returns the media type files of the format are served with
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatYAML:
+		return "application/yaml"
+	default:
+		return "application/json"
+	}
+}
+
+// Encode writes the document in the format
+func Encode(w io.Writer, f Format, doc Document) error {
+	if doc.Options == nil {
+		doc.Options = []Option{}
+	}
+
+	switch f {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(doc)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case FormatCSV:
+		return encodeCSV(w, doc.Options)
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+func encodeCSV(w io.Writer, options []Option) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, opt := range options {
+		record := []string{
+			escapeFormula(opt.Name),
+			"",
+			"",
+			escapeFormula(strings.Join(opt.Tags, ",")),
+			escapeFormula(opt.Description),
+			escapeFormula(strings.Join(opt.Links, " ")),
+			"",
+			formatWindows(opt.Availability),
+		}
+		if opt.DurationMinutes != nil {
+			record[1] = strconv.FormatInt(*opt.DurationMinutes, 10)
+		}
+		if opt.Weight != nil {
+			record[2] = strconv.FormatInt(*opt.Weight, 10)
+		}
+		if opt.CooldownMinutes != nil {
+			record[6] = strconv.FormatInt(*opt.CooldownMinutes, 10)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Decode reads the options in a file. A row that cannot be read is returned with Err set
+// so the rest of the file can still be imported. An error is returned if the file as a whole is unreadable.
+func Decode(r io.Reader, f Format) ([]Row, error) {
+	var rows []Row
+	var err error
+	switch f {
+	case FormatJSON:
+		rows, err = decodeJSON(r)
+	case FormatYAML:
+		rows, err = decodeYAML(r)
+	case FormatCSV:
+		rows, err = decodeCSV(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxRows {
+		return nil, ErrTooManyRows
+	}
+	return rows, nil
+}
+
+// decodeJSON reads either a document or a bare array of options
+func decodeJSON(r io.Reader) ([]Row, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &items)
+	} else {
+		var doc struct {
+			Options []json.RawMessage `json:"options"`
+		}
+		err = json.Unmarshal(trimmed, &doc)
+		items = doc.Options
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+
+	rows := make([]Row, len(items))
+	for i, item := range items {
+		rows[i].Number = i + 1
+		decoder := json.NewDecoder(bytes.NewReader(item))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&rows[i].Option); err != nil {
+			rows[i].Err = fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	return rows, nil
+}
+
+// decodeYAML reads either a document or a bare list of options
+func decodeYAML(r io.Reader) ([]Row, error) {
+	var root yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&root); err != nil {
+		if errors.Is(err, io.EOF) {
+			return []Row{}, nil
+		}
+		return nil, fmt.Errorf("invalid yaml: %w", err)
+	}
+
+	node := &root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind == yaml.MappingNode {
+		var doc struct {
+			Options yaml.Node `yaml:"options"`
+		}
+		if err := node.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("invalid yaml: %w", err)
+		}
+		node = &doc.Options
+	}
+	if node.Kind == 0 {
+		return []Row{}, nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		return nil, errors.New("invalid yaml: options must be a list")
+	}
+
+	rows := make([]Row, len(node.Content))
+	for i, item := range node.Content {
+		rows[i].Number = i + 1
+		if err := item.Decode(&rows[i].Option); err != nil {
+			rows[i].Err = fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	return rows, nil
+}
+
+// decodeCSV reads a file with a header row. Columns are matched by name, tags are comma separated, links are
+// space separated and availability windows are written as parseWindows reads them. Cells escaped against
+// formulas on export are read back as they were.
+func decodeCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []Row{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errMissingName
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+		if len(rows) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+
+		row := Row{Number: len(rows) + 1}
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row.Option.Name = unescapeFormula(field("name"))
+		row.Option.Description = unescapeFormula(field("description"))
+		if tags := unescapeFormula(field("tags")); tags != "" {
+			row.Option.Tags = strings.Split(tags, ",")
+		}
+		row.Option.Links = strings.Fields(unescapeFormula(field("links")))
+		if row.Option.DurationMinutes, err = parseInt(field("duration_minutes")); err != nil {
+			row.Err = errDuration
+		} else if row.Option.Weight, err = parseInt(field("weight")); err != nil {
+			row.Err = errWeight
+		} else if row.Option.CooldownMinutes, err = parseInt(field("cooldown_minutes")); err != nil {
+			row.Err = errCooldown
+		} else if row.Option.Availability, err = parseWindows(field("availability")); err != nil {
+			row.Err = errAvailability
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// formatWindows writes availability windows for a CSV cell, such as mon,tue 11:00-14:00; sat,sun
+func formatWindows(windows []Window) string {
+	parts := make([]string, len(windows))
+	for i, w := range windows {
+		parts[i] = strings.Join(w.Days, ",")
+		if w.Start != "" || w.End != "" {
+			parts[i] += " " + w.Start + "-" + w.End
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseWindows reads availability windows written by formatWindows. The days and times are checked when the
+// option is validated, so only the layout of the cell is checked here.
+func parseWindows(cell string) ([]Window, error) {
+	var windows []Window
+	for part := range strings.SplitSeq(cell, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// The hours come last and are the only part with a colon
+		var w Window
+		if hours := fields[len(fields)-1]; strings.Contains(hours, ":") {
+			var ok bool
+			if w.Start, w.End, ok = strings.Cut(hours, "-"); !ok {
+				return nil, errAvailability
+			}
+			fields = fields[:len(fields)-1]
+		}
+		for day := range strings.SplitSeq(strings.Join(fields, ","), ",") {
+			if day = strings.TrimSpace(day); day != "" {
+				w.Days = append(w.Days, day)
+			}
+		}
+		if len(w.Days) == 0 {
+			return nil, errAvailability
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// formulaPrefixes are the characters that make a spreadsheet read a cell starting with them as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula quotes a cell that a spreadsheet would read as a formula with a leading apostrophe,
+// so opening an export cannot run anything
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeFormula removes the apostrophe escapeFormula adds, so exports import as they were
+func unescapeFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+// parseInt parses an optional integer, returning nil for an empty string
+func parseInt(s string) (*int64, error) {
+	if s == "" {
+		return nil, nil //nolint:nilnil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
@@ -0,0 +1,410 @@
+package transfer_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Piszmog/make-a-decision/internal/transfer"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr(v int64) *int64 {
+	return &v
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   transfer.Format
+		input    string
+		expected []transfer.Option
+		errors   []bool
+	}{
+		{
+			name:     "json document",
+			format:   transfer.FormatJSON,
+			input:    `{"wheel": "Weekend", "options": [{"name": "Hike", "duration_minutes": 90, "weight": 3, "tags": ["outdoor"]}]}`,
+			expected: []transfer.Option{{Name: "Hike", DurationMinutes: ptr(90), Weight: ptr(3), Tags: []string{"outdoor"}}},
+		},
+		{
+			name:     "json list",
+			format:   transfer.FormatJSON,
+			input:    `[{"name": "Hike"}, {"name": "Read", "description": "A chapter", "links": ["https://example.com"]}]`,
+			expected: []transfer.Option{{Name: "Hike"}, {Name: "Read", Description: "A chapter", Links: []string{"https://example.com"}}},
+		},
+		{
+			name:     "json row errors",
+			format:   transfer.FormatJSON,
+			input:    `[{"name": "Hike", "colour": "red"}, {"name": "Read", "weight": "high"}, {"name": "Cook"}]`,
+			expected: []transfer.Option{{Name: "Hike"}, {Name: "Read"}, {Name: "Cook"}},
+			errors:   []bool{true, true, false},
+		},
+		{
+			name:     "yaml document",
+			format:   transfer.FormatYAML,
+			input:    "wheel: Weekend\noptions:\n  - name: Hike\n    weight: 3\n    tags: [outdoor]\n",
+			expected: []transfer.Option{{Name: "Hike", Weight: ptr(3), Tags: []string{"outdoor"}}},
+		},
+		{
+			name:   "json cooldown and availability",
+			format: transfer.FormatJSON,
+			input:  `[{"name": "Lunch out", "cooldown_minutes": 1440, "availability": [{"days": ["mon", "fri"], "start": "11:00", "end": "14:00"}]}]`,
+			expected: []transfer.Option{{
+				Name:            "Lunch out",
+				CooldownMinutes: ptr(1440),
+				Availability:    []transfer.Window{{Days: []string{"mon", "fri"}, Start: "11:00", End: "14:00"}},
+			}},
+		},
+		{
+			name:     "yaml list",
+			format:   transfer.FormatYAML,
+			input:    "- name: Hike\n- name: Read\n  duration_minutes: 30\n",
+			expected: []transfer.Option{{Name: "Hike"}, {Name: "Read", DurationMinutes: ptr(30)}},
+		},
+		{
+			name:     "yaml row error",
+			format:   transfer.FormatYAML,
+			input:    "- name: Hike\n  weight: high\n- name: Read\n",
+			expected: []transfer.Option{{Name: "Hike"}, {Name: "Read"}},
+			errors:   []bool{true, false},
+		},
+		{
+			name:   "yaml cooldown and availability",
+			format: transfer.FormatYAML,
+			input:  "- name: Lunch out\n  cooldown_minutes: 1440\n  availability:\n    - days: [sat, sun]\n",
+			expected: []transfer.Option{{
+				Name:            "Lunch out",
+				CooldownMinutes: ptr(1440),
+				Availability:    []transfer.Window{{Days: []string{"sat", "sun"}}},
+			}},
+		},
+		{
+			name:     "empty yaml",
+			format:   transfer.FormatYAML,
+			input:    "",
+			expected: []transfer.Option{},
+		},
+		{
+			name:   "csv",
+			format: transfer.FormatCSV,
+			input:  "name,duration_minutes,weight,tags,description,links\nHike,90,3,\"outdoor,cheap\",Bring water,https://example.com/trail https://example.com/map\n",
+			expected: []transfer.Option{{
+				Name:            "Hike",
+				DurationMinutes: ptr(90),
+				Weight:          ptr(3),
+				Tags:            []string{"outdoor", "cheap"},
+				Description:     "Bring water",
+				Links:           []string{"https://example.com/trail", "https://example.com/map"},
+			}},
+		},
+		{
+			name:   "csv cooldown and availability",
+			format: transfer.FormatCSV,
+			input:  "name,cooldown_minutes,availability\nLunch out,1440,\"mon, fri 11:00-14:00;sat,sun ; \"\nNap,,\n",
+			expected: []transfer.Option{
+				{
+					Name:            "Lunch out",
+					CooldownMinutes: ptr(1440),
+					Availability:    []transfer.Window{{Days: []string{"mon", "fri"}, Start: "11:00", End: "14:00"}, {Days: []string{"sat", "sun"}}},
+					Links:           []string{},
+				},
+				{Name: "Nap", Links: []string{}},
+			},
+		},
+		{
+			name:     "csv columns in any order",
+			format:   transfer.FormatCSV,
+			input:    "\ufeffWeight, Name ,tags\n2,Hike,outdoor\n",
+			expected: []transfer.Option{{Name: "Hike", Weight: ptr(2), Tags: []string{"outdoor"}, Links: []string{}}},
+		},
+		{
+			name:     "csv missing columns",
+			format:   transfer.FormatCSV,
+			input:    "name\nHike\nRead,extra\n",
+			expected: []transfer.Option{{Name: "Hike", Links: []string{}}, {Name: "Read", Links: []string{}}},
+		},
+		{
+			name:     "csv short rows",
+			format:   transfer.FormatCSV,
+			input:    "name,duration_minutes,weight\nHike\n",
+			expected: []transfer.Option{{Name: "Hike", Links: []string{}}},
+		},
+		{
+			name:     "csv row errors",
+			format:   transfer.FormatCSV,
+			input:    "name,duration_minutes,weight,cooldown_minutes,availability\nHike,an hour,1,,\nRead,30,lots,,\nSwim,30,1,a day,\nRun,30,1,,11:00-14:00\nCook,30,1,,\n",
+			expected: []transfer.Option{{Name: "Hike"}, {Name: "Read"}, {Name: "Swim"}, {Name: "Run"}, {Name: "Cook", DurationMinutes: ptr(30), Weight: ptr(1), Links: []string{}}},
+			errors:   []bool{true, true, true, true, false},
+		},
+		{
+			name:     "csv escaped formulas",
+			format:   transfer.FormatCSV,
+			input:    "name,tags,description\n'=SUM(A1),'@home,'- one\n'plain,tags,'' quoted\n",
+			expected: []transfer.Option{{Name: "=SUM(A1)", Tags: []string{"@home"}, Description: "- one", Links: []string{}}, {Name: "'plain", Tags: []string{"tags"}, Description: "'' quoted", Links: []string{}}},
+		},
+		{
+			name:     "empty csv",
+			format:   transfer.FormatCSV,
+			input:    "",
+			expected: []transfer.Option{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, err := transfer.Decode(strings.NewReader(test.input), test.format)
+			require.NoError(t, err)
+			require.Len(t, rows, len(test.expected))
+			for i, row := range rows {
+				require.Equal(t, i+1, row.Number)
+				hasError := test.errors != nil && test.errors[i]
+				require.Equal(t, hasError, row.Err != nil, "row %d error: %v", row.Number, row.Err)
+				if hasError {
+					// Only the name is kept to report the row
+					require.Equal(t, test.expected[i].Name, row.Option.Name)
+					continue
+				}
+				require.Equal(t, test.expected[i], row.Option)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsFile(t *testing.T) {
+	tooMany := "name\n" + strings.Repeat("Hike\n", transfer.MaxRows+1)
+	tooManyJSON := "[" + strings.TrimSuffix(strings.Repeat(`{"name": "Hike"},`, transfer.MaxRows+1), ",") + "]"
+
+	tests := []struct {
+		name   string
+		format transfer.Format
+		input  string
+		err    error
+	}{
+		{name: "invalid json", format: transfer.FormatJSON, input: `{"options": `},
+		{name: "invalid yaml", format: transfer.FormatYAML, input: "options: [\n"},
+		{name: "yaml options not a list", format: transfer.FormatYAML, input: "options: Hike\n"},
+		{name: "csv without a name column", format: transfer.FormatCSV, input: "title,weight\nHike,1\n"},
+		{name: "too many csv rows", format: transfer.FormatCSV, input: tooMany, err: transfer.ErrTooManyRows},
+		{name: "too many json rows", format: transfer.FormatJSON, input: tooManyJSON, err: transfer.ErrTooManyRows},
+		{name: "unknown format", format: transfer.Format("xml"), input: "<options/>", err: transfer.ErrUnknownFormat},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := transfer.Decode(strings.NewReader(test.input), test.format)
+			require.Error(t, err)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestDecodeMaxRows(t *testing.T) {
+	rows, err := transfer.Decode(strings.NewReader("name\n"+strings.Repeat("Hike\n", transfer.MaxRows)), transfer.FormatCSV)
+	require.NoError(t, err)
+	require.Len(t, rows, transfer.MaxRows)
+}
+
+func TestEncode(t *testing.T) {
+	doc := transfer.Document{
+		Wheel: "Weekend",
+		Options: []transfer.Option{
+			{
+				Name:            "Hike",
+				DurationMinutes: ptr(90),
+				Weight:          ptr(3),
+				Tags:            []string{"outdoor", "cheap"},
+				Links:           []string{"https://example.com/trail"},
+				CooldownMinutes: ptr(60),
+				Availability:    []transfer.Window{{Days: []string{"mon", "tue"}, Start: "11:00", End: "14:00"}, {Days: []string{"sat", "sun"}}},
+			},
+			{Name: "Read", Weight: ptr(1), Description: "A chapter,\nthen sleep"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		format   transfer.Format
+		expected string
+	}{
+		{
+			name:   "csv",
+			format: transfer.FormatCSV,
+			expected: "name,duration_minutes,weight,tags,description,links,cooldown_minutes,availability\n" +
+				"Hike,90,3,\"outdoor,cheap\",,https://example.com/trail,60,\"mon,tue 11:00-14:00; sat,sun\"\n" +
+				"Read,,1,,\"A chapter,\nthen sleep\",,,\n",
+		},
+		{
+			name:   "json",
+			format: transfer.FormatJSON,
+			expected: `{
+  "wheel": "Weekend",
+  "options": [
+    {
+      "name": "Hike",
+      "duration_minutes": 90,
+      "weight": 3,
+      "tags": [
+        "outdoor",
+        "cheap"
+      ],
+      "links": [
+        "https://example.com/trail"
+      ],
+      "cooldown_minutes": 60,
+      "availability": [
+        {
+          "days": [
+            "mon",
+            "tue"
+          ],
+          "start": "11:00",
+          "end": "14:00"
+        },
+        {
+          "days": [
+            "sat",
+            "sun"
+          ]
+        }
+      ]
+    },
+    {
+      "name": "Read",
+      "duration_minutes": null,
+      "weight": 1,
+      "tags": null,
+      "description": "A chapter,\nthen sleep"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:   "yaml",
+			format: transfer.FormatYAML,
+			expected: `wheel: Weekend
+options:
+  - name: Hike
+    duration_minutes: 90
+    weight: 3
+    tags:
+      - outdoor
+      - cheap
+    links:
+      - https://example.com/trail
+    cooldown_minutes: 60
+    availability:
+      - days: [mon, tue]
+        start: "11:00"
+        end: "14:00"
+      - days: [sat, sun]
+  - name: Read
+    weight: 1
+    description: |-
+      A chapter,
+      then sleep
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, transfer.Encode(&buf, test.format, doc))
+			require.Equal(t, test.expected, buf.String())
+
+			// What is written reads back the same
+			rows, err := transfer.Decode(&buf, test.format)
+			require.NoError(t, err)
+			require.Len(t, rows, len(doc.Options))
+			for i, row := range rows {
+				require.NoError(t, row.Err)
+				require.Equal(t, doc.Options[i].Name, row.Option.Name)
+				require.Equal(t, doc.Options[i].Description, row.Option.Description)
+				require.ElementsMatch(t, doc.Options[i].Links, row.Option.Links)
+				require.Equal(t, doc.Options[i].CooldownMinutes, row.Option.CooldownMinutes)
+				require.Equal(t, doc.Options[i].Availability, row.Option.Availability)
+			}
+		})
+	}
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, transfer.Encode(&buf, transfer.FormatJSON, transfer.Document{}))
+	require.Equal(t, "{\n  \"options\": []\n}\n", buf.String())
+
+	buf.Reset()
+	require.ErrorIs(t, transfer.Encode(&buf, transfer.Format("xml"), transfer.Document{}), transfer.ErrUnknownFormat)
+}
+
+func TestEncodeCSVEscapesFormulas(t *testing.T) {
+	doc := transfer.Document{Options: []transfer.Option{
+		{Name: "=HYPERLINK(\"https://evil.example\")", Tags: []string{"+tag"}, Description: "- step one\n- step two"},
+		{Name: "@SUM(A1)", Tags: []string{"ok", "-x"}, Description: "Fine"},
+		{Name: "Plain", Description: "'quoted"},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, transfer.Encode(&buf, transfer.FormatCSV, doc))
+	require.Equal(t,
+		"name,duration_minutes,weight,tags,description,links,cooldown_minutes,availability\n"+
+			"\"'=HYPERLINK(\"\"https://evil.example\"\")\",,,'+tag,\"'- step one\n- step two\",,,\n"+
+			"'@SUM(A1),,,\"ok,-x\",Fine,,,\n"+
+			"Plain,,,,'quoted,,,\n",
+		buf.String())
+
+	rows, err := transfer.Decode(&buf, transfer.FormatCSV)
+	require.NoError(t, err)
+	for i, row := range rows {
+		require.Equal(t, doc.Options[i].Name, row.Option.Name)
+		require.Equal(t, doc.Options[i].Description, row.Option.Description)
+	}
+	require.Equal(t, []string{"+tag"}, rows[0].Option.Tags)
+	require.Equal(t, []string{"ok", "-x"}, rows[1].Option.Tags)
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected transfer.Format
+		err      error
+	}{
+		{input: "json", expected: transfer.FormatJSON},
+		{input: ".CSV", expected: transfer.FormatCSV},
+		{input: " yml ", expected: transfer.FormatYAML},
+		{input: "xml", err: transfer.ErrUnknownFormat},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			actual, err := transfer.ParseFormat(test.input)
+			require.ErrorIs(t, err, test.err)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestFormatFromContentType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected transfer.Format
+		err      error
+	}{
+		{input: "application/json; charset=utf-8", expected: transfer.FormatJSON},
+		{input: "text/csv", expected: transfer.FormatCSV},
+		{input: "application/x-yaml", expected: transfer.FormatYAML},
+		{input: "text/plain", err: transfer.ErrUnknownFormat},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			actual, err := transfer.FormatFromContentType(test.input)
+			require.ErrorIs(t, err, test.err)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}